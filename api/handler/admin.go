@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"b2w/swapi-challenge/domain"
+	"b2w/swapi-challenge/domain/entity/planet"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const importPlanetsPath = "/v1/admin/import-planets"
+
+// CreateAdminRoutes registra as rotas administrativas das dependências informadas
+func CreateAdminRoutes(router gin.IRouter, refresher planet.Refresher, importer planet.CatalogImporter) {
+	admin := router.Group("/v1/admin")
+	{
+		if refresher != nil {
+			admin.POST("/refresh-apparitions", refreshApparitions(refresher))
+		}
+		if importer != nil {
+			admin.POST("/import-planets", importPlanets(importer))
+			admin.GET("/import-planets", getImportPlanetsStatus(importer))
+		}
+	}
+}
+
+func refreshApparitions(refresher planet.Refresher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := refresher.TriggerRefresh()
+		if err != nil {
+			if errors.Is(err, domain.ErrConflict) {
+				respondError(c, domain.Wrap(err, domain.CodeConflict, "An apparitions refresh is already running"))
+			} else {
+				respondError(c, domain.Wrap(err, domain.CodeInternal, "Error while starting apparitions refresh"))
+			}
+
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"data": gin.H{"status": "started"}})
+	}
+}
+
+// importPlanets inicia a importação do catálogo da SWAPI em segundo plano. A
+// importação pode demorar mais que as requisições, então o andamento e o
+// resumo são consultados em getImportPlanetsStatus.
+func importPlanets(importer planet.CatalogImporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := importer.TriggerImport()
+		if err != nil {
+			if errors.Is(err, domain.ErrConflict) {
+				respondError(c, domain.Wrap(err, domain.CodeConflict, "A planets import is already running"))
+			} else {
+				respondError(c, domain.Wrap(err, domain.CodeInternal, "Error while starting planets import"))
+			}
+
+			return
+		}
+
+		c.Header("Location", importPlanetsPath)
+		c.JSON(http.StatusAccepted, gin.H{"data": importer.Status()})
+	}
+}
+
+// getImportPlanetsStatus responde com o estado da importação em andamento ou
+// da última executada
+func getImportPlanetsStatus(importer planet.CatalogImporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": importer.Status()})
+	}
+}
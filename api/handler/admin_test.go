@@ -0,0 +1,216 @@
+package handler_test
+
+import (
+	"b2w/swapi-challenge/api"
+	"b2w/swapi-challenge/api/middleware"
+	"b2w/swapi-challenge/config"
+	"b2w/swapi-challenge/domain"
+	"b2w/swapi-challenge/domain/entity/planet"
+	"b2w/swapi-challenge/domain/entity/planet/mocks"
+	"b2w/swapi-challenge/infra/auth"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshApparitions(t *testing.T) {
+	manager := &mocks.Manager{}
+	refresher := &mocks.Refresher{}
+
+	router := api.SetupRouter(manager, api.RouterOptions{Refresher: refresher})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	url := fmt.Sprintf("%s/v1/admin/refresh-apparitions", ts.URL)
+
+	refresher.
+		On("TriggerRefresh").
+		Return(nil).
+		Once()
+
+	refresher.
+		On("TriggerRefresh").
+		Return(domain.ErrConflict).
+		Once()
+
+	refresher.
+		On("TriggerRefresh").
+		Return(errors.New("refresh error")).
+		Once()
+
+	// Testing refresh started
+	resp, err := http.Post(url, "application/json", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing refresh already running
+	resp, err = http.Post(url, "application/json", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing refresh error
+	resp, err = http.Post(url, "application/json", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestImportPlanets(t *testing.T) {
+	manager := &mocks.Manager{}
+	importer := &mocks.CatalogImporter{}
+
+	router := api.SetupRouter(manager, api.RouterOptions{CatalogImporter: importer})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	url := fmt.Sprintf("%s/v1/admin/import-planets", ts.URL)
+
+	startedAt := time.Date(2020, 8, 9, 12, 0, 0, 0, time.UTC)
+	finishedAt := startedAt.Add(time.Minute)
+	running := planet.CatalogImportStatus{State: planet.CatalogImportRunning, StartedAt: &startedAt}
+	finished := planet.CatalogImportStatus{
+		State:      planet.CatalogImportFinished,
+		StartedAt:  &startedAt,
+		FinishedAt: &finishedAt,
+		Result:     &planet.CatalogImportResult{Created: 58, Updated: 1, Skipped: 1},
+	}
+
+	importer.
+		On("TriggerImport").
+		Return(nil).
+		Once()
+
+	importer.
+		On("TriggerImport").
+		Return(domain.ErrConflict).
+		Once()
+
+	importer.
+		On("TriggerImport").
+		Return(errors.New("import error")).
+		Once()
+
+	importer.
+		On("Status").
+		Return(running).
+		Once()
+
+	importer.
+		On("Status").
+		Return(finished).
+		Once()
+
+	type statusBody struct {
+		Data planet.CatalogImportStatus `json:"data"`
+	}
+
+	// Testing import started in background
+	resp, err := http.Post(url, "application/json", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, "/v1/admin/import-planets", resp.Header.Get("Location"))
+
+	var body statusBody
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	assert.Nil(t, err)
+	assert.Equal(t, planet.CatalogImportRunning, body.Data.State)
+	assert.Nil(t, body.Data.Result)
+	resp.Body.Close()
+
+	// Testing import already running
+	resp, err = http.Post(url, "application/json", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing import error
+	resp, err = http.Post(url, "application/json", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing import status with the summary
+	resp, err = http.Get(url)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body = statusBody{}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	assert.Nil(t, err)
+	assert.Equal(t, planet.CatalogImportFinished, body.Data.State)
+	assert.Equal(t, finished.Result, body.Data.Result)
+	assert.True(t, finishedAt.Equal(*body.Data.FinishedAt))
+	resp.Body.Close()
+
+	// Testing refresh route not registered without a refresher
+	resp, err = http.Post(fmt.Sprintf("%s/v1/admin/refresh-apparitions", ts.URL), "application/json", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+
+	importer.AssertExpectations(t)
+}
+
+func TestAdminRoutesWithAuth(t *testing.T) {
+	manager := &mocks.Manager{}
+	importer := &mocks.CatalogImporter{}
+
+	authenticator, err := auth.NewAuthenticator(config.Auth{
+		APIKeys: []config.APIKey{
+			{Name: "writer", Key: "write-key", Scopes: []string{auth.ScopeRead, auth.ScopeWrite}},
+			{Name: "admin", Key: "admin-key", Scopes: []string{auth.ScopeAdmin}},
+		},
+	})
+	assert.Nil(t, err)
+
+	router := api.SetupRouter(manager, api.RouterOptions{
+		CatalogImporter: importer,
+		Authenticator:   authenticator,
+		Auth:            middleware.AuthOptions{PublicReads: true},
+	})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	url := fmt.Sprintf("%s/v1/admin/import-planets", ts.URL)
+
+	importer.
+		On("TriggerImport").
+		Return(nil)
+
+	importer.
+		On("Status").
+		Return(planet.CatalogImportStatus{State: planet.CatalogImportIdle})
+
+	request := func(method, key string) *http.Response {
+		req, err := http.NewRequest(method, url, nil)
+		assert.Nil(t, err)
+		if key != "" {
+			req.Header.Set(middleware.APIKeyHeader, key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.Nil(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	// Testing public reads not applied to admin routes
+	assert.Equal(t, http.StatusUnauthorized, request("GET", "").StatusCode)
+
+	// Testing write scope not enough for admin routes
+	assert.Equal(t, http.StatusForbidden, request("POST", "write-key").StatusCode)
+	assert.Equal(t, http.StatusForbidden, request("GET", "write-key").StatusCode)
+
+	// Testing admin scope
+	assert.Equal(t, http.StatusAccepted, request("POST", "admin-key").StatusCode)
+	assert.Equal(t, http.StatusOK, request("GET", "admin-key").StatusCode)
+
+	importer.AssertNumberOfCalls(t, "TriggerImport", 1)
+}
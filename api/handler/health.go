@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"b2w/swapi-challenge/infra/health"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CreateHealthRoutes(router *gin.Engine, readiness *health.Readiness) {
+	router.GET("/healthz", getLiveness())
+	router.GET("/readyz", getReadiness(readiness))
+}
+
+// getLiveness indica apenas que o processo está respondendo, sem consultar dependências
+func getLiveness() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": gin.H{"status": "alive"}})
+	}
+}
+
+func getReadiness(readiness *health.Readiness) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := readiness.Check(c.Request.Context())
+		if !report.Ready() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"data": report})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": report})
+	}
+}
@@ -0,0 +1,122 @@
+package handler_test
+
+import (
+	"b2w/swapi-challenge/api"
+	"b2w/swapi-challenge/domain/entity/planet/mocks"
+	"b2w/swapi-challenge/infra/health"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiveness(t *testing.T) {
+	manager := &mocks.Manager{}
+
+	router := api.SetupRouter(manager, api.RouterOptions{Readiness: health.NewReadiness(time.Second)})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	// Testing alive even when not ready
+	resp, err := http.Get(fmt.Sprintf("%s/healthz", ts.URL))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestReadiness(t *testing.T) {
+	manager := &mocks.Manager{}
+
+	dbUp := true
+	readiness := health.NewReadiness(time.Second, health.Dependency{
+		Name: "mongodb",
+		Check: func(ctx context.Context) error {
+			if !dbUp {
+				return errors.New("ping error")
+			}
+			return nil
+		},
+	})
+
+	router := api.SetupRouter(manager, api.RouterOptions{Readiness: readiness})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	url := fmt.Sprintf("%s/readyz", ts.URL)
+
+	type readinessBody struct {
+		Data health.Report `json:"data"`
+	}
+
+	// Testing not ready before startup finishes
+	resp, err := http.Get(url)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing ready with the dependency breakdown
+	readiness.SetReady(true)
+	resp, err = http.Get(url)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body readinessBody
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	assert.Nil(t, err)
+	assert.Equal(t, health.StatusReady, body.Data.Status)
+	assert.Equal(t, health.StatusUp, body.Data.Dependencies["mongodb"].Status)
+
+	resp.Body.Close()
+
+	// Testing dependency down
+	dbUp = false
+	resp, err = http.Get(url)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	body = readinessBody{}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	assert.Nil(t, err)
+	assert.Equal(t, health.StatusDown, body.Data.Dependencies["mongodb"].Status)
+	assert.Equal(t, "ping error", body.Data.Dependencies["mongodb"].Error)
+
+	resp.Body.Close()
+
+	// Testing not ready while shutting down
+	dbUp = true
+	readiness.SetReady(false)
+	resp, err = http.Get(url)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	manager := &mocks.Manager{}
+
+	router := api.SetupRouter(manager, api.RouterOptions{})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	// Testing requests are exposed in the prometheus format
+	resp, err := http.Get(fmt.Sprintf("%s/v1/planets/invalid", ts.URL))
+	assert.Nil(t, err)
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s/metrics", ts.URL))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	assert.Contains(t, string(body), `http_requests_total{method="GET",route="/v1/planets/:id",status="400"}`)
+
+	resp.Body.Close()
+}
@@ -2,8 +2,12 @@ package handler
 
 import (
 	"b2w/swapi-challenge/domain"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"b2w/swapi-challenge/api/middleware"
 	"b2w/swapi-challenge/api/presenter"
 	"b2w/swapi-challenge/domain/entity/planet"
 
@@ -11,84 +15,233 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func CreatePlanetRoutes(router *gin.Engine, manager planet.Manager) {
+func CreatePlanetRoutes(router gin.IRouter, manager planet.Manager) {
 	planet := router.Group("/v1/planets")
 	{
 		planet.POST("", createPlanet(manager))
 		planet.GET("", getPlanets(manager))
 		planet.GET("/:id", getPlanet(manager))
+		planet.PUT("/:id", updatePlanet(manager))
+		planet.PATCH("/:id", patchPlanet(manager))
 		planet.DELETE("/:id", deletePlanet(manager))
+		planet.POST("/:id", postPlanetAction(manager))
+		planet.POST("/:id/restore", restorePlanet(manager))
 	}
 }
 
+var errInvalidID = domain.NewError(domain.CodeInvalidInput, "Unexpected ID format",
+	domain.FieldError{Field: "id", Message: "must be a 24 character hexadecimal string"})
+
 func createPlanet(manager planet.Manager) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var addPlanet presenter.AddPlanetCommand
-		err := c.BindJSON(&addPlanet)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Unexpected JSON format", "params": addPlanet})
+		expand, ok := parseExpand(c)
+		if !ok {
+			return
+		}
+
+		policy, ok := parseInsertPolicy(c)
+		if !ok {
+			return
+		}
+
+		addPlanet, ok := bindPlanetCommand(c)
+		if !ok {
 			return
 		}
 
 		p := addPlanet.ToModel()
-		err = manager.Insert(&p)
+		err := manager.InsertWithPolicy(c.Request.Context(), &p, policy)
 		if err != nil {
-			if err == domain.ErrConflict {
-				c.JSON(http.StatusConflict, gin.H{"error": "A planet with specified params already exists", "params": addPlanet})
-			} else if err == domain.ErrBadParamInput {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid planet input params", "params": addPlanet})
-			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while saving planet on database"})
-			}
-
+			respondError(c, planetError(err, "Error while saving planet on database"))
 			return
 		}
 
-		c.JSON(http.StatusCreated, gin.H{"data": presenter.NewPlanetResult(p)})
+		c.JSON(http.StatusCreated, gin.H{"data": presenter.NewPlanetResult(p, expand)})
+	}
+}
+
+// postPlanetAction atende às ações sobre a coleção, já que o router não
+// permite uma rota estática ao lado de /:id
+func postPlanetAction(manager planet.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch {
+		case c.Param("id") == "import":
+			setActionRoute(c, "/import")
+			importPlanet(c, manager)
+		case isBatch(c):
+			setActionRoute(c, middleware.BatchSuffix)
+			createPlanets(c, manager)
+		default:
+			RouteNotFound(c)
+		}
+	}
+}
+
+// setActionRoute registra a ação com a própria rota, como /v1/planets/import,
+// em vez da rota /:id que a atendeu
+func setActionRoute(c *gin.Context, action string) {
+	middleware.SetRoute(c, strings.TrimSuffix(c.FullPath(), "/:id")+action)
+}
+
+func importPlanet(c *gin.Context, manager planet.Manager) {
+	expand, ok := parseExpand(c)
+	if !ok {
+		return
+	}
+
+	data, err := c.GetRawData()
+	if err != nil {
+		respondError(c, domain.Wrap(err, domain.CodeInvalidInput, "Unexpected JSON format"))
+		return
 	}
+
+	cmd, err := presenter.DecodeImportPlanetCommand(data)
+	err = presenter.ValidateDecoded(err, cmd.Validate)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	p, err := manager.Import(c.Request.Context(), cmd.ToReference())
+	if err != nil {
+		respondError(c, planetError(err, "Error while importing planet"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": presenter.NewPlanetResult(p, expand)})
 }
 
 func getPlanet(manager planet.Manager) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// O router não permite uma rota estática ao lado de /:id
 		idParam := c.Param("id")
-		id, err := primitive.ObjectIDFromHex(idParam)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Unexpected ID format", "params": idParam})
+		if idParam == "trash" {
+			setActionRoute(c, "/trash")
+			getTrash(c, manager)
 			return
 		}
 
-		p, err := manager.GetById(id)
+		id, ok := parseID(c, idParam)
+		if !ok {
+			return
+		}
+
+		expand, ok := parseExpand(c)
+		if !ok {
+			return
+		}
+
+		p, err := manager.GetById(c.Request.Context(), id)
 		if err != nil {
-			if err == domain.ErrNotFound {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found", "params": idParam})
-			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while getting planet from database"})
-			}
+			respondError(c, planetError(err, "Error while getting planet from database"))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": presenter.NewPlanetResult(p, expand)})
+	}
+}
 
+func updatePlanet(manager planet.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idParam := c.Param("id")
+		id, ok := parseID(c, idParam)
+		if !ok {
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"data": presenter.NewPlanetResult(p)})
+		expand, ok := parseExpand(c)
+		if !ok {
+			return
+		}
+
+		policy, ok := parseInsertPolicy(c)
+		if !ok {
+			return
+		}
+
+		updatePlanet, ok := bindPlanetCommand(c)
+		if !ok {
+			return
+		}
+
+		p := updatePlanet.ToModel()
+		p.ID = id
+		saveUpdatedPlanet(c, manager, &p, policy, expand)
 	}
 }
 
-func deletePlanet(manager planet.Manager) gin.HandlerFunc {
+func patchPlanet(manager planet.Manager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		idParam := c.Param("id")
-		id, err := primitive.ObjectIDFromHex(idParam)
+		id, ok := parseID(c, idParam)
+		if !ok {
+			return
+		}
+
+		expand, ok := parseExpand(c)
+		if !ok {
+			return
+		}
+
+		policy, ok := parseInsertPolicy(c)
+		if !ok {
+			return
+		}
+
+		patch, err := c.GetRawData()
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Unexpected ID format", "params": idParam})
+			respondError(c, domain.Wrap(err, domain.CodeInvalidInput, "Unexpected JSON format"))
 			return
 		}
 
-		err = manager.Delete(id)
+		currentP, err := manager.GetById(c.Request.Context(), id)
 		if err != nil {
-			if err == domain.ErrNotFound {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found", "params": idParam})
-			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while removing planet from database"})
-			}
+			respondError(c, planetError(err, "Error while getting planet from database"))
+			return
+		}
 
+		currentCmd := presenter.NewAddPlanetCommand(currentP)
+		patchedPlanet, err := currentCmd.ApplyMergePatch(patch)
+		err = presenter.ValidateDecoded(err, func() error { return patchedPlanet.ValidateChanges(currentCmd) })
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		p := patchedPlanet.ToModel()
+		p.ID = id
+		saveUpdatedPlanet(c, manager, &p, policy, expand)
+	}
+}
+
+// saveUpdatedPlanet salva o planeta alterado. A política de criação só é usada
+// quando o nome muda e as aparições são buscadas novamente.
+func saveUpdatedPlanet(c *gin.Context, manager planet.Manager, p *planet.Planet, policy planet.InsertPolicy, expand presenter.Expand) {
+	err := manager.UpdateWithPolicy(c.Request.Context(), p, policy)
+	if err != nil {
+		respondError(c, planetError(err, "Error while updating planet on database"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": presenter.NewPlanetResult(*p, expand)})
+}
+
+func deletePlanet(manager planet.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isBatch(c) {
+			setActionRoute(c, middleware.BatchSuffix)
+			deletePlanets(c, manager)
+			return
+		}
+
+		id, ok := parseID(c, c.Param("id"))
+		if !ok {
+			return
+		}
+
+		err := manager.Delete(c.Request.Context(), id)
+		if err != nil {
+			respondError(c, planetError(err, "Error while removing planet from database"))
 			return
 		}
 
@@ -98,31 +251,216 @@ func deletePlanet(manager planet.Manager) gin.HandlerFunc {
 
 func getPlanets(manager planet.Manager) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		expand, ok := parseExpand(c)
+		if !ok {
+			return
+		}
+
 		name := c.Query("name")
+		if name != "" {
+			getPlanetByName(c, manager, name, expand)
+			return
+		}
 
-		var result interface{}
-		var err error
+		req, ok := parsePageRequest(c)
+		if !ok {
+			return
+		}
+
+		query, err := parsePlanetQuery(c)
+		if err != nil {
+			respondError(c, domain.Wrap(err, domain.CodeInvalidInput, "Invalid filter params"))
+			return
+		}
+		req.Query = query
+
+		page, err := manager.FindPage(c.Request.Context(), req)
+		if err != nil {
+			respondError(c, planetError(err, "Error while fetching planets from database"))
+			return
+		}
+
+		renderPage(c, page, expand)
+	}
+}
+
+func getTrash(c *gin.Context, manager planet.Manager) {
+	expand, ok := parseExpand(c)
+	if !ok {
+		return
+	}
+
+	req, ok := parsePageRequest(c)
+	if !ok {
+		return
+	}
 
-		if name == "" {
-			var pList []planet.Planet
-			pList, err = manager.FindAll()
-			result = presenter.NewPlanetResultSlice(pList)
-		} else {
-			var p planet.Planet
-			p, err = manager.GetByName(name)
-			result = presenter.NewPlanetResult(p)
+	page, err := manager.FindTrash(c.Request.Context(), req)
+	if err != nil {
+		respondError(c, planetError(err, "Error while fetching deleted planets from database"))
+		return
+	}
+
+	renderPage(c, page, expand)
+}
 
-			if err != nil && err == domain.ErrNotFound {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Planet not found", "params": name})
-				return
-			}
+func restorePlanet(manager planet.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idParam := c.Param("id")
+		id, ok := parseID(c, idParam)
+		if !ok {
+			return
 		}
 
+		expand, ok := parseExpand(c)
+		if !ok {
+			return
+		}
+
+		err := manager.Restore(c.Request.Context(), id)
+		if errors.Is(err, domain.ErrNotFound) {
+			respondError(c, domain.Wrap(err, domain.CodeNotFound, "Deleted planet not found"))
+			return
+		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while fetching planets from database"})
+			respondError(c, planetError(err, "Error while restoring planet on database"))
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"data": result})
+		p, err := manager.GetById(c.Request.Context(), id)
+		if err != nil {
+			respondError(c, domain.Wrap(err, domain.CodeInternal, "Error while getting planet from database"))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": presenter.NewPlanetResult(p, expand)})
+	}
+}
+
+// bindPlanetCommand lê e valida o comando do corpo da requisição, respondendo
+// com erro caso seja inválido
+func bindPlanetCommand(c *gin.Context) (presenter.AddPlanetCommand, bool) {
+	data, err := c.GetRawData()
+	if err != nil {
+		respondError(c, domain.Wrap(err, domain.CodeInvalidInput, "Unexpected JSON format"))
+		return presenter.AddPlanetCommand{}, false
 	}
+
+	cmd, err := presenter.DecodeAddPlanetCommand(data)
+	err = presenter.ValidateDecoded(err, cmd.Validate)
+	if err != nil {
+		respondError(c, err)
+		return cmd, false
+	}
+
+	return cmd, true
+}
+
+// parseInsertPolicy lê a política de criação, respondendo com erro caso seja inválida
+func parseInsertPolicy(c *gin.Context) (planet.InsertPolicy, bool) {
+	policy, err := planet.ParseInsertPolicy(c.Query("insert_policy"))
+	if err != nil {
+		respondInvalidParam(c, "Unexpected insert_policy value", "insert_policy", "must be one of strict, lenient or enrich")
+		return policy, false
+	}
+
+	return policy, true
+}
+
+// parseID lê o ID do planeta, respondendo com erro caso seja inválido
+func parseID(c *gin.Context, idParam string) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(idParam)
+	if err != nil {
+		respondError(c, errInvalidID)
+		return id, false
+	}
+
+	return id, true
+}
+
+// parsePageRequest lê os parâmetros de paginação, respondendo com erro caso sejam inválidos
+func parsePageRequest(c *gin.Context) (planet.PageRequest, bool) {
+	req := planet.PageRequest{Limit: planet.DefaultPageLimit}
+	if limitParam := c.Query("limit"); limitParam != "" {
+		var err error
+		req.Limit, err = strconv.ParseInt(limitParam, 10, 64)
+		if err != nil {
+			respondInvalidParam(c, "Unexpected limit format", "limit", "must be an integer")
+			return req, false
+		}
+	}
+
+	after, err := presenter.DecodeCursor(c.Query("cursor"))
+	if err != nil {
+		respondInvalidParam(c, "Unexpected cursor format", "cursor", "is not a valid cursor")
+		return req, false
+	}
+	req.After = after
+
+	return req, true
+}
+
+func renderPage(c *gin.Context, page planet.Page, expand presenter.Expand) {
+	c.JSON(http.StatusOK, gin.H{
+		"data":        presenter.NewPlanetResultSlice(page.Planets, expand),
+		"next_cursor": presenter.EncodeCursor(page.NextCursor),
+		"has_more":    page.HasMore,
+	})
+}
+
+// parseExpand lê o parâmetro expand, respondendo com erro caso seja inválido
+func parseExpand(c *gin.Context) (presenter.Expand, bool) {
+	expand, err := presenter.ParseExpand(c.Query("expand"))
+	if err != nil {
+		respondInvalidParam(c, "Unexpected expand value", "expand", err.Error())
+		return expand, false
+	}
+
+	return expand, true
+}
+
+func parsePlanetQuery(c *gin.Context) (planet.Query, error) {
+	query := planet.Query{
+		NameContains: c.Query("name_contains"),
+		Climate:      c.Query("climate"),
+		Terrain:      c.Query("terrain"),
+	}
+
+	var err error
+	if query.ApparitionsGte, err = parseInt32Query(c, "apparitions_gte"); err != nil {
+		return query, err
+	}
+	if query.ApparitionsLte, err = parseInt32Query(c, "apparitions_lte"); err != nil {
+		return query, err
+	}
+	if query.Sort, err = planet.ParseSort(c.Query("sort")); err != nil {
+		return query, err
+	}
+
+	return query, nil
+}
+
+func parseInt32Query(c *gin.Context, key string) (*int32, error) {
+	param := c.Query(key)
+	if param == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseInt(param, 10, 32)
+	if err != nil {
+		return nil, domain.NewError(domain.CodeInvalidInput, "Invalid filter params", domain.FieldError{Field: key, Message: "must be an integer"})
+	}
+
+	result := int32(value)
+	return &result, nil
+}
+
+func getPlanetByName(c *gin.Context, manager planet.Manager, name string, expand presenter.Expand) {
+	p, err := manager.GetByName(c.Request.Context(), name)
+	if err != nil {
+		respondError(c, planetError(err, "Error while fetching planets from database"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": presenter.NewPlanetResult(p, expand)})
 }
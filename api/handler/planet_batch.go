@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"b2w/swapi-challenge/api/middleware"
+	"b2w/swapi-challenge/api/presenter"
+	"b2w/swapi-challenge/domain"
+	"b2w/swapi-challenge/domain/entity/planet"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// batchParam é o valor de :id nas operações em lote. O caminho /v1/planets:batch
+// é reescrito pelo router para /v1/planets/batch.
+const batchParam = "batch"
+
+// isBatch informa se a requisição é uma operação em lote. O caminho
+// /v1/planets/batch só é aceito quando reescrito a partir da forma :batch.
+func isBatch(c *gin.Context) bool {
+	return c.Param("id") == batchParam && middleware.IsBatch(c)
+}
+
+// createPlanets cria os planetas de um array de AddPlanetCommand, respondendo
+// com o resultado de cada item
+func createPlanets(c *gin.Context, manager planet.Manager) {
+	policy, ok := parseInsertPolicy(c)
+	if !ok {
+		return
+	}
+
+	items, ok := bindBatch(c)
+	if !ok {
+		return
+	}
+
+	results := make([]presenter.BatchItemResult, len(items))
+	planets := make([]*planet.Planet, 0, len(items))
+	indexes := make([]int, 0, len(items))
+	for i, item := range items {
+		cmd, err := presenter.DecodeAddPlanetCommand(item)
+		err = presenter.ValidateDecoded(err, cmd.Validate)
+		if err != nil {
+			results[i] = batchItemError(c, err)
+			continue
+		}
+
+		p := cmd.ToModel()
+		planets = append(planets, &p)
+		indexes = append(indexes, i)
+	}
+
+	if len(planets) > 0 {
+		errs := manager.InsertManyWithPolicy(c.Request.Context(), planets, policy)
+		for j, err := range errs {
+			if err != nil {
+				results[indexes[j]] = batchItemError(c, planetError(err, "Error while saving planet on database"))
+			} else {
+				results[indexes[j]] = presenter.BatchItemResult{Status: http.StatusCreated, ID: planets[j].ID.Hex()}
+			}
+		}
+	}
+
+	c.JSON(http.StatusMultiStatus, gin.H{"data": results})
+}
+
+// deletePlanets move para a lixeira os planetas de um array de IDs,
+// respondendo com o resultado de cada item
+func deletePlanets(c *gin.Context, manager planet.Manager) {
+	items, ok := bindBatch(c)
+	if !ok {
+		return
+	}
+
+	results := make([]presenter.BatchItemResult, len(items))
+	ids := make([]primitive.ObjectID, 0, len(items))
+	indexes := make([]int, 0, len(items))
+	for i, item := range items {
+		var idParam string
+		if err := json.Unmarshal(item, &idParam); err != nil {
+			results[i] = batchItemError(c, errInvalidID)
+			continue
+		}
+
+		id, err := primitive.ObjectIDFromHex(idParam)
+		if err != nil {
+			results[i] = batchItemError(c, errInvalidID)
+			continue
+		}
+
+		ids = append(ids, id)
+		indexes = append(indexes, i)
+	}
+
+	if len(ids) > 0 {
+		errs := manager.DeleteMany(c.Request.Context(), ids)
+		for j, err := range errs {
+			if err != nil {
+				results[indexes[j]] = batchItemError(c, planetError(err, "Error while removing planet from database"))
+			} else {
+				results[indexes[j]] = presenter.BatchItemResult{Status: http.StatusNoContent, ID: ids[j].Hex()}
+			}
+		}
+	}
+
+	c.JSON(http.StatusMultiStatus, gin.H{"data": results})
+}
+
+func bindBatch(c *gin.Context) ([]json.RawMessage, bool) {
+	data, err := c.GetRawData()
+	if err != nil {
+		respondError(c, domain.Wrap(err, domain.CodeInvalidInput, "Unexpected JSON format"))
+		return nil, false
+	}
+
+	items, err := presenter.DecodeBatch(data)
+	if err != nil {
+		respondError(c, err)
+		return nil, false
+	}
+
+	return items, true
+}
+
+// batchItemError descreve o erro de um item. O caminho e o request ID já
+// são os da requisição, então não são repetidos em cada item.
+func batchItemError(c *gin.Context, err error) presenter.BatchItemResult {
+	problem := middleware.NewProblem(c, err)
+	problem.Instance = ""
+	problem.RequestID = ""
+
+	return presenter.BatchItemResult{Status: problem.Status, Error: &problem}
+}
@@ -0,0 +1,171 @@
+package handler_test
+
+import (
+	"b2w/swapi-challenge/api"
+	"b2w/swapi-challenge/api/presenter"
+	"b2w/swapi-challenge/domain"
+	"b2w/swapi-challenge/domain/entity/planet"
+	"b2w/swapi-challenge/domain/entity/planet/mocks"
+	"b2w/swapi-challenge/infra/metrics"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type batchResponseBody struct {
+	Data []presenter.BatchItemResult `json:"data"`
+}
+
+func TestCreatePlanets(t *testing.T) {
+	manager := &mocks.Manager{}
+
+	router := api.SetupRouter(manager, api.RouterOptions{})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	batchUrl := fmt.Sprintf("%s/v1/planets:batch", ts.URL)
+
+	pID := primitive.NewObjectID()
+
+	manager.
+		On("InsertManyWithPolicy", mock.Anything, mock.AnythingOfType("[]*planet.Planet"), planet.InsertStrict).
+		Return(func(ctx context.Context, planets []*planet.Planet, policy planet.InsertPolicy) []error {
+			errs := make([]error, len(planets))
+			for i, p := range planets {
+				switch p.Name {
+				case "Tatooine":
+					p.ID = pID
+				case "Alderaan":
+					errs[i] = domain.ErrConflict
+				}
+			}
+			return errs
+		})
+
+	batchRequests := metrics.HTTPRequests.WithLabelValues("POST", "/v1/planets:batch", "207")
+	batchRequestsBefore := testutil.ToFloat64(batchRequests)
+
+	// Testing per-item results of a mixed batch
+	body := `[{"name":"Tatooine"},{"climate":"arid"},{"name":"Alderaan"}]`
+	resp, err := http.Post(batchUrl+"?insert_policy=strict", "application/json", bytes.NewBufferString(body))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusMultiStatus, resp.StatusCode)
+	assert.Equal(t, batchRequestsBefore+1, testutil.ToFloat64(batchRequests))
+
+	var result batchResponseBody
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	assert.Nil(t, err)
+	resp.Body.Close()
+
+	assert.Len(t, result.Data, 3)
+	assert.Equal(t, http.StatusCreated, result.Data[0].Status)
+	assert.Equal(t, pID.Hex(), result.Data[0].ID)
+	assert.Nil(t, result.Data[0].Error)
+	assert.Equal(t, http.StatusUnprocessableEntity, result.Data[1].Status)
+	assert.Equal(t, "name", result.Data[1].Error.Errors[0].Field)
+	assert.Empty(t, result.Data[1].Error.Instance)
+	assert.Equal(t, http.StatusConflict, result.Data[2].Status)
+	assert.Equal(t, domain.CodeConflict, result.Data[2].Error.Code)
+	assert.Empty(t, result.Data[2].ID)
+
+	manager.AssertNumberOfCalls(t, "InsertManyWithPolicy", 1)
+
+	// Testing empty batch
+	resp, err = http.Post(batchUrl, "application/json", bytes.NewBufferString(`[]`))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing batch over the size limit
+	items := make([]string, presenter.MaxBatchSize+1)
+	for i := range items {
+		items[i] = fmt.Sprintf(`{"name":"Planet %d"}`, i)
+	}
+	resp, err = http.Post(batchUrl, "application/json", bytes.NewBufferString("["+strings.Join(items, ",")+"]"))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing body that is not an array
+	resp, err = http.Post(batchUrl, "application/json", bytes.NewBufferString(`{"name":"Tatooine"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing unknown policy
+	resp, err = http.Post(batchUrl+"?insert_policy=loose", "application/json", bytes.NewBufferString(body))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing the rewritten path is not a batch alias
+	resp, err = http.Post(fmt.Sprintf("%s/v1/planets/batch", ts.URL), "application/json", bytes.NewBufferString(body))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+
+	manager.AssertNumberOfCalls(t, "InsertManyWithPolicy", 1)
+}
+
+func TestDeletePlanets(t *testing.T) {
+	manager := &mocks.Manager{}
+
+	router := api.SetupRouter(manager, api.RouterOptions{})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	batchUrl := fmt.Sprintf("%s/v1/planets:batch", ts.URL)
+
+	pID := primitive.NewObjectID()
+	pIDNotFound := primitive.NewObjectID()
+
+	manager.
+		On("DeleteMany", mock.Anything, []primitive.ObjectID{pID, pIDNotFound}).
+		Return([]error{nil, domain.ErrNotFound})
+
+	// Testing per-item results of a mixed batch
+	body := fmt.Sprintf(`["%s","invalid",42,"%s"]`, pID.Hex(), pIDNotFound.Hex())
+	req, _ := http.NewRequest(http.MethodDelete, batchUrl, bytes.NewBufferString(body))
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusMultiStatus, resp.StatusCode)
+
+	var result batchResponseBody
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	assert.Nil(t, err)
+	resp.Body.Close()
+
+	assert.Len(t, result.Data, 4)
+	assert.Equal(t, http.StatusNoContent, result.Data[0].Status)
+	assert.Equal(t, pID.Hex(), result.Data[0].ID)
+	assert.Equal(t, http.StatusBadRequest, result.Data[1].Status)
+	assert.Equal(t, http.StatusBadRequest, result.Data[2].Status)
+	assert.Equal(t, http.StatusNotFound, result.Data[3].Status)
+
+	// Testing body that is not an array
+	req, _ = http.NewRequest(http.MethodDelete, batchUrl, bytes.NewBufferString(`"invalid"`))
+	resp, err = http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing the rewritten path is not a batch alias
+	req, _ = http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/v1/planets/batch", ts.URL), bytes.NewBufferString(body))
+	resp, err = http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	manager.AssertExpectations(t)
+	manager.AssertNumberOfCalls(t, "DeleteMany", 1)
+}
@@ -2,25 +2,40 @@ package handler_test
 
 import (
 	"b2w/swapi-challenge/api"
+	"b2w/swapi-challenge/api/middleware"
+	"b2w/swapi-challenge/api/presenter"
+	"b2w/swapi-challenge/config"
 	"b2w/swapi-challenge/domain"
 	"b2w/swapi-challenge/domain/entity/planet"
 	"b2w/swapi-challenge/domain/entity/planet/mocks"
+	"b2w/swapi-challenge/infra/auth"
+	"b2w/swapi-challenge/infra/logger"
+	"b2w/swapi-challenge/infra/swapi"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type responseBody struct {
-	Data interface{} `json:"data"`
-	Err  string      `json:"error"`
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor"`
+	HasMore    bool        `json:"has_more"`
+	Detail     string      `json:"detail"`
+	Code       domain.Code `json:"code"`
+	RequestID  string      `json:"request_id"`
 }
 
 func idMatchsParam(id string) interface{} {
@@ -44,7 +59,7 @@ func planetMatchsClimate(climate string) interface{} {
 func TestCreatePlanet(t *testing.T) {
 	manager := &mocks.Manager{}
 
-	router := api.SetupRouter(manager)
+	router := api.SetupRouter(manager, api.RouterOptions{})
 	ts := httptest.NewServer(router)
 	defer ts.Close()
 
@@ -55,26 +70,33 @@ func TestCreatePlanet(t *testing.T) {
 	var baInvalidPlanet = []byte(`{"climate":"temperate"}`)
 	var baConflict = []byte(`{"name":"Conflict"}`)
 	var baError = []byte(`{"name":"Error"}`)
+	var baUnavailable = []byte(`{"name":"Unavailable"}`)
 
 	manager.
-		On("Insert", planetMatchsName("Success")).
-		Return(func(p *planet.Planet) error {
+		On("InsertWithPolicy", mock.Anything, planetMatchsName("Success"), planet.InsertPolicy("")).
+		Return(func(ctx context.Context, p *planet.Planet, policy planet.InsertPolicy) error {
 			p.ID = primitive.NewObjectID()
 			return nil
 		})
 
 	manager.
-		On("Insert", planetMatchsClimate("temperate")).
+		On("InsertWithPolicy", mock.Anything, planetMatchsClimate("temperate"), planet.InsertPolicy("")).
 		Return(domain.ErrBadParamInput)
 
 	manager.
-		On("Insert", planetMatchsName("Conflict")).
+		On("InsertWithPolicy", mock.Anything, planetMatchsName("Conflict"), planet.InsertPolicy("")).
 		Return(domain.ErrConflict)
 
 	manager.
-		On("Insert", planetMatchsName("Error")).
+		On("InsertWithPolicy", mock.MatchedBy(func(ctx context.Context) bool {
+			return logger.RequestID(ctx) == "create-error"
+		}), planetMatchsName("Error"), planet.InsertPolicy("")).
 		Return(errors.New("create error"))
 
+	manager.
+		On("InsertWithPolicy", mock.Anything, planetMatchsName("Unavailable"), planet.InsertPolicy("")).
+		Return(domain.ErrUnavailable)
+
 	// Testing create success
 	resp, err := http.Post(baseUrl, "application/json", bytes.NewBuffer(baSuccess))
 	assert.Nil(t, err)
@@ -99,7 +121,7 @@ func TestCreatePlanet(t *testing.T) {
 	// Testing create invalid planet
 	resp, err = http.Post(baseUrl, "application/json", bytes.NewBuffer(baInvalidPlanet))
 	assert.Nil(t, err)
-	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
 	resp.Body.Close()
 
 	// Testing create conflict
@@ -108,17 +130,69 @@ func TestCreatePlanet(t *testing.T) {
 	assert.Equal(t, http.StatusConflict, resp.StatusCode)
 	resp.Body.Close()
 
-	// Testing create error
-	resp, err = http.Post(baseUrl, "application/json", bytes.NewBuffer(baError))
+	// Testing create error carrying the request id to the manager and the response
+	req, _ := http.NewRequest("POST", baseUrl, bytes.NewBuffer(baError))
+	req.Header.Set("X-Request-ID", "create-error")
+	resp, err = http.DefaultClient.Do(req)
 	assert.Nil(t, err)
 	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, "create-error", resp.Header.Get("X-Request-ID"))
+
+	body = responseBody{}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	assert.Nil(t, err)
+	assert.Equal(t, "Error while saving planet on database", body.Detail)
+	assert.Equal(t, domain.CodeInternal, body.Code)
+	assert.Equal(t, "create-error", body.RequestID)
+	resp.Body.Close()
+
+	// Testing create with swapi unavailable
+	resp, err = http.Post(baseUrl, "application/json", bytes.NewBuffer(baUnavailable))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestCreatePlanetSwapiDown(t *testing.T) {
+	var swapiRequests int32
+	swapiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&swapiRequests, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer swapiServer.Close()
+
+	dbRepo := &mocks.DbRepository{}
+	dbRepo.
+		On("GetByName", mock.Anything, "Tatooine").
+		Return(planet.Planet{}, domain.ErrNotFound)
+
+	client := swapi.NewClient(swapiServer.URL, swapiServer.Client(), swapi.Options{MaxRetries: 2})
+	manager := planet.NewManager(dbRepo, planet.NewSWApiRepository(client, zerolog.Nop()), planet.ManagerOptions{}, zerolog.Nop())
+
+	router := api.SetupRouter(manager, api.RouterOptions{})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	// Testing swapi still failing after the retries
+	resp, err := http.Post(fmt.Sprintf("%s/v1/planets", ts.URL), "application/json", bytes.NewBufferString(`{"name":"Tatooine"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	var body responseBody
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	assert.Nil(t, err)
 	resp.Body.Close()
+
+	assert.Equal(t, domain.CodeUnavailable, body.Code)
+	assert.Equal(t, "Star Wars API is unavailable, try again later", body.Detail)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&swapiRequests))
+	dbRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
 }
 
 func TestGetPlanet(t *testing.T) {
 	manager := &mocks.Manager{}
 
-	router := api.SetupRouter(manager)
+	router := api.SetupRouter(manager, api.RouterOptions{})
 	ts := httptest.NewServer(router)
 	defer ts.Close()
 
@@ -129,19 +203,19 @@ func TestGetPlanet(t *testing.T) {
 	pIDNotFound := primitive.NewObjectID()
 	pIDErr := primitive.NewObjectID()
 
-	p := planet.Planet{ID: pID}
+	p := planet.Planet{ID: pID, Apparitions: 1, Films: []planet.Film{{Title: "A New Hope", EpisodeID: 4, ReleaseDate: "1977-05-25"}}}
 	pErr := planet.Planet{}
 
 	manager.
-		On("GetById", idMatchsParam(pID.Hex())).
+		On("GetById", mock.Anything, idMatchsParam(pID.Hex())).
 		Return(p, nil)
 
 	manager.
-		On("GetById", idMatchsParam(pIDNotFound.Hex())).
+		On("GetById", mock.Anything, idMatchsParam(pIDNotFound.Hex())).
 		Return(pErr, domain.ErrNotFound)
 
 	manager.
-		On("GetById", idMatchsParam(pIDErr.Hex())).
+		On("GetById", mock.Anything, idMatchsParam(pIDErr.Hex())).
 		Return(pErr, errors.New("get error"))
 
 	// Testing get success
@@ -155,7 +229,29 @@ func TestGetPlanet(t *testing.T) {
 
 	bodyData := body.Data.(map[string]interface{})
 	assert.Equal(t, pID.Hex(), bodyData["id"])
+	assert.NotContains(t, bodyData, "films")
+
+	resp.Body.Close()
+
+	// Testing get with expanded films
+	resp, err = http.Get(fmt.Sprintf("%s/%s?expand=films", baseUrl, pID.Hex()))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body = responseBody{}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	assert.Nil(t, err)
 
+	films := body.Data.(map[string]interface{})["films"].([]interface{})
+	assert.Equal(t, 1, len(films))
+	assert.Equal(t, "A New Hope", films[0].(map[string]interface{})["title"])
+
+	resp.Body.Close()
+
+	// Testing get with unknown expand
+	resp, err = http.Get(fmt.Sprintf("%s/%s?expand=residents", baseUrl, pID.Hex()))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 	resp.Body.Close()
 
 	// Testing get invalid id
@@ -180,7 +276,7 @@ func TestGetPlanet(t *testing.T) {
 func TestGetPlanets(t *testing.T) {
 	manager := &mocks.Manager{}
 
-	router := api.SetupRouter(manager)
+	router := api.SetupRouter(manager, api.RouterOptions{})
 	ts := httptest.NewServer(router)
 	defer ts.Close()
 
@@ -193,8 +289,8 @@ func TestGetPlanets(t *testing.T) {
 	pList := []planet.Planet{pOne, pTwo, pThree}
 
 	manager.
-		On("FindAll").
-		Return(pList, nil)
+		On("FindPage", mock.Anything, planet.PageRequest{Limit: planet.DefaultPageLimit}).
+		Return(planet.Page{Planets: pList}, nil)
 
 	// Testing get success
 	resp, err := http.Get(baseUrl)
@@ -207,22 +303,166 @@ func TestGetPlanets(t *testing.T) {
 
 	bodyData := body.Data.([]interface{})
 	assert.Equal(t, 3, len(bodyData))
+	assert.False(t, body.HasMore)
+	assert.Equal(t, "", body.NextCursor)
+
+	resp.Body.Close()
+}
+
+func TestGetPlanetsPaginated(t *testing.T) {
+	manager := &mocks.Manager{}
+
+	router := api.SetupRouter(manager, api.RouterOptions{})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	baseUrl := fmt.Sprintf("%s/v1/planets", ts.URL)
+
+	pOne := planet.Planet{ID: primitive.NewObjectID(), Name: "One"}
+	pTwo := planet.Planet{ID: primitive.NewObjectID(), Name: "Two"}
+	pThree := planet.Planet{ID: primitive.NewObjectID(), Name: "Three"}
+
+	manager.
+		On("FindPage", mock.Anything, planet.PageRequest{Limit: 2}).
+		Return(planet.Page{Planets: []planet.Planet{pOne, pTwo}, NextCursor: planet.Cursor{ID: pTwo.ID}, HasMore: true}, nil)
+
+	manager.
+		On("FindPage", mock.Anything, planet.PageRequest{After: planet.Cursor{ID: pTwo.ID}, Limit: 2}).
+		Return(planet.Page{Planets: []planet.Planet{pThree}}, nil)
+
+	manager.
+		On("FindPage", mock.Anything, planet.PageRequest{Limit: 1000}).
+		Return(planet.Page{}, domain.ErrBadParamInput)
+
+	// Testing first page
+	resp, err := http.Get(fmt.Sprintf("%s?limit=2", baseUrl))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body responseBody
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(body.Data.([]interface{})))
+	assert.True(t, body.HasMore)
+	assert.NotEqual(t, "", body.NextCursor)
+
+	resp.Body.Close()
+
+	// Testing next page
+	resp, err = http.Get(fmt.Sprintf("%s?limit=2&cursor=%s", baseUrl, body.NextCursor))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body = responseBody{}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	assert.Nil(t, err)
+
+	bodyData := body.Data.([]interface{})
+	assert.Equal(t, 1, len(bodyData))
+	assert.Equal(t, "Three", bodyData[0].(map[string]interface{})["name"])
+	assert.False(t, body.HasMore)
+
+	resp.Body.Close()
+
+	// Testing invalid limit format
+	resp, err = http.Get(fmt.Sprintf("%s?limit=abc", baseUrl))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing limit out of range
+	resp, err = http.Get(fmt.Sprintf("%s?limit=1000", baseUrl))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing invalid cursor
+	resp, err = http.Get(fmt.Sprintf("%s?cursor=invalid", baseUrl))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestGetPlanetsWithFilters(t *testing.T) {
+	manager := &mocks.Manager{}
+
+	router := api.SetupRouter(manager, api.RouterOptions{})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	baseUrl := fmt.Sprintf("%s/v1/planets", ts.URL)
+
+	manager.
+		On("FindPage", mock.Anything, mock.MatchedBy(func(req planet.PageRequest) bool {
+			q := req.Query
+			return q.Climate == "arid" && q.Terrain == "desert" && q.NameContains == "too" &&
+				q.ApparitionsGte != nil && *q.ApparitionsGte == 1 &&
+				q.ApparitionsLte != nil && *q.ApparitionsLte == 5 &&
+				len(q.Sort) == 2 && q.Sort[0] == planet.SortField{Field: "apparitions", Desc: true}
+		})).
+		Return(planet.Page{Planets: []planet.Planet{{Name: "Tatooine"}}}, nil)
+
+	sort := []planet.SortField{{Field: planet.FieldApparitions, Desc: true}, {Field: planet.FieldName}}
+	last := planet.Planet{ID: primitive.NewObjectID(), Name: "Tatooine", Apparitions: 5}
+
+	manager.
+		On("FindPage", mock.Anything, mock.MatchedBy(func(req planet.PageRequest) bool {
+			return req.Query.Climate == "" && len(req.Query.Sort) == 2 && req.After.IsZero()
+		})).
+		Return(planet.Page{Planets: []planet.Planet{last}, NextCursor: planet.NewCursor(last, sort), HasMore: true}, nil)
+
+	manager.
+		On("FindPage", mock.Anything, mock.MatchedBy(func(req planet.PageRequest) bool {
+			return assert.ObjectsAreEqual(planet.NewCursor(last, sort), req.After)
+		})).
+		Return(planet.Page{}, nil)
+
+	// Testing filters success
+	resp, err := http.Get(fmt.Sprintf("%s?climate=arid&terrain=desert&name_contains=too&apparitions_gte=1&apparitions_lte=5&sort=-apparitions,name", baseUrl))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing sorted cursor carrying the sort values to the next page
+	resp, err = http.Get(fmt.Sprintf("%s?sort=-apparitions,name&limit=1", baseUrl))
+	assert.Nil(t, err)
+
+	var body responseBody
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", body.NextCursor)
+	resp.Body.Close()
+
+	resp, err = http.Get(fmt.Sprintf("%s?sort=-apparitions,name&limit=1&cursor=%s", baseUrl, body.NextCursor))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing invalid apparitions filter
+	resp, err = http.Get(fmt.Sprintf("%s?apparitions_gte=many", baseUrl))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
 
+	// Testing sort field not allowed
+	resp, err = http.Get(fmt.Sprintf("%s?sort=password", baseUrl))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 	resp.Body.Close()
 }
 
 func TestGetPlanetsErr(t *testing.T) {
 	manager := &mocks.Manager{}
 
-	router := api.SetupRouter(manager)
+	router := api.SetupRouter(manager, api.RouterOptions{})
 	ts := httptest.NewServer(router)
 	defer ts.Close()
 
 	baseUrl := fmt.Sprintf("%s/v1/planets", ts.URL)
 
 	manager.
-		On("FindAll").
-		Return(nil, errors.New("find all error"))
+		On("FindPage", mock.Anything, mock.AnythingOfType("planet.PageRequest")).
+		Return(planet.Page{}, errors.New("find page error"))
 
 	// Testing get error
 	resp, err := http.Get(baseUrl)
@@ -234,7 +474,7 @@ func TestGetPlanetsErr(t *testing.T) {
 func TestGetPlanetsWithName(t *testing.T) {
 	manager := &mocks.Manager{}
 
-	router := api.SetupRouter(manager)
+	router := api.SetupRouter(manager, api.RouterOptions{})
 	ts := httptest.NewServer(router)
 	defer ts.Close()
 
@@ -249,15 +489,15 @@ func TestGetPlanetsWithName(t *testing.T) {
 	pErr := planet.Planet{}
 
 	manager.
-		On("GetByName", pName).
+		On("GetByName", mock.Anything, pName).
 		Return(p, nil)
 
 	manager.
-		On("GetByName", pNameNotFound).
+		On("GetByName", mock.Anything, pNameNotFound).
 		Return(pErr, domain.ErrNotFound)
 
 	manager.
-		On("GetByName", pNameErr).
+		On("GetByName", mock.Anything, pNameErr).
 		Return(pErr, errors.New("get error"))
 
 	// Testing get success
@@ -291,7 +531,7 @@ func TestGetPlanetsWithName(t *testing.T) {
 func TestDelete(t *testing.T) {
 	manager := &mocks.Manager{}
 
-	router := api.SetupRouter(manager)
+	router := api.SetupRouter(manager, api.RouterOptions{})
 
 	pID := primitive.NewObjectID()
 	pIDInvalid := "Invalid"
@@ -299,15 +539,15 @@ func TestDelete(t *testing.T) {
 	pIDErr := primitive.NewObjectID()
 
 	manager.
-		On("Delete", idMatchsParam(pID.Hex())).
+		On("Delete", mock.Anything, idMatchsParam(pID.Hex())).
 		Return(nil)
 
 	manager.
-		On("Delete", idMatchsParam(pIDNotFound.Hex())).
+		On("Delete", mock.Anything, idMatchsParam(pIDNotFound.Hex())).
 		Return(domain.ErrNotFound)
 
 	manager.
-		On("Delete", idMatchsParam(pIDErr.Hex())).
+		On("Delete", mock.Anything, idMatchsParam(pIDErr.Hex())).
 		Return(errors.New("delete error"))
 
 	ts := httptest.NewServer(router)
@@ -349,3 +589,779 @@ func TestDelete(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
 	resp.Body.Close()
 }
+
+func TestRestorePlanet(t *testing.T) {
+	manager := &mocks.Manager{}
+
+	router := api.SetupRouter(manager, api.RouterOptions{})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	baseUrl := fmt.Sprintf("%s/v1/planets", ts.URL)
+
+	pID := primitive.NewObjectID()
+	pIDInvalid := "Invalid"
+	pIDNotFound := primitive.NewObjectID()
+	pIDErr := primitive.NewObjectID()
+
+	manager.
+		On("Restore", mock.Anything, idMatchsParam(pID.Hex())).
+		Return(nil)
+
+	manager.
+		On("Restore", mock.Anything, idMatchsParam(pIDNotFound.Hex())).
+		Return(domain.ErrNotFound)
+
+	manager.
+		On("Restore", mock.Anything, idMatchsParam(pIDErr.Hex())).
+		Return(errors.New("restore error"))
+
+	manager.
+		On("GetById", mock.Anything, idMatchsParam(pID.Hex())).
+		Return(planet.Planet{ID: pID, Name: "Restored"}, nil)
+
+	// Testing restore success
+	resp, err := http.Post(fmt.Sprintf("%s/%s/restore", baseUrl, pID.Hex()), "application/json", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body responseBody
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	assert.Nil(t, err)
+	assert.Equal(t, "Restored", body.Data.(map[string]interface{})["name"])
+
+	resp.Body.Close()
+
+	// Testing restore invalid id
+	resp, err = http.Post(fmt.Sprintf("%s/%s/restore", baseUrl, pIDInvalid), "application/json", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing restore not found
+	resp, err = http.Post(fmt.Sprintf("%s/%s/restore", baseUrl, pIDNotFound.Hex()), "application/json", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing restore error
+	resp, err = http.Post(fmt.Sprintf("%s/%s/restore", baseUrl, pIDErr.Hex()), "application/json", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestGetTrash(t *testing.T) {
+	manager := &mocks.Manager{}
+
+	router := api.SetupRouter(manager, api.RouterOptions{})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	baseUrl := fmt.Sprintf("%s/v1/planets/trash", ts.URL)
+
+	pDeleted := planet.Planet{ID: primitive.NewObjectID(), Name: "Deleted"}
+
+	manager.
+		On("FindTrash", mock.Anything, planet.PageRequest{Limit: 1}).
+		Return(planet.Page{Planets: []planet.Planet{pDeleted}, NextCursor: planet.Cursor{ID: pDeleted.ID}, HasMore: true}, nil)
+
+	manager.
+		On("FindTrash", mock.Anything, planet.PageRequest{Limit: 2}).
+		Return(planet.Page{}, errors.New("find error"))
+
+	// Testing trash listing
+	resp, err := http.Get(baseUrl + "?limit=1")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body responseBody
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(body.Data.([]interface{})))
+	assert.True(t, body.HasMore)
+	assert.NotEqual(t, "", body.NextCursor)
+
+	resp.Body.Close()
+
+	// Testing invalid cursor
+	resp, err = http.Get(baseUrl + "?cursor=invalid")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing listing error
+	resp, err = http.Get(baseUrl + "?limit=2")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	resp.Body.Close()
+
+	manager.AssertNotCalled(t, "GetById", mock.Anything, mock.Anything)
+}
+
+func TestUpdatePlanet(t *testing.T) {
+	manager := &mocks.Manager{}
+
+	router := api.SetupRouter(manager, api.RouterOptions{})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	baseUrl := fmt.Sprintf("%s/v1/planets", ts.URL)
+
+	pID := primitive.NewObjectID()
+	pIDInvalid := "Invalid"
+	pIDNotFound := primitive.NewObjectID()
+
+	var baSuccess = []byte(`{"name":"Success","climate":"arid"}`)
+	var baInvalidJson = []byte(`{name:Invalid}`)
+	var baConflict = []byte(`{"name":"Conflict"}`)
+	var baError = []byte(`{"name":"Error"}`)
+
+	manager.
+		On("UpdateWithPolicy", mock.Anything, planetMatchsName("Success"), planet.InsertPolicy("")).
+		Return(nil)
+
+	manager.
+		On("UpdateWithPolicy", mock.Anything, planetMatchsName("Enriched"), planet.InsertEnrich).
+		Return(nil)
+
+	manager.
+		On("UpdateWithPolicy", mock.Anything, planetMatchsName("Conflict"), planet.InsertPolicy("")).
+		Return(domain.ErrConflict)
+
+	manager.
+		On("UpdateWithPolicy", mock.Anything, planetMatchsName("Error"), planet.InsertPolicy("")).
+		Return(errors.New("update error"))
+
+	manager.
+		On("UpdateWithPolicy", mock.Anything, mock.MatchedBy(func(p *planet.Planet) bool {
+			return p.ID == pIDNotFound
+		}), planet.InsertPolicy("")).
+		Return(domain.ErrNotFound)
+
+	client := &http.Client{}
+
+	// Testing update success
+	req, err := http.NewRequest("PUT", fmt.Sprintf("%s/%s", baseUrl, pID.Hex()), bytes.NewBuffer(baSuccess))
+	assert.Nil(t, err)
+	resp, err := client.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body responseBody
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	assert.Nil(t, err)
+
+	bodyData := body.Data.(map[string]interface{})
+	assert.Equal(t, pID.Hex(), bodyData["id"])
+	assert.Equal(t, "arid", bodyData["climate"])
+
+	resp.Body.Close()
+
+	// Testing update invalid id
+	req, err = http.NewRequest("PUT", fmt.Sprintf("%s/%s", baseUrl, pIDInvalid), bytes.NewBuffer(baSuccess))
+	assert.Nil(t, err)
+	resp, err = client.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing update invalid json
+	req, err = http.NewRequest("PUT", fmt.Sprintf("%s/%s", baseUrl, pID.Hex()), bytes.NewBuffer(baInvalidJson))
+	assert.Nil(t, err)
+	resp, err = client.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing update not found
+	req, err = http.NewRequest("PUT", fmt.Sprintf("%s/%s", baseUrl, pIDNotFound.Hex()), bytes.NewBuffer([]byte(`{"name":"Not Found"}`)))
+	assert.Nil(t, err)
+	resp, err = client.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing update conflict
+	req, err = http.NewRequest("PUT", fmt.Sprintf("%s/%s", baseUrl, pID.Hex()), bytes.NewBuffer(baConflict))
+	assert.Nil(t, err)
+	resp, err = client.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing update error
+	req, err = http.NewRequest("PUT", fmt.Sprintf("%s/%s", baseUrl, pID.Hex()), bytes.NewBuffer(baError))
+	assert.Nil(t, err)
+	resp, err = client.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing update with insert policy
+	req, err = http.NewRequest("PUT", fmt.Sprintf("%s/%s?insert_policy=enrich", baseUrl, pID.Hex()), bytes.NewBuffer([]byte(`{"name":"Enriched"}`)))
+	assert.Nil(t, err)
+	resp, err = client.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing update invalid insert policy
+	req, err = http.NewRequest("PUT", fmt.Sprintf("%s/%s?insert_policy=loose", baseUrl, pID.Hex()), bytes.NewBuffer(baSuccess))
+	assert.Nil(t, err)
+	resp, err = client.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	manager.AssertNumberOfCalls(t, "UpdateWithPolicy", 5)
+}
+
+func TestPatchPlanet(t *testing.T) {
+	manager := &mocks.Manager{}
+
+	router := api.SetupRouter(manager, api.RouterOptions{})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	baseUrl := fmt.Sprintf("%s/v1/planets", ts.URL)
+
+	pID := primitive.NewObjectID()
+	pIDNotFound := primitive.NewObjectID()
+
+	p := planet.Planet{ID: pID, Name: "Tatooine", Climate: "arid", Terrain: "desert", Apparitions: 5}
+
+	manager.
+		On("GetById", mock.Anything, idMatchsParam(pID.Hex())).
+		Return(p, nil)
+
+	manager.
+		On("GetById", mock.Anything, idMatchsParam(pIDNotFound.Hex())).
+		Return(planet.Planet{}, domain.ErrNotFound)
+
+	manager.
+		On("UpdateWithPolicy", mock.Anything, mock.MatchedBy(func(p *planet.Planet) bool {
+			return p.Name == "Tatooine" && p.Climate == "temperate" && p.Terrain == ""
+		}), planet.InsertPolicy("")).
+		Return(nil)
+
+	manager.
+		On("UpdateWithPolicy", mock.Anything, planetMatchsName("Unknown"), planet.InsertStrict).
+		Return(planet.ErrNotInSwapi)
+
+	client := &http.Client{}
+
+	// Testing patch success
+	req, err := http.NewRequest("PATCH", fmt.Sprintf("%s/%s", baseUrl, pID.Hex()), bytes.NewBuffer([]byte(`{"climate":"temperate","terrain":null}`)))
+	assert.Nil(t, err)
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	resp, err := client.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body responseBody
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	assert.Nil(t, err)
+
+	bodyData := body.Data.(map[string]interface{})
+	assert.Equal(t, "Tatooine", bodyData["name"])
+	assert.Equal(t, "temperate", bodyData["climate"])
+	assert.Equal(t, "", bodyData["terrain"])
+
+	resp.Body.Close()
+
+	// Testing patch invalid json
+	req, err = http.NewRequest("PATCH", fmt.Sprintf("%s/%s", baseUrl, pID.Hex()), bytes.NewBuffer([]byte(`{climate:temperate}`)))
+	assert.Nil(t, err)
+	resp, err = client.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing patch not found
+	req, err = http.NewRequest("PATCH", fmt.Sprintf("%s/%s", baseUrl, pIDNotFound.Hex()), bytes.NewBuffer([]byte(`{"climate":"temperate"}`)))
+	assert.Nil(t, err)
+	resp, err = client.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing patch rename with insert policy
+	req, err = http.NewRequest("PATCH", fmt.Sprintf("%s/%s?insert_policy=strict", baseUrl, pID.Hex()), bytes.NewBuffer([]byte(`{"name":"Unknown"}`)))
+	assert.Nil(t, err)
+	resp, err = client.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestPatchPlanetLegacyValues(t *testing.T) {
+	manager := &mocks.Manager{}
+
+	router := api.SetupRouter(manager, api.RouterOptions{})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	pID := primitive.NewObjectID()
+	planetUrl := fmt.Sprintf("%s/v1/planets/%s", ts.URL, pID.Hex())
+
+	manager.
+		On("GetById", mock.Anything, idMatchsParam(pID.Hex())).
+		Return(planet.Planet{ID: pID, Name: "Tatooine", Climate: "sunny", Terrain: "lava"}, nil)
+
+	manager.
+		On("UpdateWithPolicy", mock.Anything, mock.MatchedBy(func(p *planet.Planet) bool {
+			return p.Name == "Tatooine" && p.Climate == "sunny" && p.Terrain == "desert"
+		}), planet.InsertPolicy("")).
+		Return(nil)
+
+	// Testing stored values outside the current rules don't block other fields
+	req, err := http.NewRequest("PATCH", planetUrl, bytes.NewBuffer([]byte(`{"terrain":"desert"}`)))
+	assert.Nil(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing changed fields are still validated
+	req, err = http.NewRequest("PATCH", planetUrl, bytes.NewBuffer([]byte(`{"climate":"rainy"}`)))
+	assert.Nil(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	var problem presenter.Problem
+	err = json.NewDecoder(resp.Body).Decode(&problem)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, []domain.FieldError{{Field: "climate", Message: `unknown climate "rainy"`}}, problem.Errors)
+
+	manager.AssertNumberOfCalls(t, "UpdateWithPolicy", 1)
+}
+
+func TestRequestContextCancellation(t *testing.T) {
+	manager := &mocks.Manager{}
+
+	router := api.SetupRouter(manager, api.RouterOptions{})
+
+	// The client gives up before the response, so it is recorded to be checked
+	responses := make(chan *httptest.ResponseRecorder, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, r)
+		responses <- rec
+	}))
+	defer ts.Close()
+
+	pID := primitive.NewObjectID()
+
+	canceled := make(chan struct{})
+	manager.
+		On("GetById", mock.Anything, idMatchsParam(pID.Hex())).
+		Run(func(args mock.Arguments) {
+			<-args.Get(0).(context.Context).Done()
+			close(canceled)
+		}).
+		Return(planet.Planet{}, context.Canceled)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/v1/planets/%s", ts.URL, pID.Hex()), nil)
+	assert.Nil(t, err)
+
+	// Testing the client giving up cancels the context received by the manager
+	_, err = http.DefaultClient.Do(req)
+	assert.NotNil(t, err)
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("request context was not canceled")
+	}
+
+	// Testing the canceled request is answered as closed by the client
+	var rec *httptest.ResponseRecorder
+	select {
+	case rec = <-responses:
+	case <-time.After(time.Second):
+		t.Fatal("request was not answered")
+	}
+	assert.Equal(t, middleware.StatusClientClosedRequest, rec.Code)
+
+	var problem presenter.Problem
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, domain.CodeCanceled, problem.Code)
+}
+
+func TestPlanetRoutesWithAuth(t *testing.T) {
+	manager := &mocks.Manager{}
+
+	authenticator, err := auth.NewAuthenticator(config.Auth{
+		APIKeys: []config.APIKey{{Name: "writer", Key: "write-key", Scopes: []string{auth.ScopeWrite}}},
+	})
+	assert.Nil(t, err)
+
+	router := api.SetupRouter(manager, api.RouterOptions{
+		Authenticator: authenticator,
+		Auth:          middleware.AuthOptions{PublicReads: true},
+	})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	baseUrl := fmt.Sprintf("%s/v1/planets", ts.URL)
+
+	manager.
+		On("FindPage", mock.Anything, mock.AnythingOfType("planet.PageRequest")).
+		Return(planet.Page{}, nil)
+
+	// Testing public read
+	resp, err := http.Get(baseUrl)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing write without credentials
+	resp, err = http.Post(baseUrl, "application/json", bytes.NewBuffer([]byte(`{"name":"Tatooine"}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	var body responseBody
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	assert.Nil(t, err)
+	assert.Equal(t, "Missing credentials", body.Detail)
+	assert.Equal(t, domain.CodeUnauthorized, body.Code)
+	assert.NotEmpty(t, body.RequestID)
+	resp.Body.Close()
+
+	// Testing metrics are not protected
+	resp, err = http.Get(fmt.Sprintf("%s/metrics", ts.URL))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	manager.AssertNotCalled(t, "InsertWithPolicy", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProblemDetails(t *testing.T) {
+	manager := &mocks.Manager{}
+
+	router := api.SetupRouter(manager, api.RouterOptions{})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	baseUrl := fmt.Sprintf("%s/v1/planets", ts.URL)
+
+	manager.
+		On("InsertWithPolicy", mock.Anything, planetMatchsName("Invalid"), planet.InsertPolicy("")).
+		Return(planet.Planet{}.Validate())
+
+	decode := func(resp *http.Response) presenter.Problem {
+		var problem presenter.Problem
+		err := json.NewDecoder(resp.Body).Decode(&problem)
+		assert.Nil(t, err)
+		resp.Body.Close()
+		return problem
+	}
+
+	// Testing invalid param rendered as problem details
+	resp, err := http.Get(fmt.Sprintf("%s/invalid?expand=films", baseUrl))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, presenter.ProblemContentType, resp.Header.Get("Content-Type"))
+
+	problem := decode(resp)
+	assert.Equal(t, "urn:swapi-challenge:problem:invalid_input", problem.Type)
+	assert.Equal(t, "Bad Request", problem.Title)
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+	assert.Equal(t, "Unexpected ID format", problem.Detail)
+	assert.Equal(t, "/v1/planets/invalid", problem.Instance)
+	assert.Equal(t, domain.CodeInvalidInput, problem.Code)
+	assert.Equal(t, resp.Header.Get("X-Request-ID"), problem.RequestID)
+	assert.Len(t, problem.Errors, 1)
+	assert.Equal(t, "id", problem.Errors[0].Field)
+
+	// Testing domain validation errors keep their field details
+	resp, err = http.Post(baseUrl, "application/json", bytes.NewBuffer([]byte(`{"name":"Invalid"}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	problem = decode(resp)
+	assert.Equal(t, "Invalid planet input params", problem.Detail)
+	assert.Equal(t, []domain.FieldError{{Field: "name", Message: "must not be empty"}}, problem.Errors)
+
+	// Testing filter errors pointing the invalid query param
+	resp, err = http.Get(fmt.Sprintf("%s?apparitions_gte=many", baseUrl))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	problem = decode(resp)
+	assert.Equal(t, "Invalid filter params", problem.Detail)
+	assert.Equal(t, []domain.FieldError{{Field: "apparitions_gte", Message: "must be an integer"}}, problem.Errors)
+
+	// Testing unknown routes
+	resp, err = http.Get(fmt.Sprintf("%s/v1/unknown", ts.URL))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, presenter.ProblemContentType, resp.Header.Get("Content-Type"))
+
+	problem = decode(resp)
+	assert.Equal(t, domain.CodeNotFound, problem.Code)
+	assert.Equal(t, "Route not found", problem.Detail)
+}
+
+func TestPlanetValidation(t *testing.T) {
+	manager := &mocks.Manager{}
+
+	router := api.SetupRouter(manager, api.RouterOptions{})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	baseUrl := fmt.Sprintf("%s/v1/planets", ts.URL)
+	pID := primitive.NewObjectID()
+
+	manager.
+		On("GetById", mock.Anything, idMatchsParam(pID.Hex())).
+		Return(planet.Planet{ID: pID, Name: "Tatooine", Climate: "arid", Terrain: "desert"}, nil)
+
+	decode := func(resp *http.Response) presenter.Problem {
+		var problem presenter.Problem
+		err := json.NewDecoder(resp.Body).Decode(&problem)
+		assert.Nil(t, err)
+		resp.Body.Close()
+		return problem
+	}
+
+	// Testing every failing field reported at once
+	resp, err := http.Post(baseUrl, "application/json", bytes.NewBuffer([]byte(`{"name":"Tatooine!","climate":"arid, sunny","terrain":"desert,,lava"}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	problem := decode(resp)
+	assert.Equal(t, domain.CodeValidationFailed, problem.Code)
+	assert.Equal(t, "Invalid planet input params", problem.Detail)
+	assert.ElementsMatch(t, []domain.FieldError{
+		{Field: "name", Message: "must start with a letter or number and contain only letters, numbers, spaces, hyphens, apostrophes and periods"},
+		{Field: "climate", Message: `unknown climate "sunny"`},
+		{Field: "terrain", Message: `unknown terrain "", "lava"`},
+	}, problem.Errors)
+
+	// Testing length limits
+	resp, err = http.Post(baseUrl, "application/json", bytes.NewBuffer([]byte(fmt.Sprintf(`{"name":"%s"}`, strings.Repeat("a", 61)))))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Equal(t, []domain.FieldError{{Field: "name", Message: "must have at most 60 characters"}}, decode(resp).Errors)
+
+	// Testing unknown fields rejected
+	resp, err = http.Post(baseUrl, "application/json", bytes.NewBuffer([]byte(`{"name":"Tatooine","population":200000,"gravity":"1 standard"}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Equal(t, []domain.FieldError{
+		{Field: "gravity", Message: "is not allowed"},
+		{Field: "population", Message: "is not allowed"},
+	}, decode(resp).Errors)
+
+	// Testing unknown fields reported with the invalid ones
+	resp, err = http.Post(baseUrl, "application/json", bytes.NewBuffer([]byte(`{"name":"Tatooine!","gravity":"1 standard"}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Equal(t, []domain.FieldError{
+		{Field: "gravity", Message: "is not allowed"},
+		{Field: "name", Message: "must start with a letter or number and contain only letters, numbers, spaces, hyphens, apostrophes and periods"},
+	}, decode(resp).Errors)
+
+	// Testing wrong field types
+	resp, err = http.Post(baseUrl, "application/json", bytes.NewBuffer([]byte(`{"name":42}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Equal(t, []domain.FieldError{{Field: "name", Message: "must be a string"}}, decode(resp).Errors)
+
+	// Testing update validated the same way
+	req, err := http.NewRequest("PUT", fmt.Sprintf("%s/%s", baseUrl, pID.Hex()), bytes.NewBuffer([]byte(`{"name":"Tatooine","climate":"sunny"}`)))
+	assert.Nil(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Equal(t, []domain.FieldError{{Field: "climate", Message: `unknown climate "sunny"`}}, decode(resp).Errors)
+
+	// Testing patch validated after merging and rejecting unknown fields
+	req, err = http.NewRequest("PATCH", fmt.Sprintf("%s/%s", baseUrl, pID.Hex()), bytes.NewBuffer([]byte(`{"terrain":"lava","diameter":"10465"}`)))
+	assert.Nil(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Equal(t, []domain.FieldError{
+		{Field: "diameter", Message: "is not allowed"},
+		{Field: "terrain", Message: `unknown terrain "lava"`},
+	}, decode(resp).Errors)
+
+	req, err = http.NewRequest("PATCH", fmt.Sprintf("%s/%s", baseUrl, pID.Hex()), bytes.NewBuffer([]byte(`{"terrain":"lava"}`)))
+	assert.Nil(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Equal(t, []domain.FieldError{{Field: "terrain", Message: `unknown terrain "lava"`}}, decode(resp).Errors)
+
+	manager.AssertNotCalled(t, "InsertWithPolicy", mock.Anything, mock.Anything, mock.Anything)
+	manager.AssertNotCalled(t, "UpdateWithPolicy", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreatePlanetWithPolicy(t *testing.T) {
+	manager := &mocks.Manager{}
+
+	router := api.SetupRouter(manager, api.RouterOptions{})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	baseUrl := fmt.Sprintf("%s/v1/planets", ts.URL)
+
+	manager.
+		On("InsertWithPolicy", mock.Anything, planetMatchsName("Unknown"), planet.InsertStrict).
+		Return(planet.ErrNotInSwapi)
+
+	manager.
+		On("InsertWithPolicy", mock.Anything, planetMatchsName("Tatooine"), planet.InsertEnrich).
+		Return(func(ctx context.Context, p *planet.Planet, policy planet.InsertPolicy) error {
+			p.ID = primitive.NewObjectID()
+			p.Climate = "arid"
+			return nil
+		})
+
+	// Testing strict policy requested by the client
+	resp, err := http.Post(baseUrl+"?insert_policy=strict", "application/json", bytes.NewBuffer([]byte(`{"name":"Unknown"}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	var problem presenter.Problem
+	err = json.NewDecoder(resp.Body).Decode(&problem)
+	assert.Nil(t, err)
+	assert.Equal(t, "Planet not found in the Star Wars API", problem.Detail)
+	assert.Equal(t, "name", problem.Errors[0].Field)
+	resp.Body.Close()
+
+	// Testing enrich policy requested by the client
+	resp, err = http.Post(baseUrl+"?insert_policy=enrich", "application/json", bytes.NewBuffer([]byte(`{"name":"Tatooine"}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var body responseBody
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	assert.Nil(t, err)
+	assert.Equal(t, "arid", body.Data.(map[string]interface{})["climate"])
+	resp.Body.Close()
+
+	// Testing unknown policy
+	resp, err = http.Post(baseUrl+"?insert_policy=loose", "application/json", bytes.NewBuffer([]byte(`{"name":"Tatooine"}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	manager.AssertExpectations(t)
+}
+
+func TestImportPlanet(t *testing.T) {
+	config.Data.SWApi.BaseUrl = "https://swapi.dev/api"
+	defer func() { config.Data.SWApi.BaseUrl = "" }()
+
+	manager := &mocks.Manager{}
+
+	router := api.SetupRouter(manager, api.RouterOptions{})
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	baseUrl := fmt.Sprintf("%s/v1/planets/import", ts.URL)
+
+	population := int64(200000)
+	tatooine := planet.Planet{
+		ID:          primitive.NewObjectID(),
+		Name:        "Tatooine",
+		Climate:     "arid",
+		Terrain:     "desert",
+		Population:  &population,
+		Gravity:     "1 standard",
+		Apparitions: 5,
+	}
+
+	manager.
+		On("Import", mock.Anything, planet.SwapiReference{Name: "Tatooine"}).
+		Return(tatooine, nil).
+		Once()
+
+	manager.
+		On("Import", mock.Anything, planet.SwapiReference{ID: 1}).
+		Return(planet.Planet{}, domain.ErrConflict).
+		Twice()
+
+	manager.
+		On("Import", mock.Anything, planet.SwapiReference{ID: 99}).
+		Return(planet.Planet{}, domain.NewError(domain.CodeValidationFailed, "Planet not found in the Star Wars API",
+			domain.FieldError{Field: "id", Message: "must be a planet from the Star Wars API"})).
+		Once()
+
+	// Testing import by name
+	resp, err := http.Post(baseUrl, "application/json", bytes.NewBuffer([]byte(`{"name":"Tatooine"}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var body responseBody
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	assert.Nil(t, err)
+	data := body.Data.(map[string]interface{})
+	assert.Equal(t, tatooine.ID.Hex(), data["id"])
+	assert.Equal(t, "arid", data["climate"])
+	assert.Equal(t, float64(200000), data["population"])
+	assert.Equal(t, "1 standard", data["gravity"])
+	assert.NotContains(t, data, "diameter")
+	assert.Equal(t, float64(5), data["apparitions"])
+	resp.Body.Close()
+
+	// Testing conflict by id and by url
+	for _, payload := range []string{`{"id":1}`, `{"url":"https://swapi.dev/api/planets/1/"}`} {
+		resp, err = http.Post(baseUrl, "application/json", bytes.NewBuffer([]byte(payload)))
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		body = responseBody{}
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		assert.Nil(t, err)
+		assert.Equal(t, "A planet with specified params already exists", body.Detail)
+		resp.Body.Close()
+	}
+
+	// Testing planet not found on swapi
+	resp, err = http.Post(baseUrl, "application/json", bytes.NewBuffer([]byte(`{"id":99}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	resp.Body.Close()
+
+	// Testing invalid commands
+	for payload, field := range map[string]string{
+		`{}`:                         "name",
+		`{"name":"Tatooine","id":1}`: "name",
+		`{"id":-1}`:                  "id",
+		`{"url":"https://swapi.dev/api/people/1/"}`: "url",
+		`{"url":"http://swapi.dev/api/planets/1/"}`: "url",
+		`{"url":"https://evil.example/planets/1/"}`: "url",
+		`{"id":1,"climate":"arid"}`:                 "climate",
+	} {
+		resp, err = http.Post(baseUrl, "application/json", bytes.NewBuffer([]byte(payload)))
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode, payload)
+
+		var problem presenter.Problem
+		err = json.NewDecoder(resp.Body).Decode(&problem)
+		assert.Nil(t, err)
+		if assert.Len(t, problem.Errors, 1, payload) {
+			assert.Equal(t, field, problem.Errors[0].Field, payload)
+		}
+		resp.Body.Close()
+	}
+
+	// Testing unknown planet action
+	resp, err = http.Post(fmt.Sprintf("%s/v1/planets/export", ts.URL), "application/json", bytes.NewBuffer([]byte(`{}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+
+	manager.AssertExpectations(t)
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"b2w/swapi-challenge/domain"
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondError registra o erro para ser renderizado pelo middleware Errors.
+// Erros fora do domínio são respondidos como internos, sem expor a causa.
+func respondError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// respondInvalidParam responde com erro de entrada em um único parâmetro
+func respondInvalidParam(c *gin.Context, message string, field string, fieldMessage string) {
+	respondError(c, domain.NewError(domain.CodeInvalidInput, message, domain.FieldError{Field: field, Message: fieldMessage}))
+}
+
+// planetError traduz os erros retornados pelo Manager para as mensagens da API.
+// Erros de entrada já trazem a mensagem e os campos inválidos, e erros
+// inesperados recebem a mensagem informada. Requisições abandonadas pelo
+// cliente não são tratadas como falha da API.
+func planetError(err error, message string) error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return domain.Wrap(err, domain.CodeCanceled, "Request canceled by the client")
+	case errors.Is(err, domain.ErrBadParamInput), errors.Is(err, domain.ErrValidationFailed):
+		return err
+	case errors.Is(err, domain.ErrNotFound):
+		return domain.Wrap(err, domain.CodeNotFound, "Planet not found")
+	case errors.Is(err, domain.ErrConflict):
+		return domain.Wrap(err, domain.CodeConflict, "A planet with specified params already exists")
+	case errors.Is(err, domain.ErrUnavailable):
+		return domain.Wrap(err, domain.CodeUnavailable, "Star Wars API is unavailable, try again later")
+	default:
+		return domain.Wrap(err, domain.CodeInternal, message)
+	}
+}
+
+// RouteNotFound responde às rotas não registradas no mesmo formato dos demais erros
+func RouteNotFound(c *gin.Context) {
+	respondError(c, domain.NewError(domain.CodeNotFound, "Route not found"))
+}
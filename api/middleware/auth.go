@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"b2w/swapi-challenge/domain"
+	"b2w/swapi-challenge/infra/auth"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+const principalKey = "principal"
+
+type AuthOptions struct {
+	// PublicReads permite leituras sem credenciais. Credenciais inválidas
+	// continuam sendo recusadas.
+	PublicReads bool
+	// Scope, quando informado, é exigido em todos os métodos, no lugar dos
+	// escopos de leitura e escrita. Não permite leituras públicas.
+	Scope string
+}
+
+// Auth autentica a requisição por chave de API (header X-API-Key) ou token JWT
+// (header Authorization: Bearer). Leituras exigem o escopo planets:read e as
+// demais operações o escopo planets:write, que também permite leituras, a
+// menos que outro escopo seja exigido em AuthOptions.Scope.
+func Auth(authenticator *auth.Authenticator, opts AuthOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		read := isReadMethod(c.Request.Method)
+
+		principal, err := authenticate(c, authenticator)
+		if errors.Is(err, auth.ErrMissingCredentials) && read && opts.PublicReads && opts.Scope == "" {
+			c.Next()
+			return
+		}
+		if err != nil {
+			message := "Invalid credentials"
+			if errors.Is(err, auth.ErrMissingCredentials) {
+				message = "Missing credentials"
+			}
+
+			c.Header("WWW-Authenticate", `Bearer realm="swapi-challenge"`)
+			abortWithError(c, domain.Wrap(err, domain.CodeUnauthorized, message))
+			return
+		}
+
+		if scope, allowed := requiredScope(principal, read, opts); !allowed {
+			abortWithError(c, domain.NewError(domain.CodeForbidden, "Missing required scope",
+				domain.FieldError{Field: "scope", Message: scope + " is required"}))
+			return
+		}
+
+		c.Set(principalKey, principal)
+		log := RequestLogger(c).With().Str("subject", principal.Subject).Str("auth_method", principal.Method).Logger()
+		c.Set(loggerKey, &log)
+
+		c.Next()
+	}
+}
+
+// CurrentPrincipal retorna quem fez a requisição, quando autenticada
+func CurrentPrincipal(c *gin.Context) (auth.Principal, bool) {
+	if principal, ok := c.Get(principalKey); ok {
+		return principal.(auth.Principal), true
+	}
+	return auth.Principal{}, false
+}
+
+func authenticate(c *gin.Context, authenticator *auth.Authenticator) (auth.Principal, error) {
+	if header := c.GetHeader("Authorization"); header != "" {
+		parts := strings.SplitN(header, " ", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+			return auth.Principal{}, auth.ErrInvalidCredentials
+		}
+		return authenticator.AuthenticateToken(strings.TrimSpace(parts[1]))
+	}
+
+	return authenticator.AuthenticateAPIKey(c.GetHeader(APIKeyHeader))
+}
+
+// requiredScope informa se o principal tem o escopo exigido, retornando o
+// escopo que faltou
+func requiredScope(principal auth.Principal, read bool, opts AuthOptions) (string, bool) {
+	if opts.Scope != "" {
+		return opts.Scope, principal.HasScope(opts.Scope)
+	}
+	if principal.HasScope(auth.ScopeWrite) {
+		return auth.ScopeWrite, true
+	}
+	if read {
+		return auth.ScopeRead, opts.PublicReads || principal.HasScope(auth.ScopeRead)
+	}
+
+	return auth.ScopeWrite, false
+}
+
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
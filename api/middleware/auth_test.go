@@ -0,0 +1,107 @@
+package middleware_test
+
+import (
+	"b2w/swapi-challenge/api/middleware"
+	"b2w/swapi-challenge/config"
+	"b2w/swapi-challenge/infra/auth"
+	"b2w/swapi-challenge/infra/logger"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuth(t *testing.T) {
+	authenticator, err := auth.NewAuthenticator(config.Auth{
+		APIKeys: []config.APIKey{
+			{Name: "reader", Key: "read-key", Scopes: []string{auth.ScopeRead}},
+			{Name: "writer", Key: "write-key", Scopes: []string{auth.ScopeWrite}},
+		},
+		JWT: config.JWT{HMACSecret: "secret"},
+	})
+	assert.Nil(t, err)
+
+	writeToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user", "scope": auth.ScopeWrite, "exp": time.Now().Add(time.Hour).Unix()}).
+		SignedString([]byte("secret"))
+
+	newRouter := func(opts middleware.AuthOptions) *gin.Engine {
+		router := gin.New()
+		router.Use(middleware.RequestID(logger.New(ioutil.Discard, "info")))
+		router.Use(middleware.Errors())
+		router.Use(middleware.Auth(authenticator, opts))
+		router.GET("/v1/planets", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		router.POST("/v1/planets", func(c *gin.Context) {
+			principal, _ := middleware.CurrentPrincipal(c)
+			c.String(http.StatusCreated, principal.Subject)
+		})
+		return router
+	}
+
+	private := newRouter(middleware.AuthOptions{})
+	public := newRouter(middleware.AuthOptions{PublicReads: true})
+
+	cases := []struct {
+		name    string
+		router  *gin.Engine
+		method  string
+		headers map[string]string
+		status  int
+	}{
+		{"public read", public, "GET", nil, http.StatusOK},
+		{"public read with invalid key", public, "GET", map[string]string{"X-API-Key": "wrong"}, http.StatusUnauthorized},
+		{"private read without credentials", private, "GET", nil, http.StatusUnauthorized},
+		{"private read with read key", private, "GET", map[string]string{"X-API-Key": "read-key"}, http.StatusOK},
+		{"private read with write key", private, "GET", map[string]string{"X-API-Key": "write-key"}, http.StatusOK},
+		{"write without credentials", public, "POST", nil, http.StatusUnauthorized},
+		{"write with read key", public, "POST", map[string]string{"X-API-Key": "read-key"}, http.StatusForbidden},
+		{"write with write key", public, "POST", map[string]string{"X-API-Key": "write-key"}, http.StatusCreated},
+		{"write with token", public, "POST", map[string]string{"Authorization": "Bearer " + writeToken}, http.StatusCreated},
+		{"write with invalid token", public, "POST", map[string]string{"Authorization": "Bearer invalid"}, http.StatusUnauthorized},
+		{"write with basic auth", public, "POST", map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}, http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, "/v1/planets", nil)
+		for key, value := range tc.headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		tc.router.ServeHTTP(rec, req)
+
+		assert.Equal(t, tc.status, rec.Code, tc.name)
+	}
+
+	// Testing the error envelope of a forbidden request
+	req := httptest.NewRequest("POST", "/v1/planets", nil)
+	req.Header.Set("X-API-Key", "read-key")
+	req.Header.Set(middleware.RequestIDHeader, "auth-req")
+	rec := httptest.NewRecorder()
+	public.ServeHTTP(rec, req)
+
+	var body map[string]interface{}
+	err = json.Unmarshal(rec.Body.Bytes(), &body)
+	assert.Nil(t, err)
+	assert.Equal(t, "Missing required scope", body["detail"])
+	assert.Equal(t, "forbidden", body["code"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"field": "scope", "message": auth.ScopeWrite + " is required"}}, body["errors"])
+	assert.Equal(t, "auth-req", body["request_id"])
+
+	// Testing the challenge header and the authenticated subject
+	rec = httptest.NewRecorder()
+	public.ServeHTTP(rec, httptest.NewRequest("POST", "/v1/planets", nil))
+	assert.Equal(t, `Bearer realm="swapi-challenge"`, rec.Header().Get("WWW-Authenticate"))
+
+	req = httptest.NewRequest("POST", "/v1/planets", nil)
+	req.Header.Set("Authorization", "Bearer "+writeToken)
+	rec = httptest.NewRecorder()
+	public.ServeHTTP(rec, req)
+	assert.Equal(t, "user", rec.Body.String())
+}
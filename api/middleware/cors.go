@@ -1,24 +1,212 @@
 package middleware
 
 import (
-	"fmt"
+	"b2w/swapi-challenge/domain"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func Cors() gin.HandlerFunc {
+var (
+	defaultCorsMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"}
+	defaultCorsHeaders = []string{"Accept", "Authorization", "Content-Type", APIKeyHeader, RequestIDHeader}
+)
+
+type CorsOptions struct {
+	// AllowedOrigins aceita origens exatas ("https://app.example.com"), subdomínios
+	// com curinga ("https://*.example.com") ou "*" para qualquer origem
+	AllowedOrigins []string
+	// AllowedMethods e AllowedHeaders usam os valores padrão quando vazios.
+	// AllowedHeaders com "*" aceita quaisquer headers.
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+type corsPolicy struct {
+	anyOrigin      bool
+	anyHeader      bool
+	origins        map[string]bool
+	wildcards      []originWildcard
+	methods        map[string]bool
+	headers        map[string]bool
+	allowedMethods string
+	allowedHeaders string
+	exposedHeaders string
+}
+
+type originWildcard struct {
+	prefix string
+	suffix string
+}
+
+// Cors aplica a política de CORS configurada. Requisições de origens não
+// permitidas seguem sem os headers de CORS, e o navegador bloqueia a resposta;
+// preflights de origens, métodos ou headers não permitidos são recusados com
+// o código cors_forbidden.
+func Cors(opts CorsOptions) gin.HandlerFunc {
+	policy := newCorsPolicy(opts)
+
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, DELETE")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type")
-		c.Writer.Header().Set("Content-Type", "application/json")
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		// A resposta depende da origem, então caches não podem compartilhá-la
+		// entre origens diferentes
+		c.Writer.Header().Add("Vary", "Origin")
+		if preflight {
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+		}
 
-		fmt.Println(c.Request.Method)
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		if !policy.allowOrigin(origin) {
+			if preflight {
+				abortWithError(c, domain.NewError(domain.CodeCorsForbidden, "Origin not allowed"))
+				return
+			}
+			c.Next()
+			return
+		}
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(200)
+		header := c.Writer.Header()
+		if policy.anyOrigin && !opts.AllowCredentials {
+			header.Set("Access-Control-Allow-Origin", "*")
 		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if opts.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if policy.exposedHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", policy.exposedHeaders)
+			}
 			c.Next()
+			return
+		}
+
+		requestedHeaders := c.GetHeader("Access-Control-Request-Headers")
+		if !policy.allowMethod(c.GetHeader("Access-Control-Request-Method")) || !policy.allowHeaders(requestedHeaders) {
+			abortWithError(c, domain.NewError(domain.CodeCorsForbidden, "Method or headers not allowed"))
+			return
+		}
+
+		header.Set("Access-Control-Allow-Methods", policy.allowedMethods)
+		if policy.anyHeader {
+			header.Set("Access-Control-Allow-Headers", requestedHeaders)
+		} else {
+			header.Set("Access-Control-Allow-Headers", policy.allowedHeaders)
+		}
+		if opts.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge.Seconds())))
+		}
+
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+func newCorsPolicy(opts CorsOptions) *corsPolicy {
+	if len(opts.AllowedMethods) == 0 {
+		opts.AllowedMethods = defaultCorsMethods
+	}
+	if len(opts.AllowedHeaders) == 0 {
+		opts.AllowedHeaders = defaultCorsHeaders
+	}
+
+	policy := &corsPolicy{
+		origins: make(map[string]bool),
+		methods: make(map[string]bool),
+		headers: make(map[string]bool),
+	}
+
+	for _, origin := range opts.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		switch {
+		case origin == "*":
+			policy.anyOrigin = true
+		case strings.Contains(origin, "*"):
+			parts := strings.SplitN(origin, "*", 2)
+			policy.wildcards = append(policy.wildcards, originWildcard{prefix: parts[0], suffix: parts[1]})
+		default:
+			policy.origins[origin] = true
+		}
+	}
+
+	var methods []string
+	for _, method := range opts.AllowedMethods {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		policy.methods[method] = true
+		methods = append(methods, method)
+	}
+	policy.allowedMethods = strings.Join(methods, ", ")
+
+	var headers []string
+	for _, header := range opts.AllowedHeaders {
+		header = strings.TrimSpace(header)
+		if header == "*" {
+			policy.anyHeader = true
+			continue
+		}
+		policy.headers[strings.ToLower(header)] = true
+		headers = append(headers, http.CanonicalHeaderKey(header))
+	}
+	policy.allowedHeaders = strings.Join(headers, ", ")
+	policy.exposedHeaders = strings.Join(opts.ExposedHeaders, ", ")
+
+	return policy
+}
+
+func (p *corsPolicy) allowOrigin(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if p.origins[origin] {
+		return true
+	}
+
+	for _, w := range p.wildcards {
+		if len(origin) <= len(w.prefix)+len(w.suffix) ||
+			!strings.HasPrefix(origin, w.prefix) || !strings.HasSuffix(origin, w.suffix) {
+			continue
+		}
+
+		// O curinga representa apenas rótulos de subdomínio, como "api" ou "v1.api"
+		label := origin[len(w.prefix) : len(origin)-len(w.suffix)]
+		if !strings.ContainsAny(label, "/:@") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *corsPolicy) allowMethod(method string) bool {
+	return p.methods[strings.ToUpper(method)]
+}
+
+func (p *corsPolicy) allowHeaders(requested string) bool {
+	if p.anyHeader || requested == "" {
+		return true
+	}
+
+	for _, header := range strings.Split(requested, ",") {
+		header = strings.ToLower(strings.TrimSpace(header))
+		if header != "" && !p.headers[header] {
+			return false
 		}
 	}
+	return true
 }
@@ -0,0 +1,124 @@
+package middleware_test
+
+import (
+	"b2w/swapi-challenge/api/middleware"
+	"b2w/swapi-challenge/api/presenter"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCorsRouter(opts middleware.CorsOptions) *gin.Engine {
+	router := gin.New()
+	router.Use(middleware.Errors())
+	router.Use(middleware.Cors(opts))
+	router.GET("/v1/planets", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	return router
+}
+
+func corsRequest(router *gin.Engine, method, origin string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/v1/planets", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCors(t *testing.T) {
+	router := newCorsRouter(middleware.CorsOptions{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.swapi.dev"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+
+	// Testing requests without origin
+	rec := corsRequest(router, "GET", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Origin", rec.Header().Get("Vary"))
+	assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	// Testing allowed origins
+	for _, origin := range []string{"https://app.example.com", "https://API.swapi.dev", "https://v1.api.swapi.dev"} {
+		rec = corsRequest(router, "GET", origin, nil)
+		assert.Equal(t, http.StatusOK, rec.Code, origin)
+		assert.Equal(t, origin, rec.Header().Get("Access-Control-Allow-Origin"), origin)
+		assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"), origin)
+		assert.Equal(t, "X-Request-ID", rec.Header().Get("Access-Control-Expose-Headers"), origin)
+	}
+
+	// Testing origins not allowed
+	for _, origin := range []string{"https://evil.com", "http://api.swapi.dev", "https://swapi.dev", "https://evil.com/.swapi.dev"} {
+		rec = corsRequest(router, "GET", origin, nil)
+		assert.Equal(t, http.StatusOK, rec.Code, origin)
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"), origin)
+	}
+
+	// Testing preflight
+	rec = corsRequest(router, "OPTIONS", "https://app.example.com", map[string]string{
+		"Access-Control-Request-Method":  "PATCH",
+		"Access-Control-Request-Headers": "content-type, x-api-key",
+	})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, HEAD, POST, PUT, PATCH, DELETE", rec.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Accept, Authorization, Content-Type, X-Api-Key, X-Request-Id", rec.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", rec.Header().Get("Access-Control-Max-Age"))
+	assert.Equal(t, []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}, rec.Header().Values("Vary"))
+	assert.Empty(t, rec.Header().Get("Content-Type"))
+
+	// Testing preflights refused
+	refused := []struct {
+		origin  string
+		headers map[string]string
+		detail  string
+	}{
+		{"https://evil.com", map[string]string{"Access-Control-Request-Method": "GET"}, "Origin not allowed"},
+		{"https://app.example.com", map[string]string{"Access-Control-Request-Method": "TRACE"}, "Method or headers not allowed"},
+		{"https://app.example.com", map[string]string{"Access-Control-Request-Method": "GET", "Access-Control-Request-Headers": "X-Custom"}, "Method or headers not allowed"},
+	}
+	for _, r := range refused {
+		rec = corsRequest(router, "OPTIONS", r.origin, r.headers)
+		assert.Equal(t, http.StatusForbidden, rec.Code, r)
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Methods"), r)
+		assert.Equal(t, presenter.ProblemContentType, rec.Header().Get("Content-Type"), r)
+
+		var body map[string]interface{}
+		err := json.Unmarshal(rec.Body.Bytes(), &body)
+		assert.Nil(t, err, r)
+		assert.Equal(t, "cors_forbidden", body["code"], r)
+		assert.Equal(t, r.detail, body["detail"], r)
+	}
+}
+
+func TestCorsAnyOrigin(t *testing.T) {
+	router := newCorsRouter(middleware.CorsOptions{
+		AllowedOrigins: []string{"*"},
+		AllowedHeaders: []string{"*"},
+	})
+
+	// Testing wildcard origin without credentials
+	rec := corsRequest(router, "GET", "https://any.com", nil)
+	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+
+	// Testing any requested header is allowed
+	rec = corsRequest(router, "OPTIONS", "https://any.com", map[string]string{
+		"Access-Control-Request-Method":  "DELETE",
+		"Access-Control-Request-Headers": "X-Custom",
+	})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "X-Custom", rec.Header().Get("Access-Control-Allow-Headers"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Max-Age"))
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"b2w/swapi-challenge/api/presenter"
+	"b2w/swapi-challenge/domain"
+	"b2w/swapi-challenge/infra/logger"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatusClientClosedRequest é o status usado quando o cliente desiste da
+// requisição antes da resposta, seguindo a convenção do nginx
+const StatusClientClosedRequest = 499
+
+var codeStatus = map[domain.Code]int{
+	domain.CodeInvalidInput:     http.StatusBadRequest,
+	domain.CodeUnauthorized:     http.StatusUnauthorized,
+	domain.CodeForbidden:        http.StatusForbidden,
+	domain.CodeCorsForbidden:    http.StatusForbidden,
+	domain.CodeNotFound:         http.StatusNotFound,
+	domain.CodeConflict:         http.StatusConflict,
+	domain.CodeValidationFailed: http.StatusUnprocessableEntity,
+	domain.CodeRateLimited:      http.StatusTooManyRequests,
+	domain.CodeInternal:         http.StatusInternalServerError,
+	domain.CodeUnavailable:      http.StatusServiceUnavailable,
+	domain.CodeCanceled:         StatusClientClosedRequest,
+}
+
+// StatusOf retorna o status HTTP correspondente ao código do erro
+func StatusOf(code domain.Code) int {
+	if status, ok := codeStatus[code]; ok {
+		return status
+	}
+
+	return http.StatusInternalServerError
+}
+
+// Errors renderiza o último erro registrado com c.Error como problem+json.
+// Deve ficar depois dos middlewares que leem o status da resposta, para que
+// eles vejam o status do erro.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		writeProblem(c, c.Errors.Last().Err)
+	}
+}
+
+// abortWithError interrompe a requisição, deixando a resposta para o middleware Errors
+func abortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// NewProblem converte o erro no problema descrito na resposta. Erros fora do
+// domínio são tratados como internos, com a causa registrada no log.
+func NewProblem(c *gin.Context, err error) presenter.Problem {
+	var domainErr *domain.Error
+	if !errors.As(err, &domainErr) {
+		domainErr = domain.Wrap(err, domain.CodeInternal, "Internal server error")
+	}
+
+	status := StatusOf(domainErr.Code)
+	if status >= http.StatusInternalServerError && domainErr.Cause != nil {
+		// A causa não é exposta ao cliente, então fica registrada no log
+		RequestLogger(c).Error().Err(domainErr.Cause).Str("code", string(domainErr.Code)).Msg(domainErr.Message)
+	}
+
+	return presenter.NewProblem(domainErr, status, c.Request.URL.Path, logger.RequestID(c.Request.Context()))
+}
+
+func writeProblem(c *gin.Context, err error) {
+	problem := NewProblem(c, err)
+	body, marshalErr := json.Marshal(problem)
+	if marshalErr != nil {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.Data(problem.Status, presenter.ProblemContentType, body)
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"b2w/swapi-challenge/domain"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Logger registra uma linha de acesso por requisição, com nível de acordo
+// com o status da resposta
+func Logger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		log := RequestLogger(c)
+
+		event := log.Info()
+		switch {
+		case status == StatusClientClosedRequest:
+			// Requisições abandonadas pelo cliente não indicam falha da API
+		case status >= http.StatusInternalServerError:
+			event = log.Error()
+		case status >= http.StatusBadRequest:
+			event = log.Warn()
+		}
+
+		event.
+			Str("method", c.Request.Method).
+			Str("path", c.Request.URL.Path).
+			Str("route", Route(c)).
+			Int("status", status).
+			Int("size", c.Writer.Size()).
+			Str("client_ip", c.ClientIP()).
+			Dur("duration_ms", time.Since(start)).
+			Msg("request handled")
+	}
+}
+
+// Recovery transforma panics em respostas 500, registrando o stack trace no
+// logger da requisição. Deve ficar depois do middleware Errors, que renderiza a resposta.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				RequestLogger(c).Error().
+					Interface("panic", r).
+					Str("stack", string(debug.Stack())).
+					Msg("panic recovered")
+
+				abortWithError(c, domain.NewError(domain.CodeInternal, "Internal server error"))
+			}
+		}()
+
+		c.Next()
+	}
+}
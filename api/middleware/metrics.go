@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"b2w/swapi-challenge/infra/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics conta as requisições e mede sua duração por rota e status. A rota
+// é o padrão registrado (ex.: /v1/planets/:id), evitando um rótulo por ID, ou
+// a definida por SetRoute.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := Route(c)
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
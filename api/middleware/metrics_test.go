@@ -0,0 +1,39 @@
+package middleware_test
+
+import (
+	"b2w/swapi-challenge/api/middleware"
+	"b2w/swapi-challenge/infra/metrics"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	router := gin.New()
+	router.Use(middleware.Metrics())
+	router.GET("/v1/planets/:id", func(c *gin.Context) {
+		c.Status(http.StatusNotFound)
+	})
+
+	found := metrics.HTTPRequests.WithLabelValues("GET", "/v1/planets/:id", "404")
+	unmatched := metrics.HTTPRequests.WithLabelValues("GET", "unmatched", "404")
+	foundBefore := testutil.ToFloat64(found)
+	unmatchedBefore := testutil.ToFloat64(unmatched)
+
+	// Testing requests are labeled by route pattern instead of path
+	for _, path := range []string{"/v1/planets/1", "/v1/planets/2"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	}
+	assert.Equal(t, foundBefore+2, testutil.ToFloat64(found))
+
+	// Testing requests without a matching route
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/unknown", nil))
+	assert.Equal(t, unmatchedBefore+1, testutil.ToFloat64(unmatched))
+}
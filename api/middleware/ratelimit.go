@@ -0,0 +1,272 @@
+package middleware
+
+import (
+	"b2w/swapi-challenge/domain"
+	"b2w/swapi-challenge/infra/cache"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultRateLimitClients = 10000
+
+// RateLimit permite Requests requisições a cada Period, acumulando até Burst
+// requisições. Burst zerado equivale a Requests e Requests zerado desativa o limite.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+type RateLimitOptions struct {
+	Read  RateLimit
+	Write RateLimit
+	// Batch limita as operações em lote, como /v1/planets:batch, que não
+	// consomem o limite de escritas
+	Batch RateLimit
+	// MaxClients limita a quantidade de clientes acompanhados em memória. Os
+	// clientes sem requisições há mais tempo são descartados primeiro.
+	MaxClients int
+	// TrustedProxies são as redes dos proxies à frente da API. O header
+	// X-Forwarded-For só identifica o cliente nas requisições vindas deles.
+	TrustedProxies []*net.IPNet
+}
+
+type tokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets *cache.LRU
+}
+
+// RateLimiter limita as requisições de cada cliente com um token bucket, com
+// limites separados para leituras, escritas e operações em lote. O cliente é
+// identificado pelas credenciais autenticadas ou, sem autenticação, pelo IP.
+func RateLimiter(opts RateLimitOptions) gin.HandlerFunc {
+	limiter := newRateLimiter(opts)
+
+	return func(c *gin.Context) {
+		rule, class := opts.Write, "write"
+		if IsBatch(c) {
+			rule, class = opts.Batch, "batch"
+		} else if isReadMethod(c.Request.Method) {
+			rule, class = opts.Read, "read"
+		}
+		if rule.Requests <= 0 || rule.Period <= 0 {
+			c.Next()
+			return
+		}
+
+		result := limiter.take(rateLimitKey(c, opts.TrustedProxies)+":"+class, rule, time.Now())
+
+		c.Header("RateLimit-Limit", strconv.Itoa(result.limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.reset)))
+
+		if !result.allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.retryAfter)))
+			abortWithError(c, domain.NewError(domain.CodeRateLimited, "Too many requests, try again later"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AuthFailureLimiter limita as tentativas com credenciais inválidas de cada IP,
+// com o limite de escritas. Deve ser registrado antes da autenticação: o IP
+// que esgotou as tentativas é recusado sem que as credenciais sejam verificadas.
+func AuthFailureLimiter(opts RateLimitOptions) gin.HandlerFunc {
+	limiter := newRateLimiter(opts)
+	rule := opts.Write
+
+	return func(c *gin.Context) {
+		if rule.Requests <= 0 || rule.Period <= 0 {
+			c.Next()
+			return
+		}
+
+		key := "ip:" + clientIP(c.Request, opts.TrustedProxies) + ":auth"
+		if retryAfter, ok := limiter.available(key, rule, time.Now()); !ok {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(retryAfter)))
+			abortWithError(c, domain.NewError(domain.CodeRateLimited, "Too many requests, try again later"))
+			return
+		}
+
+		c.Next()
+
+		var domainErr *domain.Error
+		if last := c.Errors.Last(); last != nil && errors.As(last.Err, &domainErr) && domainErr.Code == domain.CodeUnauthorized {
+			limiter.take(key, rule, time.Now())
+		}
+	}
+}
+
+func newRateLimiter(opts RateLimitOptions) *rateLimiter {
+	if opts.MaxClients <= 0 {
+		opts.MaxClients = defaultRateLimitClients
+	}
+	return &rateLimiter{buckets: cache.NewLRU(opts.MaxClients)}
+}
+
+type rateLimitResult struct {
+	allowed    bool
+	limit      int
+	remaining  int
+	reset      time.Duration
+	retryAfter time.Duration
+}
+
+func (l *rateLimiter) take(key string, rule RateLimit, now time.Time) rateLimitResult {
+	capacity, perSecond := rule.capacity(), rule.perSecond()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket := l.bucket(key, rule, now)
+
+	result := rateLimitResult{limit: int(capacity)}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.allowed = true
+	} else {
+		result.retryAfter = secondsDuration((1 - bucket.tokens) / perSecond)
+	}
+	result.remaining = int(math.Floor(bucket.tokens))
+	result.reset = secondsDuration((capacity - bucket.tokens) / perSecond)
+
+	// Depois do reset o bucket está cheio, igual a um cliente novo
+	l.buckets.Set(key, bucket, now.Add(result.reset))
+
+	return result
+}
+
+// available informa se o bucket tem uma requisição disponível, sem consumi-la,
+// ou em quanto tempo terá
+func (l *rateLimiter) available(key string, rule RateLimit, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket := l.bucket(key, rule, now)
+	if bucket.tokens >= 1 {
+		return 0, true
+	}
+	return secondsDuration((1 - bucket.tokens) / rule.perSecond()), false
+}
+
+// bucket retorna o bucket da chave com os tokens renovados até now
+func (l *rateLimiter) bucket(key string, rule RateLimit, now time.Time) tokenBucket {
+	capacity := rule.capacity()
+
+	value, ok := l.buckets.Get(key)
+	if !ok {
+		return tokenBucket{tokens: capacity, updatedAt: now}
+	}
+
+	bucket := value.(tokenBucket)
+	elapsed := now.Sub(bucket.updatedAt).Seconds()
+	bucket.tokens = math.Min(capacity, bucket.tokens+elapsed*rule.perSecond())
+	bucket.updatedAt = now
+	return bucket
+}
+
+func (r RateLimit) capacity() float64 {
+	if r.Burst <= 0 {
+		return float64(r.Requests)
+	}
+	return float64(r.Burst)
+}
+
+func (r RateLimit) perSecond() float64 {
+	return float64(r.Requests) / r.Period.Seconds()
+}
+
+func rateLimitKey(c *gin.Context, trustedProxies []*net.IPNet) string {
+	if principal, ok := CurrentPrincipal(c); ok {
+		return principal.Method + ":" + principal.Subject
+	}
+	return "ip:" + clientIP(c.Request, trustedProxies)
+}
+
+// clientIP retorna o IP de quem abriu a conexão. Quando a conexão vem de um
+// proxy confiável, o cliente é o último endereço do X-Forwarded-For que não
+// pertence a um proxy confiável, já que os anteriores podem ter sido
+// informados pelo próprio cliente.
+func clientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !isTrustedProxy(ip, trustedProxies) {
+		return host
+	}
+
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if hop == nil {
+			break
+		}
+		ip = hop
+		if !isTrustedProxy(hop, trustedProxies) {
+			break
+		}
+	}
+	return ip.String()
+}
+
+func isTrustedProxy(ip net.IP, trustedProxies []*net.IPNet) bool {
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTrustedProxies converte os endereços dos proxies confiáveis, em notação
+// CIDR ou como IPs isolados
+func ParseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			proxy = fmt.Sprintf("%s/%d", proxy, bits)
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,216 @@
+package middleware_test
+
+import (
+	"b2w/swapi-challenge/api/middleware"
+	"b2w/swapi-challenge/config"
+	"b2w/swapi-challenge/infra/auth"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	router := gin.New()
+	router.Use(middleware.Errors())
+	router.Use(middleware.RateLimiter(middleware.RateLimitOptions{
+		Read:  middleware.RateLimit{Requests: 3, Period: time.Hour},
+		Write: middleware.RateLimit{Requests: 60, Period: time.Minute, Burst: 1},
+	}))
+	router.GET("/v1/planets", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/v1/planets", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	request := func(method, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/v1/planets", nil)
+		req.RemoteAddr = ip + ":1234"
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Testing reads until the bucket is empty
+	for i := 2; i >= 0; i-- {
+		rec := request("GET", "10.0.0.1")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "3", rec.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, strconv.Itoa(i), rec.Header().Get("RateLimit-Remaining"))
+	}
+
+	rec := request("GET", "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1200", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "3600", rec.Header().Get("RateLimit-Reset"))
+
+	var body map[string]interface{}
+	err := json.Unmarshal(rec.Body.Bytes(), &body)
+	assert.Nil(t, err)
+	assert.Equal(t, "Too many requests, try again later", body["detail"])
+	assert.Equal(t, "rate_limited", body["code"])
+
+	// Testing writes have a separate limit
+	rec = request("POST", "10.0.0.1")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = request("POST", "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+
+	// Testing other clients are not affected
+	rec = request("GET", "10.0.0.2")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Testing the bucket refills over time
+	time.Sleep(time.Second)
+	rec = request("POST", "10.0.0.1")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestRateLimiterByAPIKey(t *testing.T) {
+	authenticator, err := auth.NewAuthenticator(config.Auth{
+		APIKeys: []config.APIKey{
+			{Name: "first", Key: "first-key", Scopes: []string{auth.ScopeWrite}},
+			{Name: "second", Key: "second-key", Scopes: []string{auth.ScopeWrite}},
+		},
+	})
+	assert.Nil(t, err)
+
+	router := gin.New()
+	router.Use(middleware.Errors())
+	router.Use(middleware.Auth(authenticator, middleware.AuthOptions{}))
+	router.Use(middleware.RateLimiter(middleware.RateLimitOptions{
+		Write: middleware.RateLimit{Requests: 1, Period: time.Hour},
+	}))
+	router.POST("/v1/planets", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	request := func(key string) int {
+		req := httptest.NewRequest("POST", "/v1/planets", nil)
+		req.Header.Set(middleware.APIKeyHeader, key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Testing clients behind the same IP are limited by their API keys
+	assert.Equal(t, http.StatusCreated, request("first-key"))
+	assert.Equal(t, http.StatusTooManyRequests, request("first-key"))
+	assert.Equal(t, http.StatusCreated, request("second-key"))
+}
+
+func TestRateLimiterBatch(t *testing.T) {
+	router := gin.New()
+	router.Use(middleware.Errors())
+	router.Use(middleware.RateLimiter(middleware.RateLimitOptions{
+		Write: middleware.RateLimit{Requests: 1, Period: time.Hour},
+		Batch: middleware.RateLimit{Requests: 1, Period: time.Hour},
+	}))
+	router.POST("/v1/planets/:id", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	request := func(batch bool) int {
+		req := httptest.NewRequest("POST", "/v1/planets/batch", nil)
+		if batch {
+			req = req.WithContext(middleware.WithBatch(req.Context()))
+		}
+		req.RemoteAddr = "10.0.0.1:1234"
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Testing batches have a separate limit from writes
+	assert.Equal(t, http.StatusCreated, request(true))
+	assert.Equal(t, http.StatusTooManyRequests, request(true))
+	assert.Equal(t, http.StatusCreated, request(false))
+	assert.Equal(t, http.StatusTooManyRequests, request(false))
+}
+
+func TestRateLimiterForwardedFor(t *testing.T) {
+	trustedProxies, err := middleware.ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.0.1"})
+	assert.Nil(t, err)
+
+	router := gin.New()
+	router.Use(middleware.Errors())
+	router.Use(middleware.RateLimiter(middleware.RateLimitOptions{
+		Write:          middleware.RateLimit{Requests: 1, Period: time.Hour},
+		TrustedProxies: trustedProxies,
+	}))
+	router.POST("/v1/planets", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	request := func(remoteAddr, forwardedFor string) int {
+		req := httptest.NewRequest("POST", "/v1/planets", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Testing rotating X-Forwarded-For from an untrusted client
+	assert.Equal(t, http.StatusCreated, request("203.0.113.1:1234", "198.51.100.1"))
+	assert.Equal(t, http.StatusTooManyRequests, request("203.0.113.1:1234", "198.51.100.2"))
+	assert.Equal(t, http.StatusTooManyRequests, request("203.0.113.1:1234", "198.51.100.3, 198.51.100.4"))
+
+	// Testing clients behind trusted proxies
+	assert.Equal(t, http.StatusCreated, request("10.0.0.1:1234", "198.51.100.1"))
+	assert.Equal(t, http.StatusTooManyRequests, request("10.0.0.2:1234", "198.51.100.9, 198.51.100.1, 192.168.0.1"))
+	assert.Equal(t, http.StatusCreated, request("10.0.0.1:1234", "198.51.100.2"))
+
+	// Testing invalid trusted proxies
+	_, err = middleware.ParseTrustedProxies([]string{"proxy.example.com"})
+	assert.NotNil(t, err)
+}
+
+func TestAuthFailureLimiter(t *testing.T) {
+	authenticator, err := auth.NewAuthenticator(config.Auth{
+		APIKeys: []config.APIKey{
+			{Name: "first", Key: "first-key", Scopes: []string{auth.ScopeWrite}},
+			{Name: "second", Key: "second-key", Scopes: []string{auth.ScopeWrite}},
+		},
+	})
+	assert.Nil(t, err)
+
+	opts := middleware.RateLimitOptions{
+		Write: middleware.RateLimit{Requests: 2, Period: time.Hour},
+	}
+
+	router := gin.New()
+	router.Use(middleware.Errors())
+	router.Use(middleware.AuthFailureLimiter(opts))
+	router.Use(middleware.Auth(authenticator, middleware.AuthOptions{}))
+	router.Use(middleware.RateLimiter(opts))
+	router.POST("/v1/planets", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	request := func(ip, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/v1/planets", nil)
+		req.RemoteAddr = ip + ":1234"
+		req.Header.Set(middleware.APIKeyHeader, key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Testing valid credentials are not charged to the IP
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusCreated, request("10.0.0.1", "first-key").Code)
+	}
+
+	// Testing invalid credentials until the IP is blocked
+	assert.Equal(t, http.StatusUnauthorized, request("10.0.0.1", "guess-1").Code)
+	assert.Equal(t, http.StatusUnauthorized, request("10.0.0.1", "guess-2").Code)
+
+	rec := request("10.0.0.1", "guess-3")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "1800", rec.Header().Get("Retry-After"))
+
+	// Testing the blocked IP is refused before the credentials are checked
+	rec = request("10.0.0.1", "second-key")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+
+	// Testing other IPs are not affected
+	assert.Equal(t, http.StatusUnauthorized, request("10.0.0.2", "guess-4").Code)
+}
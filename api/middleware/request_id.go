@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"b2w/swapi-challenge/infra/logger"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+const loggerKey = "logger"
+
+// IDs recebidos fora desse formato são substituídos, evitando que o cliente
+// injete conteúdo arbitrário nos logs
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID identifica cada requisição pelo header X-Request-ID, gerando um
+// novo ID quando o cliente não informa um válido. O ID é devolvido na resposta,
+// propagado no contexto da requisição e incluído no logger da requisição.
+func RequestID(log zerolog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), id))
+		c.Set(loggerKey, logger.Ctx(c.Request.Context(), log))
+
+		c.Next()
+	}
+}
+
+// RequestLogger retorna o logger da requisição, já acrescido do request ID
+func RequestLogger(c *gin.Context) *zerolog.Logger {
+	if log, ok := c.Get(loggerKey); ok {
+		return log.(*zerolog.Logger)
+	}
+
+	nop := zerolog.Nop()
+	return &nop
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
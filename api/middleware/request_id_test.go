@@ -0,0 +1,101 @@
+package middleware_test
+
+import (
+	"b2w/swapi-challenge/api/middleware"
+	"b2w/swapi-challenge/infra/logger"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	var out bytes.Buffer
+	router := gin.New()
+	router.Use(middleware.RequestID(logger.New(&out, "info")))
+	router.Use(middleware.Logger())
+	router.GET("/v1/planets/:id", func(c *gin.Context) {
+		middleware.RequestLogger(c).Info().Msg("handler")
+		c.String(http.StatusOK, logger.RequestID(c.Request.Context()))
+	})
+
+	// Testing the request id sent by the client is kept
+	req := httptest.NewRequest("GET", "/v1/planets/1", nil)
+	req.Header.Set(middleware.RequestIDHeader, "client-id.1")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, "client-id.1", rec.Header().Get(middleware.RequestIDHeader))
+	assert.Equal(t, "client-id.1", rec.Body.String())
+
+	// Testing every log line carries the request id
+	lines := logLines(t, &out)
+	assert.Len(t, lines, 2)
+	for _, line := range lines {
+		assert.Equal(t, "client-id.1", line["request_id"])
+	}
+	assert.Equal(t, "request handled", lines[1]["message"])
+	assert.Equal(t, "/v1/planets/:id", lines[1]["route"])
+	assert.Equal(t, float64(http.StatusOK), lines[1]["status"])
+
+	// Testing an id is generated when missing or invalid
+	for _, header := range []string{"", "invalid id\nwith line break"} {
+		req := httptest.NewRequest("GET", "/v1/planets/1", nil)
+		req.Header.Set(middleware.RequestIDHeader, header)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		id := rec.Header().Get(middleware.RequestIDHeader)
+		assert.Len(t, id, 32)
+		assert.Equal(t, id, rec.Body.String())
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	var out bytes.Buffer
+	router := gin.New()
+	router.Use(middleware.RequestID(logger.New(&out, "info")))
+	router.Use(middleware.Errors())
+	router.Use(middleware.Recovery())
+	router.GET("/panic", func(c *gin.Context) {
+		panic("unexpected")
+	})
+
+	req := httptest.NewRequest("GET", "/panic", nil)
+	req.Header.Set(middleware.RequestIDHeader, "panic-id")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	// Testing the error response and the log line carry the request id
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	var body map[string]interface{}
+	err := json.Unmarshal(rec.Body.Bytes(), &body)
+	assert.Nil(t, err)
+	assert.Equal(t, "panic-id", body["request_id"])
+
+	lines := logLines(t, &out)
+	assert.Len(t, lines, 1)
+	assert.Equal(t, "error", lines[0]["level"])
+	assert.Equal(t, "panic-id", lines[0]["request_id"])
+	assert.Equal(t, "unexpected", lines[0]["panic"])
+}
+
+func logLines(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	var lines []map[string]interface{}
+
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		err := json.Unmarshal(scanner.Bytes(), &line)
+		assert.Nil(t, err)
+		lines = append(lines, line)
+	}
+
+	return lines
+}
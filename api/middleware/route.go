@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BatchSuffix identifica as operações em lote sobre uma coleção, como em
+// /v1/planets:batch
+const BatchSuffix = ":batch"
+
+const routeKey = "route"
+
+type batchContextKey struct{}
+
+// WithBatch marca a requisição como uma operação em lote. Só as requisições
+// feitas pela forma :batch devem ser marcadas.
+func WithBatch(ctx context.Context) context.Context {
+	return context.WithValue(ctx, batchContextKey{}, true)
+}
+
+// IsBatch informa se a requisição foi marcada como uma operação em lote
+func IsBatch(c *gin.Context) bool {
+	batch, _ := c.Request.Context().Value(batchContextKey{}).(bool)
+	return batch
+}
+
+// SetRoute substitui a rota da requisição nos logs, nas métricas e nos spans.
+// É usada pelas ações atendidas por uma rota com parâmetro, como
+// /v1/planets/import, que seriam registradas como /v1/planets/:id.
+func SetRoute(c *gin.Context, route string) {
+	c.Set(routeKey, route)
+}
+
+// Route retorna a rota da requisição: a definida por SetRoute ou o padrão
+// registrado no router, vazio quando nenhuma rota corresponde ao caminho
+func Route(c *gin.Context) string {
+	if route := c.GetString(routeKey); route != "" {
+		return route
+	}
+	return c.FullPath()
+}
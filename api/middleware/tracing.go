@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"b2w/swapi-challenge/infra/logger"
+	"b2w/swapi-challenge/infra/tracing"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "b2w/swapi-challenge/api"
+
+// Tracing cria um span para cada requisição, continuando o trace recebido no
+// header traceparent quando presente. O span fica no contexto da requisição,
+// sendo o pai dos spans do Manager, do banco de dados e da SWAPI.
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		ctx, span := tracing.Start(ctx, tracerName, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethodKey.String(c.Request.Method),
+				semconv.HTTPRouteKey.String(route),
+				semconv.HTTPTargetKey.String(c.Request.URL.RequestURI()),
+				semconv.HTTPClientIPKey.String(c.ClientIP()),
+				attribute.String("http.request_id", logger.RequestID(ctx)),
+			),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+
+		// Incluindo o trace nos logs da requisição para relacioná-los aos spans
+		if sc := span.SpanContext(); sc.IsValid() {
+			log := RequestLogger(c).With().Str("trace_id", sc.TraceID().String()).Logger()
+			c.Set(loggerKey, &log)
+		}
+
+		c.Next()
+
+		// A rota pode ter sido substituída pelo handler, veja SetRoute
+		if changed := Route(c); changed != "" && changed != route {
+			span.SetName(c.Request.Method + " " + changed)
+			span.SetAttributes(semconv.HTTPRouteKey.String(changed))
+		}
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package middleware_test
+
+import (
+	"b2w/swapi-challenge/api/middleware"
+	"b2w/swapi-challenge/infra/logger"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	var out bytes.Buffer
+	router := gin.New()
+	router.Use(middleware.RequestID(logger.New(&out, "info")))
+	router.Use(middleware.Tracing())
+	router.Use(middleware.Logger())
+	router.GET("/v1/planets/:id", func(c *gin.Context) {
+		// O span da requisição deve estar no contexto usado pelo Manager
+		assert.True(t, trace.SpanContextFromContext(c.Request.Context()).IsValid())
+		c.Status(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest("GET", "/v1/planets/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set(middleware.RequestIDHeader, "trace-req")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	// Testing the span continues the incoming trace and is named by route
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+
+	span := spans[0]
+	assert.Equal(t, "GET /v1/planets/:id", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.Equal(t, codes.Error, span.Status().Code)
+	assert.Contains(t, span.Attributes(), attribute.Int("http.status_code", http.StatusInternalServerError))
+	assert.Contains(t, span.Attributes(), attribute.String("http.request_id", "trace-req"))
+
+	// Testing the access log carries the trace id
+	lines := logLines(t, &out)
+	assert.Len(t, lines, 1)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", lines[0]["trace_id"])
+}
+
+func TestTracingRoute(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	router := gin.New()
+	router.Use(middleware.Tracing())
+	router.POST("/v1/planets/:id", func(c *gin.Context) {
+		middleware.SetRoute(c, "/v1/planets:batch")
+		c.Status(http.StatusMultiStatus)
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("POST", "/v1/planets/batch", nil))
+
+	// Testing the span is renamed by the route set by the handler
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "POST /v1/planets:batch", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("http.route", "/v1/planets:batch"))
+}
@@ -0,0 +1,35 @@
+package presenter
+
+import (
+	"b2w/swapi-challenge/domain"
+	"encoding/json"
+	"fmt"
+)
+
+// MaxBatchSize é a quantidade máxima de itens de uma operação em lote
+const MaxBatchSize = 100
+
+// BatchItemResult é o resultado de um item de uma operação em lote, na mesma
+// posição do item na requisição
+type BatchItemResult struct {
+	Status int      `json:"status"`
+	ID     string   `json:"id,omitempty"`
+	Error  *Problem `json:"error,omitempty"`
+}
+
+// DecodeBatch lê os itens do corpo JSON de uma operação em lote, que deve ser
+// um array com 1 a MaxBatchSize itens. Os itens são lidos separadamente, para
+// que um item inválido não impeça os demais.
+func DecodeBatch(data []byte) ([]json.RawMessage, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, domain.Wrap(err, domain.CodeInvalidInput, "Unexpected JSON format, expected an array")
+	}
+
+	if len(items) == 0 || len(items) > MaxBatchSize {
+		return nil, domain.NewError(domain.CodeValidationFailed,
+			fmt.Sprintf("Batch must have between 1 and %d items", MaxBatchSize))
+	}
+
+	return items, nil
+}
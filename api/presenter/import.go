@@ -0,0 +1,81 @@
+package presenter
+
+import (
+	"b2w/swapi-challenge/config"
+	"b2w/swapi-challenge/domain"
+	"b2w/swapi-challenge/domain/entity/planet"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const invalidImportMessage = "Invalid import params"
+
+// Caminho do planeta na SWAPI, como em https://swapi.dev/api/planets/1/
+var swapiPlanetPathPattern = regexp.MustCompile(`/planets/([1-9][0-9]*)/?$`)
+
+// ImportPlanetCommand identifica o planeta da SWAPI a ser importado pelo
+// nome, pelo ID ou pela URL. Apenas um deles deve ser informado.
+type ImportPlanetCommand struct {
+	Name string `json:"name" validate:"omitempty,max=60,planet_name"`
+	ID   int    `json:"id" validate:"omitempty,min=1"`
+	URL  string `json:"url" validate:"omitempty,swapi_planet_url"`
+}
+
+var importPlanetFields = jsonFields(reflect.TypeOf(ImportPlanetCommand{}))
+
+// DecodeImportPlanetCommand lê o comando do corpo JSON, recusando os campos desconhecidos
+func DecodeImportPlanetCommand(data []byte) (ImportPlanetCommand, error) {
+	var cmd ImportPlanetCommand
+	err := decodeCommand(data, &cmd, importPlanetFields, invalidImportMessage)
+	return cmd, err
+}
+
+func (p ImportPlanetCommand) Validate() error {
+	given := 0
+	for _, ok := range []bool{p.Name != "", p.ID != 0, p.URL != ""} {
+		if ok {
+			given++
+		}
+	}
+	if given != 1 {
+		return domain.NewError(domain.CodeValidationFailed, invalidImportMessage,
+			domain.FieldError{Field: "name", Message: "exactly one of name, id or url is required"})
+	}
+
+	return validateCommand(p, invalidImportMessage)
+}
+
+// ToReference converte o comando validado na referência usada pelo Manager
+func (p ImportPlanetCommand) ToReference() planet.SwapiReference {
+	if p.URL != "" {
+		id, _ := swapiPlanetID(p.URL)
+		return planet.SwapiReference{ID: id}
+	}
+
+	return planet.SwapiReference{ID: p.ID, Name: p.Name}
+}
+
+// swapiPlanetID extrai o ID da URL do planeta, aceitando apenas URLs com o
+// esquema e o host da SWAPI configurada
+func swapiPlanetID(rawURL string) (int, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return 0, false
+	}
+
+	base, err := url.Parse(config.Data.SWApi.BaseUrl)
+	if err != nil || u.Scheme != base.Scheme || !strings.EqualFold(u.Host, base.Host) {
+		return 0, false
+	}
+
+	match := swapiPlanetPathPattern.FindStringSubmatch(u.Path)
+	if match == nil {
+		return 0, false
+	}
+
+	id, err := strconv.Atoi(match[1])
+	return id, err == nil
+}
@@ -1,15 +1,22 @@
 package presenter
 
 import (
+	"b2w/swapi-challenge/domain"
 	"b2w/swapi-challenge/domain/entity/planet"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type AddPlanetCommand struct {
-	Name    string `json:"name"`
-	Climate string `json:"climate"`
-	Terrain string `json:"terrain"`
+	Name    string `json:"name" validate:"required,max=60,planet_name"`
+	Climate string `json:"climate" validate:"max=200,climate"`
+	Terrain string `json:"terrain" validate:"max=200,terrain"`
 }
 
 type PlanetResult struct {
@@ -18,6 +25,44 @@ type PlanetResult struct {
 	Climate     string `json:"climate"`
 	Terrain     string `json:"terrain"`
 	Apparitions int32  `json:"apparitions"`
+
+	Population *int64 `json:"population,omitempty"`
+	Diameter   *int64 `json:"diameter,omitempty"`
+	Gravity    string `json:"gravity,omitempty"`
+
+	ApparitionsUpdatedAt *time.Time `json:"apparitions_updated_at,omitempty"`
+
+	Films *[]FilmResult `json:"films,omitempty"`
+}
+
+type FilmResult struct {
+	Title       string `json:"title"`
+	EpisodeID   int    `json:"episode_id"`
+	ReleaseDate string `json:"release_date"`
+}
+
+// Expand indica quais relacionamentos devem ser incluídos na resposta
+type Expand struct {
+	Films bool
+}
+
+// ParseExpand interpreta o parâmetro expand, com valores separados por vírgula
+func ParseExpand(param string) (Expand, error) {
+	var expand Expand
+	if param == "" {
+		return expand, nil
+	}
+
+	for _, value := range strings.Split(param, ",") {
+		switch strings.TrimSpace(value) {
+		case "films":
+			expand.Films = true
+		default:
+			return expand, errors.New("unknown expand value: " + value)
+		}
+	}
+
+	return expand, nil
 }
 
 func (p AddPlanetCommand) ToModel() planet.Planet {
@@ -28,29 +73,156 @@ func (p AddPlanetCommand) ToModel() planet.Planet {
 	}
 }
 
-func NewPlanetResult(p planet.Planet) PlanetResult {
+func NewAddPlanetCommand(p planet.Planet) AddPlanetCommand {
+	return AddPlanetCommand{
+		Name:    p.Name,
+		Climate: p.Climate,
+		Terrain: p.Terrain,
+	}
+}
+
+// ApplyMergePatch aplica um JSON merge patch (RFC 7396) sobre o comando,
+// recusando os campos desconhecidos da mesma forma que DecodeAddPlanetCommand.
+// O comando resultante é retornado junto com o erro dos campos desconhecidos.
+func (p AddPlanetCommand) ApplyMergePatch(patch []byte) (AddPlanetCommand, error) {
+	var patchData interface{}
+	if err := json.Unmarshal(patch, &patchData); err != nil {
+		return p, domain.Wrap(err, domain.CodeInvalidInput, "Unexpected JSON format")
+	}
+
+	current, err := json.Marshal(p)
+	if err != nil {
+		return p, err
+	}
+
+	var currentData interface{}
+	if err := json.Unmarshal(current, &currentData); err != nil {
+		return p, err
+	}
+
+	merged, err := json.Marshal(mergePatch(currentData, patchData))
+	if err != nil {
+		return p, err
+	}
+
+	return DecodeAddPlanetCommand(merged)
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = make(map[string]interface{})
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+		} else {
+			targetObj[key] = mergePatch(targetObj[key], value)
+		}
+	}
+
+	return targetObj
+}
+
+func NewPlanetResult(p planet.Planet, expand Expand) PlanetResult {
 	if p.ID == primitive.NilObjectID {
 		return PlanetResult{}
 	}
 
-	return PlanetResult{
+	result := PlanetResult{
 		ID:          p.ID.Hex(),
 		Name:        p.Name,
 		Climate:     p.Climate,
 		Terrain:     p.Terrain,
 		Apparitions: p.Apparitions,
+		Population:  p.Population,
+		Diameter:    p.Diameter,
+		Gravity:     p.Gravity,
+	}
+	if !p.ApparitionsUpdatedAt.IsZero() {
+		updatedAt := p.ApparitionsUpdatedAt
+		result.ApparitionsUpdatedAt = &updatedAt
+	}
+	if expand.Films {
+		films := newFilmResultSlice(p.Films)
+		result.Films = &films
 	}
+
+	return result
+}
+
+func newFilmResultSlice(films []planet.Film) []FilmResult {
+	resultSlice := make([]FilmResult, 0, len(films))
+	for _, f := range films {
+		resultSlice = append(resultSlice, FilmResult{
+			Title:       f.Title,
+			EpisodeID:   f.EpisodeID,
+			ReleaseDate: f.ReleaseDate,
+		})
+	}
+
+	return resultSlice
 }
 
-func NewPlanetResultSlice(pSlice []planet.Planet) []PlanetResult {
+func NewPlanetResultSlice(pSlice []planet.Planet, expand Expand) []PlanetResult {
 	if pSlice == nil {
 		return make([]PlanetResult, 0)
 	}
 
 	resultSlice := make([]PlanetResult, 0, len(pSlice))
 	for _, p := range pSlice {
-		resultSlice = append(resultSlice, NewPlanetResult(p))
+		resultSlice = append(resultSlice, NewPlanetResult(p, expand))
 	}
 
 	return resultSlice
 }
+
+// EncodeCursor gera um cursor opaco. Sem ordenação, o cursor é apenas o ID do
+// último planeta da página; com ordenação, inclui também os valores dele nos
+// campos ordenados.
+func EncodeCursor(cursor planet.Cursor) string {
+	if cursor.IsZero() {
+		return ""
+	}
+	if len(cursor.Sort) == 0 {
+		return base64.RawURLEncoding.EncodeToString(cursor.ID[:])
+	}
+
+	data, err := bson.Marshal(cursor)
+	if err != nil {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(value string) (planet.Cursor, error) {
+	var cursor planet.Cursor
+	if value == "" {
+		return cursor, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+	if len(data) == len(cursor.ID) {
+		copy(cursor.ID[:], data)
+		return cursor, nil
+	}
+
+	if err := bson.Unmarshal(data, &cursor); err != nil {
+		return planet.Cursor{}, err
+	}
+	if cursor.IsZero() {
+		return cursor, errors.New("invalid cursor")
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,37 @@
+package presenter
+
+import (
+	"b2w/swapi-challenge/domain"
+	"net/http"
+)
+
+const (
+	ProblemContentType = "application/problem+json"
+	problemTypePrefix  = "urn:swapi-challenge:problem:"
+)
+
+// Problem é o corpo das respostas de erro, no formato da RFC 7807. O campo
+// code repete o sufixo de type para facilitar o tratamento pelos clientes.
+type Problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"`
+	Code      domain.Code         `json:"code"`
+	RequestID string              `json:"request_id,omitempty"`
+	Errors    []domain.FieldError `json:"errors,omitempty"`
+}
+
+func NewProblem(err *domain.Error, status int, instance string, requestID string) Problem {
+	return Problem{
+		Type:      problemTypePrefix + string(err.Code),
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    err.Message,
+		Instance:  instance,
+		Code:      err.Code,
+		RequestID: requestID,
+		Errors:    err.Fields,
+	}
+}
@@ -0,0 +1,206 @@
+package presenter
+
+import (
+	"b2w/swapi-challenge/config"
+	"b2w/swapi-challenge/domain"
+	"b2w/swapi-challenge/domain/entity/planet"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const invalidPlanetMessage = "Invalid planet input params"
+
+// Letras, números e espaços, além de hífen, apóstrofo e ponto (ex.: "Yavin IV", "Ord Mantell")
+var planetNamePattern = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N} '.-]*$`)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	// Os erros apontam os campos pelo nome usado no JSON
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	})
+
+	_ = v.RegisterValidation("planet_name", func(fl validator.FieldLevel) bool {
+		return planetNamePattern.MatchString(strings.TrimSpace(fl.Field().String()))
+	})
+	_ = v.RegisterValidation("swapi_planet_url", func(fl validator.FieldLevel) bool {
+		_, ok := swapiPlanetID(fl.Field().String())
+		return ok
+	})
+	_ = v.RegisterValidation("climate", func(fl validator.FieldLevel) bool {
+		return len(planet.Climates.Unknown(fl.Field().String())) == 0
+	})
+	_ = v.RegisterValidation("terrain", func(fl validator.FieldLevel) bool {
+		return len(planet.Terrains.Unknown(fl.Field().String())) == 0
+	})
+
+	return v
+}
+
+// Validate confere as regras declaradas nas tags validate do comando,
+// retornando todos os campos inválidos de uma vez
+func (p AddPlanetCommand) Validate() error {
+	return validateCommand(p, invalidPlanetMessage)
+}
+
+// ValidateChanges confere apenas os campos que diferem de before, para que
+// valores gravados antes das regras atuais não impeçam um patch dos demais campos
+func (p AddPlanetCommand) ValidateChanges(before AddPlanetCommand) error {
+	var unchanged []string
+	if p.Name == before.Name {
+		unchanged = append(unchanged, "Name")
+	}
+	if p.Climate == before.Climate {
+		unchanged = append(unchanged, "Climate")
+	}
+	if p.Terrain == before.Terrain {
+		unchanged = append(unchanged, "Terrain")
+	}
+
+	return validateCommand(p, invalidPlanetMessage, unchanged...)
+}
+
+// validateCommand confere o comando, exceto os campos de except, identificados
+// pelo nome no struct
+func validateCommand(cmd interface{}, invalidMessage string, except ...string) error {
+	err := validate.StructExcept(cmd, except...)
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	fields := make([]domain.FieldError, 0, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		fields = append(fields, domain.FieldError{Field: fieldErr.Field(), Message: fieldErrorMessage(fieldErr)})
+	}
+
+	return domain.NewError(domain.CodeValidationFailed, invalidMessage, fields...)
+}
+
+func fieldErrorMessage(fieldErr validator.FieldError) string {
+	value := fieldErr.Value()
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "max":
+		return fmt.Sprintf("must have at most %s characters", fieldErr.Param())
+	case "min":
+		return fmt.Sprintf("must be at least %s", fieldErr.Param())
+	case "swapi_planet_url":
+		return "must be a Star Wars API planet URL, like " + strings.TrimSuffix(config.Data.SWApi.BaseUrl, "/") + "/planets/1/"
+	case "planet_name":
+		return "must start with a letter or number and contain only letters, numbers, spaces, hyphens, apostrophes and periods"
+	case "climate":
+		return "unknown climate " + quoteTerms(planet.Climates.Unknown(value.(string)))
+	case "terrain":
+		return "unknown terrain " + quoteTerms(planet.Terrains.Unknown(value.(string)))
+	default:
+		return "is invalid"
+	}
+}
+
+func quoteTerms(terms []string) string {
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		quoted = append(quoted, fmt.Sprintf("%q", term))
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+// ValidateDecoded valida o comando lido com o erro decodeErr, respondendo os
+// campos desconhecidos ou com tipo errado junto com os campos inválidos em um
+// único erro. Erros no formato do JSON são retornados sem validar o comando.
+func ValidateDecoded(decodeErr error, validate func() error) error {
+	var decoded *domain.Error
+	if decodeErr != nil && (!errors.As(decodeErr, &decoded) || decoded.Code != domain.CodeValidationFailed) {
+		return decodeErr
+	}
+
+	err := validate()
+	if decodeErr == nil {
+		return err
+	}
+
+	var invalid *domain.Error
+	if !errors.As(err, &invalid) || invalid.Code != domain.CodeValidationFailed {
+		return decodeErr
+	}
+
+	// Um campo com tipo errado também falha na validação, mas é reportado uma vez só
+	fields := append([]domain.FieldError{}, decoded.Fields...)
+	reported := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		reported[field.Field] = true
+	}
+	for _, field := range invalid.Fields {
+		if !reported[field.Field] {
+			fields = append(fields, field)
+		}
+	}
+
+	return domain.NewError(domain.CodeValidationFailed, decoded.Message, fields...)
+}
+
+// DecodeAddPlanetCommand lê o comando do corpo JSON, recusando os campos
+// desconhecidos. Os campos conhecidos são lidos mesmo quando há campos
+// desconhecidos, mas não são validados, veja ValidateDecoded.
+func DecodeAddPlanetCommand(data []byte) (AddPlanetCommand, error) {
+	var cmd AddPlanetCommand
+	err := decodeCommand(data, &cmd, addPlanetFields, invalidPlanetMessage)
+	return cmd, err
+}
+
+func decodeCommand(data []byte, cmd interface{}, allowed map[string]bool, invalidMessage string) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return domain.Wrap(err, domain.CodeInvalidInput, "Unexpected JSON format")
+	}
+
+	var fields []domain.FieldError
+	for key := range raw {
+		if !allowed[key] {
+			fields = append(fields, domain.FieldError{Field: key, Message: "is not allowed"})
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+
+	if err := json.Unmarshal(data, cmd); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			return domain.Wrap(err, domain.CodeInvalidInput, "Unexpected JSON format")
+		}
+		fields = append(fields, domain.FieldError{Field: typeErr.Field, Message: "must be a " + typeErr.Type.String()})
+	}
+
+	if len(fields) > 0 {
+		return domain.NewError(domain.CodeValidationFailed, invalidMessage, fields...)
+	}
+
+	return nil
+}
+
+var addPlanetFields = jsonFields(reflect.TypeOf(AddPlanetCommand{}))
+
+func jsonFields(t reflect.Type) map[string]bool {
+	fields := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.SplitN(t.Field(i).Tag.Get("json"), ",", 2)[0]
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+
+	return fields
+}
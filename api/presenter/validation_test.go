@@ -0,0 +1,58 @@
+package presenter_test
+
+import (
+	"b2w/swapi-challenge/api/presenter"
+	"b2w/swapi-challenge/domain"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateDecoded(t *testing.T) {
+	decode := func(data string) error {
+		cmd, err := presenter.DecodeAddPlanetCommand([]byte(data))
+		return presenter.ValidateDecoded(err, cmd.Validate)
+	}
+
+	fieldsOf := func(err error) []domain.FieldError {
+		var domainErr *domain.Error
+		if assert.True(t, errors.As(err, &domainErr)) {
+			assert.Equal(t, domain.CodeValidationFailed, domainErr.Code)
+			return domainErr.Fields
+		}
+		return nil
+	}
+
+	// Testing valid command
+	assert.Nil(t, decode(`{"name":"Tatooine","climate":"arid","terrain":"desert"}`))
+
+	// Testing unknown fields reported with the invalid ones
+	err := decode(`{"name":"Tatooine!","climate":"sunny","terrain":"desert","gravity":"1 standard","diameter":10465}`)
+	assert.Equal(t, []domain.FieldError{
+		{Field: "diameter", Message: "is not allowed"},
+		{Field: "gravity", Message: "is not allowed"},
+		{Field: "name", Message: "must start with a letter or number and contain only letters, numbers, spaces, hyphens, apostrophes and periods"},
+		{Field: "climate", Message: `unknown climate "sunny"`},
+	}, fieldsOf(err))
+
+	// Testing wrong field types reported once
+	err = decode(`{"name":42,"gravity":"1 standard"}`)
+	assert.Equal(t, []domain.FieldError{
+		{Field: "gravity", Message: "is not allowed"},
+		{Field: "name", Message: "must be a string"},
+	}, fieldsOf(err))
+
+	// Testing malformed JSON not validated
+	err = decode(`{"name":`)
+	assert.True(t, errors.Is(err, domain.ErrBadParamInput))
+
+	// Testing patch with unknown fields validated after merging
+	current := presenter.AddPlanetCommand{Name: "Tatooine", Climate: "arid", Terrain: "desert"}
+	patched, err := current.ApplyMergePatch([]byte(`{"terrain":"lava","diameter":"10465"}`))
+	err = presenter.ValidateDecoded(err, func() error { return patched.ValidateChanges(current) })
+	assert.Equal(t, []domain.FieldError{
+		{Field: "diameter", Message: "is not allowed"},
+		{Field: "terrain", Message: `unknown terrain "lava"`},
+	}, fieldsOf(err))
+}
@@ -4,15 +4,105 @@ import (
 	"b2w/swapi-challenge/api/handler"
 	"b2w/swapi-challenge/api/middleware"
 	"b2w/swapi-challenge/domain/entity/planet"
+	"b2w/swapi-challenge/infra/auth"
+	"b2w/swapi-challenge/infra/health"
+	"b2w/swapi-challenge/infra/metrics"
+	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
 )
 
-func SetupRouter(pManager planet.Manager) *gin.Engine {
-	router := gin.Default()
-	router.Use(middleware.Cors())
+// RouterOptions reúne as dependências opcionais do router. As rotas que
+// dependem de um campo não informado não são registradas.
+type RouterOptions struct {
+	Refresher       planet.Refresher
+	CatalogImporter planet.CatalogImporter
+	Readiness       *health.Readiness
+	// Logger recebe os logs de acesso e de erros das requisições. Quando não
+	// informado, os logs são descartados.
+	Logger *zerolog.Logger
+	// Authenticator protege as rotas /v1. Quando não informado, as rotas são abertas.
+	Authenticator *auth.Authenticator
+	Auth          middleware.AuthOptions
+	// RateLimit limita as requisições de cada cliente às rotas /v1. Quando não
+	// informado, as requisições não são limitadas.
+	RateLimit *middleware.RateLimitOptions
+	// Cors define a política de CORS. Quando não informada, as respostas não
+	// incluem os headers de CORS.
+	Cors *middleware.CorsOptions
+}
+
+func SetupRouter(pManager planet.Manager, opts RouterOptions) http.Handler {
+	log := zerolog.Nop()
+	if opts.Logger != nil {
+		log = *opts.Logger
+	}
+
+	router := gin.New()
+	// O X-Forwarded-For pode ser informado pelo próprio cliente, então o IP
+	// registrado é o da conexão
+	router.ForwardedByClientIP = false
+	router.Use(middleware.RequestID(log))
+	router.Use(middleware.Tracing())
+	router.Use(middleware.Logger())
+	router.Use(middleware.Metrics())
+	router.Use(middleware.Errors())
+	router.Use(middleware.Recovery())
+	if opts.Cors != nil {
+		router.Use(middleware.Cors(*opts.Cors))
+	}
+
+	router.NoRoute(handler.RouteNotFound)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// As rotas administrativas exigem o próprio escopo, mas compartilham os
+	// limites de requisições com as demais
+	v1 := router.Group("")
+	admin := router.Group("")
+	if opts.Authenticator != nil {
+		if opts.RateLimit != nil {
+			authFailureLimiter := middleware.AuthFailureLimiter(*opts.RateLimit)
+			v1.Use(authFailureLimiter)
+			admin.Use(authFailureLimiter)
+		}
+		v1.Use(middleware.Auth(opts.Authenticator, opts.Auth))
+		admin.Use(middleware.Auth(opts.Authenticator, middleware.AuthOptions{Scope: auth.ScopeAdmin}))
+	}
+	if opts.RateLimit != nil {
+		rateLimiter := middleware.RateLimiter(*opts.RateLimit)
+		v1.Use(rateLimiter)
+		admin.Use(rateLimiter)
+	}
+
+	handler.CreatePlanetRoutes(v1, pManager)
+	if opts.Refresher != nil || opts.CatalogImporter != nil {
+		handler.CreateAdminRoutes(admin, opts.Refresher, opts.CatalogImporter)
+	}
+	if opts.Readiness != nil {
+		handler.CreateHealthRoutes(router, opts.Readiness)
+	}
+
+	return rewriteBatchPath(router)
+}
+
+// rewriteBatchPath atende às operações em lote, como /v1/planets:batch. O
+// router não aceita ":" no meio de um segmento, então o caminho é reescrito
+// para /v1/planets/batch antes do roteamento, e a requisição é marcada como
+// lote. Requisições diretas a /v1/planets/batch não são marcadas e não são
+// tratadas como lote.
+func rewriteBatchPath(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, middleware.BatchSuffix) {
+			u := *r.URL
+			u.Path = strings.TrimSuffix(u.Path, middleware.BatchSuffix) + "/batch"
+			u.RawPath = ""
 
-	handler.CreatePlanetRoutes(router, pManager)
+			r = r.WithContext(middleware.WithBatch(r.Context()))
+			r.URL = &u
+		}
 
-	return router
+		next.ServeHTTP(w, r)
+	})
 }
@@ -2,9 +2,8 @@ package config
 
 import (
 	"fmt"
-	"log"
-	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -19,32 +18,144 @@ type Database struct {
 	CommandTimeout    time.Duration
 }
 
+type SWApi struct {
+	BaseUrl          string
+	Timeout          time.Duration
+	MaxRetries       int
+	BaseBackoff      time.Duration
+	MaxBackoff       time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+type Cache struct {
+	Enabled     bool
+	Persistent  bool
+	Size        int
+	TTL         time.Duration
+	NegativeTTL time.Duration
+}
+
+type Refresher struct {
+	Interval    time.Duration
+	Concurrency int
+}
+
+type Server struct {
+	Address       string
+	ShutdownDelay time.Duration
+	DrainTimeout  time.Duration
+}
+
+type Health struct {
+	Timeout    time.Duration
+	CheckSWApi bool
+}
+
+type APIKey struct {
+	Name   string
+	Key    string
+	Scopes []string
+}
+
+type JWT struct {
+	HMACSecret       string
+	RSAPublicKeyFile string
+	Issuer           string
+	Audience         string
+}
+
+type Auth struct {
+	Enabled     bool
+	PublicReads bool
+	APIKeys     []APIKey
+	JWT         JWT
+}
+
+type RateLimitRule struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+type RateLimit struct {
+	Enabled        bool
+	MaxClients     int
+	TrustedProxies []string
+	Read           RateLimitRule
+	Write          RateLimitRule
+	Batch          RateLimitRule
+}
+
+type Cors struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+type Tracing struct {
+	Enabled     bool
+	Exporter    string
+	Endpoint    string
+	Insecure    bool
+	ServiceName string
+	SampleRatio float64
+}
+
+type Log struct {
+	Level string
+}
+
+type Planets struct {
+	InsertPolicy      string
+	ImportConcurrency int
+	BatchConcurrency  int
+}
+
+type Trash struct {
+	Retention     time.Duration
+	PurgeInterval time.Duration
+}
+
 type config struct {
-	Database Database
-	Server   struct {
-		Address string
-	}
-	SWApi struct {
-		BaseUrl string
-	}
+	Database  Database
+	Server    Server
+	SWApi     SWApi
+	Cache     Cache
+	Refresher Refresher
+	Planets   Planets
+	Trash     Trash
+	Health    Health
+	Log       Log
+	Tracing   Tracing
+	Auth      Auth
+	RateLimit RateLimit
+	Cors      Cors
 }
 
 var Data config
 
-func ReadConfig() {
+// ReadConfig lê o arquivo de configuração e as variáveis de ambiente para Data
+func ReadConfig() error {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yml")
 	viper.AddConfigPath(filepath.Join("$GOPATH", "src", "b2w", "swapi-challenge", "config"))
 	viper.AddConfigPath("./config")
+	// As variáveis de ambiente substituem as chaves do arquivo, com "_" no lugar
+	// de ".", como AUTH_JWT_HMACSECRET para auth.jwt.hmacSecret
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv()
 
 	if err := viper.ReadInConfig(); err != nil {
-		fmt.Println(err)
-		log.Fatalln(err)
+		return fmt.Errorf("config: reading file: %w", err)
 	}
 
 	if err := viper.Unmarshal(&Data); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return fmt.Errorf("config: decoding: %w", err)
 	}
+
+	return nil
 }
@@ -0,0 +1,223 @@
+package planet
+
+import (
+	"b2w/swapi-challenge/domain"
+	"b2w/swapi-challenge/infra/logger"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type CatalogOptions struct {
+	Concurrency int
+}
+
+type CatalogImportResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
+
+type CatalogImportState string
+
+const (
+	CatalogImportIdle     CatalogImportState = "idle"
+	CatalogImportRunning  CatalogImportState = "running"
+	CatalogImportFinished CatalogImportState = "finished"
+	CatalogImportFailed   CatalogImportState = "failed"
+)
+
+// CatalogImportStatus descreve a última importação executada. O resultado é
+// preenchido ao final, e a causa das falhas é informada apenas pelo código.
+type CatalogImportStatus struct {
+	State      CatalogImportState   `json:"state"`
+	StartedAt  *time.Time           `json:"started_at,omitempty"`
+	FinishedAt *time.Time           `json:"finished_at,omitempty"`
+	Result     *CatalogImportResult `json:"result,omitempty"`
+	ErrorCode  domain.Code          `json:"error_code,omitempty"`
+}
+
+// catalogImporter percorre a lista de planetas da SWAPI, criando ou
+// atualizando cada planeta pelo Manager. Executar novamente a importação
+// não altera os planetas que já estão atualizados.
+type catalogImporter struct {
+	manager   Manager
+	swapiRepo SwapiRepository
+	options   CatalogOptions
+	log       zerolog.Logger
+
+	running int32
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+
+	mu     sync.Mutex
+	status CatalogImportStatus
+}
+
+func NewCatalogImporter(manager Manager, swapiR SwapiRepository, opts CatalogOptions, log zerolog.Logger) *catalogImporter {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+
+	// O contexto é cancelado pelo Stop, interrompendo a importação em segundo plano
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &catalogImporter{
+		manager:   manager,
+		swapiRepo: swapiR,
+		options:   opts,
+		log:       log.With().Str("component", "catalog_importer").Logger(),
+		ctx:       ctx,
+		cancel:    cancel,
+		status:    CatalogImportStatus{State: CatalogImportIdle},
+	}
+}
+
+// ImportCatalog importa todos os planetas da SWAPI, retornando domain.ErrConflict
+// caso já exista uma importação em andamento. As falhas de cada planeta são
+// contadas no resultado, enquanto as falhas ao buscar a lista interrompem a importação.
+func (i *catalogImporter) ImportCatalog(ctx context.Context) (CatalogImportResult, error) {
+	if !atomic.CompareAndSwapInt32(&i.running, 0, 1) {
+		return CatalogImportResult{}, domain.ErrConflict
+	}
+	defer atomic.StoreInt32(&i.running, 0)
+
+	i.start()
+	return i.run(ctx)
+}
+
+// TriggerImport inicia uma importação em segundo plano, retornando
+// domain.ErrConflict caso já exista uma em andamento. O andamento é
+// consultado pelo Status.
+func (i *catalogImporter) TriggerImport() error {
+	if !atomic.CompareAndSwapInt32(&i.running, 0, 1) {
+		return domain.ErrConflict
+	}
+
+	// O status é marcado antes de retornar, para que uma consulta logo em
+	// seguida já veja a importação em andamento
+	i.start()
+
+	i.wg.Add(1)
+	go func() {
+		defer i.wg.Done()
+		defer atomic.StoreInt32(&i.running, 0)
+
+		_, _ = i.run(i.ctx)
+	}()
+
+	return nil
+}
+
+// Status retorna o estado da importação em andamento ou da última executada
+func (i *catalogImporter) Status() CatalogImportStatus {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.status
+}
+
+// Stop interrompe a importação em segundo plano, aguardando seu término
+func (i *catalogImporter) Stop() {
+	i.cancel()
+	i.wg.Wait()
+}
+
+func (i *catalogImporter) start() {
+	startedAt := time.Now()
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.status = CatalogImportStatus{State: CatalogImportRunning, StartedAt: &startedAt}
+}
+
+func (i *catalogImporter) finish(result CatalogImportResult, err error) {
+	finishedAt := time.Now()
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.status.FinishedAt = &finishedAt
+	i.status.Result = &result
+	i.status.State = CatalogImportFinished
+	if err != nil {
+		i.status.State = CatalogImportFailed
+		i.status.ErrorCode = domain.CodeOf(err)
+	}
+}
+
+// run executa a importação iniciada pelo start, registrando o resultado no status
+func (i *catalogImporter) run(ctx context.Context) (CatalogImportResult, error) {
+	result, err := i.importAll(ctx)
+	i.finish(result, err)
+	if err != nil {
+		logger.Ctx(ctx, i.log).Error().Err(err).Msg("catalog import failed")
+		return result, err
+	}
+
+	logger.Ctx(ctx, i.log).Info().
+		Int("created", result.Created).
+		Int("updated", result.Updated).
+		Int("skipped", result.Skipped).
+		Int("failed", result.Failed).
+		Msg("catalog import finished")
+
+	return result, nil
+}
+
+func (i *catalogImporter) importAll(ctx context.Context) (CatalogImportResult, error) {
+	var result CatalogImportResult
+	var mu sync.Mutex
+
+	for pageNumber := 1; ; pageNumber++ {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		page, err := i.swapiRepo.ListPlanets(ctx, pageNumber)
+		if err != nil {
+			return result, err
+		}
+
+		sem := make(chan struct{}, i.options.Concurrency)
+		var wg sync.WaitGroup
+		for _, swapiP := range page.Planets {
+			sem <- struct{}{}
+			wg.Add(1)
+
+			go func(swapiP SwapiPlanet) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				p := NewPlanetFromSwapi(swapiP)
+				upserted, err := i.manager.Upsert(ctx, &p)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				switch {
+				case err != nil:
+					result.Failed++
+					logger.Ctx(ctx, i.log).Warn().Err(err).Str("name", swapiP.Name).Msg("catalog import of planet failed")
+				case upserted == UpsertCreated:
+					result.Created++
+				case upserted == UpsertUpdated:
+					result.Updated++
+				default:
+					result.Skipped++
+				}
+			}(swapiP)
+		}
+		wg.Wait()
+
+		if !page.HasMore {
+			return result, nil
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package planet_test
+
+import (
+	"b2w/swapi-challenge/domain"
+	"b2w/swapi-challenge/domain/entity/planet"
+	"b2w/swapi-challenge/domain/entity/planet/mocks"
+	"b2w/swapi-challenge/infra/swapi"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// memoryDbRepository guarda os planetas do mock em memória, por nome
+func memoryDbRepository(failing string) *mocks.DbRepository {
+	var mu sync.Mutex
+	planets := make(map[string]planet.Planet)
+
+	dbRepo := &mocks.DbRepository{}
+	dbRepo.
+		On("GetByName", mock.Anything, mock.AnythingOfType("string")).
+		Return(func(ctx context.Context, name string) planet.Planet {
+			mu.Lock()
+			defer mu.Unlock()
+			return planets[strings.ToLower(name)]
+		}, func(ctx context.Context, name string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if _, ok := planets[strings.ToLower(name)]; !ok {
+				return domain.ErrNotFound
+			}
+			return nil
+		})
+
+	dbRepo.
+		On("GetDeletedByName", mock.Anything, mock.AnythingOfType("string")).
+		Return(planet.Planet{}, domain.ErrNotFound)
+
+	save := func(ctx context.Context, p *planet.Planet) error {
+		if p.Name == failing {
+			return errors.New("database error")
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		planets[strings.ToLower(p.Name)] = *p
+		return nil
+	}
+	dbRepo.On("Insert", mock.Anything, mock.AnythingOfType("*planet.Planet")).Return(save)
+	dbRepo.On("Update", mock.Anything, mock.AnythingOfType("*planet.Planet")).Return(save)
+
+	return dbRepo
+}
+
+func TestCatalogImporter(t *testing.T) {
+	var ts *httptest.Server
+	var climate atomic.Value
+	climate.Store("arid")
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/films/1/":
+			fmt.Fprint(w, `{"title":"A New Hope","episode_id":4,"release_date":"1977-05-25"}`)
+		case r.URL.Path != "/planets/":
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Query().Get("page") == "1":
+			fmt.Fprintf(w, `{"count":4,"next":"%[1]s/planets/?page=2","results":[
+				{"name":"Tatooine","climate":"%[2]s","terrain":"desert","population":"200000","diameter":"10465","gravity":"1 standard","films":["%[1]s/films/1/"]},
+				{"name":"Alderaan","climate":"temperate","terrain":"grasslands","population":"2000000000","diameter":"12500","gravity":"1 standard","films":["%[1]s/films/1/"]}
+			]}`, ts.URL, climate.Load())
+		case r.URL.Query().Get("page") == "2":
+			fmt.Fprint(w, `{"count":4,"next":null,"results":[
+				{"name":"Yavin IV","climate":"temperate, tropical","terrain":"jungle","population":"1000","diameter":"10200","gravity":"1 standard","films":[]},
+				{"name":"Failing","climate":"unknown","terrain":"unknown","population":"unknown","diameter":"0","gravity":"N/A","films":[]}
+			]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	swapiRepo := planet.NewSWApiRepository(swapi.NewClient(ts.URL, ts.Client(), swapi.Options{}), zerolog.Nop())
+	dbRepo := memoryDbRepository("Failing")
+	manager := planet.NewManager(dbRepo, swapiRepo, planet.ManagerOptions{}, zerolog.Nop())
+
+	importer := planet.NewCatalogImporter(manager, swapiRepo, planet.CatalogOptions{Concurrency: 2}, zerolog.Nop())
+
+	// Testing first import going through every page
+	result, err := importer.ImportCatalog(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, planet.CatalogImportResult{Created: 3, Failed: 1}, result)
+
+	p, err := manager.GetByName(context.Background(), "Tatooine")
+	assert.Nil(t, err)
+	assert.Equal(t, "arid", p.Climate)
+	assert.Equal(t, int64(200000), *p.Population)
+	assert.Equal(t, int32(1), p.Apparitions)
+
+	// Testing import idempotent on re-run
+	result, err = importer.ImportCatalog(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, planet.CatalogImportResult{Skipped: 3, Failed: 1}, result)
+	// O planeta com falha é tentado novamente
+	dbRepo.AssertNumberOfCalls(t, "Insert", 5)
+	dbRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+
+	// Testing planets changed on swapi being updated
+	climate.Store("arid, hot")
+	result, err = importer.ImportCatalog(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, planet.CatalogImportResult{Updated: 1, Skipped: 2, Failed: 1}, result)
+
+	p, err = manager.GetByName(context.Background(), "Tatooine")
+	assert.Nil(t, err)
+	assert.Equal(t, "arid, hot", p.Climate)
+}
+
+func TestCatalogImporterErrors(t *testing.T) {
+	manager := &mocks.Manager{}
+	swapiRepo := &mocks.SwapiRepository{}
+
+	importer := planet.NewCatalogImporter(manager, swapiRepo, planet.CatalogOptions{}, zerolog.Nop())
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	swapiRepo.
+		On("ListPlanets", mock.Anything, 1).
+		Return(func(ctx context.Context, page int) planet.SwapiPlanetPage {
+			close(started)
+			<-release
+			return planet.SwapiPlanetPage{}
+		}, domain.ErrUnavailable).
+		Once()
+
+	// Testing import already running
+	done := make(chan error)
+	go func() {
+		_, err := importer.ImportCatalog(context.Background())
+		done <- err
+	}()
+
+	<-started
+	_, err := importer.ImportCatalog(context.Background())
+	assert.ErrorIs(t, err, domain.ErrConflict)
+
+	// Testing swapi error interrupting the import
+	close(release)
+	assert.ErrorIs(t, <-done, domain.ErrUnavailable)
+
+	// Testing canceled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = importer.ImportCatalog(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	manager.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+}
+
+func TestCatalogImporterTrigger(t *testing.T) {
+	manager := &mocks.Manager{}
+	swapiRepo := &mocks.SwapiRepository{}
+
+	importer := planet.NewCatalogImporter(manager, swapiRepo, planet.CatalogOptions{}, zerolog.Nop())
+
+	release := make(chan struct{})
+	swapiRepo.
+		On("ListPlanets", mock.Anything, 1).
+		Return(func(ctx context.Context, page int) planet.SwapiPlanetPage {
+			<-release
+			return planet.SwapiPlanetPage{Planets: []planet.SwapiPlanet{{Name: "Tatooine"}}}
+		}, nil).
+		Once()
+
+	swapiRepo.
+		On("ListPlanets", mock.Anything, 1).
+		Return(planet.SwapiPlanetPage{}, domain.ErrUnavailable).
+		Once()
+
+	manager.
+		On("Upsert", mock.Anything, mock.AnythingOfType("*planet.Planet")).
+		Return(planet.UpsertCreated, nil)
+
+	// Testing status before the first import
+	assert.Equal(t, planet.CatalogImportIdle, importer.Status().State)
+
+	// Testing import running in background
+	err := importer.TriggerImport()
+	assert.Nil(t, err)
+
+	status := importer.Status()
+	assert.Equal(t, planet.CatalogImportRunning, status.State)
+	assert.NotNil(t, status.StartedAt)
+	assert.Nil(t, status.Result)
+
+	err = importer.TriggerImport()
+	assert.ErrorIs(t, err, domain.ErrConflict)
+
+	_, err = importer.ImportCatalog(context.Background())
+	assert.ErrorIs(t, err, domain.ErrConflict)
+
+	// Testing summary available after the import finishes
+	close(release)
+	assert.Eventually(t, func() bool {
+		return importer.Status().State != planet.CatalogImportRunning
+	}, time.Second, time.Millisecond)
+
+	status = importer.Status()
+	assert.Equal(t, planet.CatalogImportFinished, status.State)
+	assert.NotNil(t, status.FinishedAt)
+	assert.Equal(t, &planet.CatalogImportResult{Created: 1}, status.Result)
+
+	// Testing failed import reported with the error code
+	_, err = importer.ImportCatalog(context.Background())
+	assert.ErrorIs(t, err, domain.ErrUnavailable)
+
+	status = importer.Status()
+	assert.Equal(t, planet.CatalogImportFailed, status.State)
+	assert.Equal(t, domain.CodeUnavailable, status.ErrorCode)
+
+	importer.Stop()
+}
@@ -0,0 +1,85 @@
+package planet
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SwapiReference identifica um planeta da SWAPI pelo ID ou, quando o ID
+// não é informado, pelo nome
+type SwapiReference struct {
+	ID   int
+	Name string
+}
+
+// UpsertResult indica o que Manager.Upsert fez com o planeta
+type UpsertResult string
+
+const (
+	UpsertCreated UpsertResult = "created"
+	UpsertUpdated UpsertResult = "updated"
+	// UpsertSkipped indica que o planeta já estava atualizado ou está na lixeira
+	UpsertSkipped UpsertResult = "skipped"
+)
+
+// NewPlanetFromSwapi monta um planeta a partir do registro da SWAPI. Os
+// valores desconhecidos pela SWAPI ficam vazios.
+func NewPlanetFromSwapi(swapiP SwapiPlanet) Planet {
+	p := Planet{
+		Name:       swapiP.Name,
+		Climate:    swapiP.Climate,
+		Terrain:    swapiP.Terrain,
+		Population: parseSwapiNumber(swapiP.Population),
+		Diameter:   parseSwapiNumber(swapiP.Diameter),
+		Gravity:    swapiP.Gravity,
+	}
+	if isSwapiUnknown(p.Gravity) {
+		p.Gravity = ""
+	}
+	p.SetFilms(swapiP.Films)
+
+	return p
+}
+
+// sameSwapiData compara os dados que vêm da SWAPI
+func (p Planet) sameSwapiData(other Planet) bool {
+	return p.Name == other.Name &&
+		p.Climate == other.Climate &&
+		p.Terrain == other.Terrain &&
+		int64PtrEqual(p.Population, other.Population) &&
+		int64PtrEqual(p.Diameter, other.Diameter) &&
+		p.Gravity == other.Gravity &&
+		p.Apparitions == other.Apparitions &&
+		FilmsEqual(p.Films, other.Films)
+}
+
+func int64PtrEqual(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+// parseSwapiNumber interpreta números como "200000" ou "1,000,000"
+func parseSwapiNumber(value string) *int64 {
+	if isSwapiUnknown(value) {
+		return nil
+	}
+
+	n, err := strconv.ParseInt(strings.ReplaceAll(strings.TrimSpace(value), ",", ""), 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	return &n
+}
+
+func isSwapiUnknown(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "unknown", "n/a", "none":
+		return true
+	default:
+		return false
+	}
+}
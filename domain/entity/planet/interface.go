@@ -1,19 +1,80 @@
 package planet
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type DbRepository interface {
-	Insert(p *Planet) error
-	FindAll() ([]Planet, error)
-	GetById(id primitive.ObjectID) (Planet, error)
-	GetByName(name string) (Planet, error)
-	Delete(id primitive.ObjectID) error
+	Insert(ctx context.Context, p *Planet) error
+	InsertMany(ctx context.Context, planets []*Planet) []error
+	FindPage(ctx context.Context, req PageRequest) (Page, error)
+	GetById(ctx context.Context, id primitive.ObjectID) (Planet, error)
+	GetByName(ctx context.Context, name string) (Planet, error)
+	GetDeletedByName(ctx context.Context, name string) (Planet, error)
+	Update(ctx context.Context, p *Planet) error
+	UpdateFilms(ctx context.Context, id primitive.ObjectID, films []Film, updatedAt time.Time) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	DeleteMany(ctx context.Context, ids []primitive.ObjectID) []error
+	FindTrash(ctx context.Context, req PageRequest) (Page, error)
+	Restore(ctx context.Context, id primitive.ObjectID) error
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
 }
 
 type SwapiRepository interface {
-	GetPlanetApparitions(name string) (int32, error)
+	GetPlanet(ctx context.Context, name string) (SwapiPlanet, error)
+	GetPlanetByID(ctx context.Context, id int) (SwapiPlanet, error)
+	GetPlanetFilms(ctx context.Context, name string) ([]Film, error)
+	ListPlanets(ctx context.Context, page int) (SwapiPlanetPage, error)
+}
+
+// SwapiPlanet é o registro de um planeta na SWAPI, com os filmes em que aparece.
+// Os dados numéricos são mantidos como na SWAPI, que usa "unknown" quando não os conhece.
+type SwapiPlanet struct {
+	Name       string
+	Climate    string
+	Terrain    string
+	Population string
+	Diameter   string
+	Gravity    string
+	Films      []Film
+}
+
+// SwapiPlanetPage é uma página da lista de planetas da SWAPI
+type SwapiPlanetPage struct {
+	Planets []SwapiPlanet
+	HasMore bool
+}
+
+type SwapiCacheEntry struct {
+	Planet    SwapiPlanet
+	Found     bool
+	ExpiresAt time.Time
+}
+
+type SwapiCacheStore interface {
+	Get(ctx context.Context, key string) (SwapiCacheEntry, error)
+	Set(ctx context.Context, key string, entry SwapiCacheEntry) error
 }
 
 type Manager interface {
 	DbRepository
+	InsertWithPolicy(ctx context.Context, p *Planet, policy InsertPolicy) error
+	InsertManyWithPolicy(ctx context.Context, planets []*Planet, policy InsertPolicy) []error
+	UpdateWithPolicy(ctx context.Context, p *Planet, policy InsertPolicy) error
+	Import(ctx context.Context, ref SwapiReference) (Planet, error)
+	Upsert(ctx context.Context, p *Planet) (UpsertResult, error)
+}
+
+type CatalogImporter interface {
+	ImportCatalog(ctx context.Context) (CatalogImportResult, error)
+	TriggerImport() error
+	Status() CatalogImportStatus
+}
+
+type Refresher interface {
+	Refresh(ctx context.Context) (RefreshResult, error)
+	TriggerRefresh() error
 }
@@ -2,59 +2,471 @@ package planet
 
 import (
 	"b2w/swapi-challenge/domain"
+	"b2w/swapi-challenge/infra/logger"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/rs/zerolog"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+type ManagerOptions struct {
+	// InsertPolicy é usada nas criações que não informam uma política.
+	// Quando não informada, a política é InsertLenient.
+	InsertPolicy InsertPolicy
+	// BatchConcurrency limita as consultas simultâneas à SWAPI nas criações
+	// em lote. Quando não informada, as consultas são feitas uma por vez.
+	BatchConcurrency int
+}
+
 type manager struct {
 	dbRepo    DbRepository
 	swapiRepo SwapiRepository
+	options   ManagerOptions
+	log       zerolog.Logger
 }
 
-func NewManager(dbR DbRepository, swapiR SwapiRepository) *manager {
+func NewManager(dbR DbRepository, swapiR SwapiRepository, opts ManagerOptions, log zerolog.Logger) *manager {
+	if opts.InsertPolicy == "" {
+		opts.InsertPolicy = InsertLenient
+	}
+	if opts.BatchConcurrency <= 0 {
+		opts.BatchConcurrency = 1
+	}
+
 	return &manager{
 		dbRepo:    dbR,
 		swapiRepo: swapiR,
+		options:   opts,
+		log:       log.With().Str("component", "manager").Logger(),
+	}
+}
+
+func (m *manager) Insert(ctx context.Context, p *Planet) error {
+	return m.InsertWithPolicy(ctx, p, "")
+}
+
+// InsertWithPolicy cria o planeta com a política informada, ou com a
+// política padrão do gerenciador quando ela for vazia
+func (m *manager) InsertWithPolicy(ctx context.Context, p *Planet, policy InsertPolicy) error {
+	policy, err := m.insertPolicy(policy)
+	if err != nil {
+		return err
+	}
+
+	if err := m.prepareInsert(ctx, p, policy); err != nil {
+		return err
+	}
+	if err := m.create(ctx, p); err != nil {
+		return err
+	}
+
+	logger.Ctx(ctx, m.log).Info().
+		Str("planet_id", p.ID.Hex()).
+		Str("name", p.Name).
+		Int32("apparitions", p.Apparitions).
+		Str("insert_policy", string(policy)).
+		Msg("planet created")
+
+	return nil
+}
+
+func (m *manager) InsertMany(ctx context.Context, planets []*Planet) []error {
+	return m.InsertManyWithPolicy(ctx, planets, "")
+}
+
+// InsertManyWithPolicy cria os planetas com a política informada, retornando
+// o erro de cada planeta na mesma ordem. As consultas à SWAPI são feitas em
+// paralelo, e os planetas válidos são salvos em uma única operação.
+func (m *manager) InsertManyWithPolicy(ctx context.Context, planets []*Planet, policy InsertPolicy) []error {
+	errs := make([]error, len(planets))
+
+	policy, err := m.insertPolicy(policy)
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	// Nomes repetidos no lote são recusados como os já cadastrados
+	seen := make(map[string]bool, len(planets))
+	for i, p := range planets {
+		p.Normalize()
+		key := strings.ToLower(p.Name)
+		if seen[key] {
+			errs[i] = domain.ErrConflict
+		}
+		seen[key] = true
+	}
+
+	sem := make(chan struct{}, m.options.BatchConcurrency)
+	var wg sync.WaitGroup
+	for i, p := range planets {
+		if errs[i] != nil {
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+
+		go func(i int, p *Planet) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := m.prepareInsert(ctx, p, policy); err != nil {
+				errs[i] = err
+				return
+			}
+			errs[i] = m.checkConflict(ctx, p.Name)
+		}(i, p)
+	}
+	wg.Wait()
+
+	valid := make([]*Planet, 0, len(planets))
+	validIndexes := make([]int, 0, len(planets))
+	for i, p := range planets {
+		if errs[i] != nil {
+			continue
+		}
+
+		p.ID = primitive.NewObjectID()
+		p.ApparitionsUpdatedAt = time.Now()
+		valid = append(valid, p)
+		validIndexes = append(validIndexes, i)
+	}
+
+	if len(valid) == 0 {
+		return errs
 	}
+
+	created := 0
+	for j, err := range m.dbRepo.InsertMany(ctx, valid) {
+		errs[validIndexes[j]] = err
+		if err == nil {
+			created++
+		} else {
+			valid[j].ID = primitive.NilObjectID
+		}
+	}
+
+	logger.Ctx(ctx, m.log).Info().
+		Int("requested", len(planets)).
+		Int("created", created).
+		Str("insert_policy", string(policy)).
+		Msg("planets created")
+
+	return errs
+}
+
+// insertPolicy resolve a política padrão e recusa as políticas desconhecidas
+func (m *manager) insertPolicy(policy InsertPolicy) (InsertPolicy, error) {
+	if policy == "" {
+		policy = m.options.InsertPolicy
+	}
+	if _, err := ParseInsertPolicy(string(policy)); err != nil {
+		return "", domain.NewError(domain.CodeInvalidInput, "Invalid insert policy",
+			domain.FieldError{Field: "insert_policy", Message: err.Error()})
+	}
+
+	return policy, nil
 }
 
-func (m *manager) Insert(p *Planet) error {
+// prepareInsert valida o planeta e preenche as aparições, e os demais campos
+// quando a política pedir, com os dados da SWAPI
+func (m *manager) prepareInsert(ctx context.Context, p *Planet, policy InsertPolicy) error {
+	p.Normalize()
 	if err := p.Validate(); err != nil {
-		return domain.ErrBadParamInput
+		return err
 	}
 
-	apparitions, err := m.swapiRepo.GetPlanetApparitions(p.Name)
+	swapiP, err := m.lookupSwapi(ctx, p, policy)
 	if err != nil {
 		return err
 	}
+	if policy == InsertEnrich {
+		p.Enrich(swapiP)
+	}
+	p.SetFilms(swapiP.Films)
+
+	return nil
+}
+
+// Import cria o planeta a partir do registro da SWAPI, com o clima, o terreno,
+// a população, o diâmetro, a gravidade e as aparições informados por ela
+func (m *manager) Import(ctx context.Context, ref SwapiReference) (Planet, error) {
+	var swapiP SwapiPlanet
+	var err error
+	if ref.ID != 0 {
+		swapiP, err = m.swapiRepo.GetPlanetByID(ctx, ref.ID)
+	} else {
+		swapiP, err = m.swapiRepo.GetPlanet(ctx, ref.Name)
+	}
+	if errors.Is(err, domain.ErrNotFound) {
+		logger.Ctx(ctx, m.log).Debug().Int("swapi_id", ref.ID).Str("name", ref.Name).Msg("planet not found on swapi")
+		return Planet{}, notInSwapi(ref)
+	}
+	if err != nil {
+		logger.Ctx(ctx, m.log).Warn().Err(err).Int("swapi_id", ref.ID).Str("name", ref.Name).Msg("swapi lookup failed")
+		return Planet{}, err
+	}
+
+	p := NewPlanetFromSwapi(swapiP)
+	p.Normalize()
+	if err := p.Validate(); err != nil {
+		return Planet{}, err
+	}
+
+	if err := m.create(ctx, &p); err != nil {
+		return Planet{}, err
+	}
+
+	logger.Ctx(ctx, m.log).Info().
+		Str("planet_id", p.ID.Hex()).
+		Str("name", p.Name).
+		Int32("apparitions", p.Apparitions).
+		Msg("planet imported")
+
+	return p, nil
+}
+
+// Upsert cria o planeta ou atualiza o planeta de mesmo nome com os dados da
+// SWAPI, sem buscá-los novamente. Planetas já atualizados não são alterados,
+// e planetas na lixeira não são recriados.
+func (m *manager) Upsert(ctx context.Context, p *Planet) (UpsertResult, error) {
+	p.Normalize()
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
 
-	existingP, _ := m.GetByName(p.Name)
+	currentP, err := m.GetByName(ctx, p.Name)
+	if errors.Is(err, domain.ErrNotFound) {
+		// O índice único não inclui a lixeira, então o nome dos planetas
+		// removidos é checado aqui
+		_, err = m.dbRepo.GetDeletedByName(ctx, p.Name)
+		if err == nil {
+			logger.Ctx(ctx, m.log).Debug().Str("name", p.Name).Msg("planet upsert skipped: name used by a deleted planet")
+			return UpsertSkipped, nil
+		}
+		if !errors.Is(err, domain.ErrNotFound) {
+			return "", err
+		}
+
+		err = m.create(ctx, p)
+		if errors.Is(err, domain.ErrConflict) {
+			logger.Ctx(ctx, m.log).Debug().Str("name", p.Name).Msg("planet upsert skipped: name already used")
+			return UpsertSkipped, nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		logger.Ctx(ctx, m.log).Info().Str("planet_id", p.ID.Hex()).Str("name", p.Name).Msg("planet created")
+		return UpsertCreated, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	p.ID = currentP.ID
+	if currentP.sameSwapiData(*p) {
+		*p = currentP
+		return UpsertSkipped, nil
+	}
+
+	p.ApparitionsUpdatedAt = time.Now()
+	if err := m.update(ctx, p); err != nil {
+		return "", err
+	}
+
+	return UpsertUpdated, nil
+}
+
+// create salva o novo planeta, recusando nomes já cadastrados. Os filmes já
+// devem estar preenchidos.
+func (m *manager) create(ctx context.Context, p *Planet) error {
+	if err := m.checkConflict(ctx, p.Name); err != nil {
+		return err
+	}
+
+	p.ID = primitive.NewObjectID()
+	p.ApparitionsUpdatedAt = time.Now()
+
+	return m.dbRepo.Insert(ctx, p)
+}
+
+// checkConflict recusa nomes já usados por outro planeta. A unicidade também é
+// garantida pelo índice do banco de dados, em caso de inserções concorrentes.
+func (m *manager) checkConflict(ctx context.Context, name string) error {
+	existingP, _ := m.GetByName(ctx, name)
 	if existingP.ID != primitive.NilObjectID {
 		return domain.ErrConflict
 	}
 
-	p.ID = primitive.NewObjectID()
-	p.Apparitions = apparitions
+	return nil
+}
+
+// lookupSwapi busca o planeta na SWAPI de acordo com a política de criação.
+// O registro completo só é buscado quando há campos a preencher.
+func (m *manager) lookupSwapi(ctx context.Context, p *Planet, policy InsertPolicy) (SwapiPlanet, error) {
+	var swapiP SwapiPlanet
+	var err error
+	if policy == InsertEnrich && (p.Climate == "" || p.Terrain == "") {
+		swapiP, err = m.swapiRepo.GetPlanet(ctx, p.Name)
+	} else {
+		swapiP.Films, err = m.swapiRepo.GetPlanetFilms(ctx, p.Name)
+	}
+
+	if errors.Is(err, domain.ErrNotFound) {
+		logger.Ctx(ctx, m.log).Debug().Str("name", p.Name).Str("insert_policy", string(policy)).Msg("planet not found on swapi")
+		if policy == InsertStrict {
+			return SwapiPlanet{}, ErrNotInSwapi
+		}
+		return SwapiPlanet{}, nil
+	}
+	if err != nil {
+		logger.Ctx(ctx, m.log).Warn().Err(err).Str("name", p.Name).Msg("swapi lookup failed")
+	}
+
+	return swapiP, err
+}
+
+func (m *manager) FindPage(ctx context.Context, req PageRequest) (Page, error) {
+	if err := req.Validate(); err != nil {
+		return Page{}, err
+	}
+
+	return m.dbRepo.FindPage(ctx, req)
+}
 
-	return m.dbRepo.Insert(p)
+func (m *manager) GetById(ctx context.Context, id primitive.ObjectID) (Planet, error) {
+	return m.dbRepo.GetById(ctx, id)
 }
 
-func (m *manager) FindAll() ([]Planet, error) {
-	return m.dbRepo.FindAll()
+func (m *manager) GetByName(ctx context.Context, name string) (Planet, error) {
+	return m.dbRepo.GetByName(ctx, NormalizeName(name))
 }
 
-func (m *manager) GetById(id primitive.ObjectID) (Planet, error) {
-	return m.dbRepo.GetById(id)
+func (m *manager) GetDeletedByName(ctx context.Context, name string) (Planet, error) {
+	return m.dbRepo.GetDeletedByName(ctx, NormalizeName(name))
 }
 
-func (m *manager) GetByName(name string) (Planet, error) {
-	return m.dbRepo.GetByName(name)
+func (m *manager) Update(ctx context.Context, p *Planet) error {
+	return m.UpdateWithPolicy(ctx, p, "")
 }
 
-func (m *manager) Delete(id primitive.ObjectID) error {
-	_, err := m.dbRepo.GetById(id)
+// UpdateWithPolicy atualiza o planeta. Quando o nome muda, as aparições são
+// buscadas novamente com a política de criação informada, ou com a política
+// padrão do gerenciador quando ela for vazia.
+func (m *manager) UpdateWithPolicy(ctx context.Context, p *Planet, policy InsertPolicy) error {
+	policy, err := m.insertPolicy(policy)
 	if err != nil {
 		return err
 	}
-	return m.dbRepo.Delete(id)
+
+	p.Normalize()
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	currentP, err := m.dbRepo.GetById(ctx, p.ID)
+	if err != nil {
+		return err
+	}
+
+	// Os dados importados da SWAPI não são alterados pela API
+	p.Population = currentP.Population
+	p.Diameter = currentP.Diameter
+	p.Gravity = currentP.Gravity
+
+	if p.Name == currentP.Name {
+		p.SetFilms(currentP.Films)
+		p.Apparitions = currentP.Apparitions
+		p.ApparitionsUpdatedAt = currentP.ApparitionsUpdatedAt
+		return m.update(ctx, p)
+	}
+
+	// O nome mudou: checar conflito e buscar novamente as aparições, como na
+	// criação de um planeta
+	existingP, _ := m.GetByName(ctx, p.Name)
+	if existingP.ID != primitive.NilObjectID && existingP.ID != p.ID {
+		return domain.ErrConflict
+	}
+
+	swapiP, err := m.lookupSwapi(ctx, p, policy)
+	if err != nil {
+		return err
+	}
+	if policy == InsertEnrich {
+		p.Enrich(swapiP)
+	}
+	p.SetFilms(swapiP.Films)
+	p.ApparitionsUpdatedAt = time.Now()
+
+	return m.update(ctx, p)
+}
+
+func (m *manager) update(ctx context.Context, p *Planet) error {
+	if err := m.dbRepo.Update(ctx, p); err != nil {
+		return err
+	}
+
+	logger.Ctx(ctx, m.log).Info().Str("planet_id", p.ID.Hex()).Str("name", p.Name).Msg("planet updated")
+	return nil
+}
+
+func (m *manager) UpdateFilms(ctx context.Context, id primitive.ObjectID, films []Film, updatedAt time.Time) error {
+	return m.dbRepo.UpdateFilms(ctx, id, films, updatedAt)
+}
+
+func (m *manager) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := m.dbRepo.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := m.dbRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	logger.Ctx(ctx, m.log).Info().Str("planet_id", id.Hex()).Msg("planet moved to trash")
+	return nil
+}
+
+// DeleteMany move os planetas para a lixeira, retornando o erro de cada ID na mesma ordem
+func (m *manager) DeleteMany(ctx context.Context, ids []primitive.ObjectID) []error {
+	errs := m.dbRepo.DeleteMany(ctx, ids)
+
+	deleted := 0
+	for _, err := range errs {
+		if err == nil {
+			deleted++
+		}
+	}
+	logger.Ctx(ctx, m.log).Info().Int("requested", len(ids)).Int("deleted", deleted).Msg("planets moved to trash")
+
+	return errs
+}
+
+func (m *manager) FindTrash(ctx context.Context, req PageRequest) (Page, error) {
+	if err := req.Validate(); err != nil {
+		return Page{}, err
+	}
+
+	return m.dbRepo.FindTrash(ctx, req)
+}
+
+func (m *manager) Restore(ctx context.Context, id primitive.ObjectID) error {
+	if err := m.dbRepo.Restore(ctx, id); err != nil {
+		return err
+	}
+
+	logger.Ctx(ctx, m.log).Info().Str("planet_id", id.Hex()).Msg("planet restored")
+	return nil
+}
+
+func (m *manager) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return m.dbRepo.Purge(ctx, deletedBefore)
 }
@@ -4,128 +4,130 @@ import (
 	"b2w/swapi-challenge/domain"
 	"b2w/swapi-challenge/domain/entity/planet"
 	"b2w/swapi-challenge/domain/entity/planet/mocks"
+	"b2w/swapi-challenge/infra/logger"
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
 
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+func testFilms(count int) []planet.Film {
+	var films []planet.Film
+	for i := 1; i <= count; i++ {
+		films = append(films, planet.Film{Title: fmt.Sprintf("Film %d", i), EpisodeID: i})
+	}
+	return films
+}
+
 func TestManagerInsert(t *testing.T) {
 	dbRepo := &mocks.DbRepository{}
 	swapiRepo := &mocks.SwapiRepository{}
 
-	manager := planet.NewManager(dbRepo, swapiRepo)
+	manager := planet.NewManager(dbRepo, swapiRepo, planet.ManagerOptions{}, zerolog.Nop())
 
 	pSuccess := &planet.Planet{Name: "Success"}
 	pInvalid := &planet.Planet{}
 	pSwapiError := &planet.Planet{Name: "Swapi Error"}
 	pGetFound := &planet.Planet{Name: "Get Found"}
 	pInsertError := &planet.Planet{Name: "Insert Error"}
+	pSwapiNotFound := &planet.Planet{Name: "Swapi Not Found"}
 
 	swapiRepo.
-		On("GetPlanetApparitions", pSuccess.Name).
-		Return(int32(1), nil)
+		On("GetPlanetFilms", mock.Anything, pSuccess.Name).
+		Return(testFilms(1), nil)
 
 	swapiRepo.
-		On("GetPlanetApparitions", pGetFound.Name).
-		Return(int32(1), nil)
+		On("GetPlanetFilms", mock.Anything, pGetFound.Name).
+		Return(testFilms(1), nil)
 
 	swapiRepo.
-		On("GetPlanetApparitions", pInsertError.Name).
-		Return(int32(1), nil)
+		On("GetPlanetFilms", mock.Anything, pInsertError.Name).
+		Return(testFilms(1), nil)
 
 	swapiRepo.
-		On("GetPlanetApparitions", pSwapiError.Name).
-		Return(int32(0), errors.New("swapi error"))
+		On("GetPlanetFilms", mock.Anything, pSwapiError.Name).
+		Return(testFilms(0), errors.New("swapi error"))
+
+	swapiRepo.
+		On("GetPlanetFilms", mock.Anything, pSwapiNotFound.Name).
+		Return(testFilms(0), domain.ErrNotFound)
+
+	dbRepo.
+		On("GetByName", mock.Anything, pSuccess.Name).
+		Return(planet.Planet{}, domain.ErrNotFound)
 
 	dbRepo.
-		On("GetByName", pSuccess.Name).
+		On("GetByName", mock.Anything, pSwapiNotFound.Name).
 		Return(planet.Planet{}, domain.ErrNotFound)
 
 	dbRepo.
-		On("GetByName", pInsertError.Name).
+		On("Insert", mock.Anything, pSwapiNotFound).
+		Return(nil)
+
+	dbRepo.
+		On("GetByName", mock.Anything, pInsertError.Name).
 		Return(planet.Planet{}, domain.ErrNotFound)
 
 	dbRepo.
-		On("GetByName", pGetFound.Name).
+		On("GetByName", mock.Anything, pGetFound.Name).
 		Return(planet.Planet{ID: primitive.NewObjectID()}, nil)
 
 	dbRepo.
-		On("Insert", pSuccess).
+		On("Insert", mock.Anything, pSuccess).
 		Return(nil)
 
 	dbRepo.
-		On("Insert", pInsertError).
+		On("Insert", mock.Anything, pInsertError).
 		Return(errors.New("insert error"))
 
 	// Testing insertion success
-	err := manager.Insert(pSuccess)
+	err := manager.Insert(context.Background(), pSuccess)
 	assert.Nil(t, err)
 	assert.NotEqual(t, primitive.NilObjectID, pSuccess.ID)
 	assert.Equal(t, int32(1), pSuccess.Apparitions)
+	assert.Equal(t, testFilms(1), pSuccess.Films)
+	assert.False(t, pSuccess.ApparitionsUpdatedAt.IsZero())
 
 	// Testing invalid planet
-	err = manager.Insert(pInvalid)
+	err = manager.Insert(context.Background(), pInvalid)
 	assert.NotNil(t, err)
-	assert.Equal(t, domain.ErrBadParamInput, err)
+	assert.ErrorIs(t, err, domain.ErrBadParamInput)
 	assert.Equal(t, primitive.NilObjectID, pInvalid.ID)
 
 	// Testing swapi error
-	err = manager.Insert(pSwapiError)
+	err = manager.Insert(context.Background(), pSwapiError)
 	assert.NotNil(t, err)
 	assert.Equal(t, "swapi error", err.Error())
 	assert.Equal(t, primitive.NilObjectID, pSwapiError.ID)
 
+	// Testing planet not found on swapi
+	err = manager.Insert(context.Background(), pSwapiNotFound)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(0), pSwapiNotFound.Apparitions)
+
 	// Testing planet found
-	err = manager.Insert(pGetFound)
+	err = manager.Insert(context.Background(), pGetFound)
 	assert.NotNil(t, err)
 	assert.Equal(t, domain.ErrConflict, err)
 	assert.Equal(t, primitive.NilObjectID, pGetFound.ID)
 
 	// Testing insertion error
-	err = manager.Insert(pInsertError)
+	err = manager.Insert(context.Background(), pInsertError)
 	assert.NotNil(t, err)
 	assert.Equal(t, "insert error", err.Error())
 }
 
-func TestManagerFindAll(t *testing.T) {
-	dbRepo := &mocks.DbRepository{}
-	dbRepoErr := &mocks.DbRepository{}
-
-	manager := planet.NewManager(dbRepo, nil)
-	managerErr := planet.NewManager(dbRepoErr, nil)
-
-	pOne := planet.Planet{Name: "One"}
-	pTwo := planet.Planet{Name: "Two"}
-	pThree := planet.Planet{Name: "Two"}
-
-	pList := []planet.Planet{pOne, pTwo, pThree}
-
-	dbRepo.
-		On("FindAll").
-		Return(pList, nil)
-
-	dbRepoErr.
-		On("FindAll").
-		Return(nil, errors.New("find all error"))
-
-	// Testing find all success
-	result, err := manager.FindAll()
-	assert.Nil(t, err)
-	assert.NotNil(t, result)
-	assert.Equal(t, 3, len(result))
-
-	// Testing find all error
-	result, err = managerErr.FindAll()
-	assert.NotNil(t, err)
-	assert.Equal(t, "find all error", err.Error())
-}
-
 func TestManagerGetById(t *testing.T) {
 	dbRepo := &mocks.DbRepository{}
 
-	manager := planet.NewManager(dbRepo, nil)
+	manager := planet.NewManager(dbRepo, nil, planet.ManagerOptions{}, zerolog.Nop())
 
 	pID := primitive.NewObjectID()
 	pIDErr := primitive.NewObjectID()
@@ -134,20 +136,20 @@ func TestManagerGetById(t *testing.T) {
 	pErr := planet.Planet{}
 
 	dbRepo.
-		On("GetById", pID).
+		On("GetById", mock.Anything, pID).
 		Return(p, nil)
 
 	dbRepo.
-		On("GetById", pIDErr).
+		On("GetById", mock.Anything, pIDErr).
 		Return(pErr, errors.New("get error"))
 
 	// Testing get success
-	result, err := manager.GetById(pID)
+	result, err := manager.GetById(context.Background(), pID)
 	assert.Nil(t, err)
 	assert.Equal(t, pID, result.ID)
 
 	// Testing get error
-	result, err = manager.GetById(pIDErr)
+	result, err = manager.GetById(context.Background(), pIDErr)
 	assert.NotNil(t, err)
 	assert.Equal(t, primitive.NilObjectID, result.ID)
 	assert.Equal(t, "get error", err.Error())
@@ -156,43 +158,703 @@ func TestManagerGetById(t *testing.T) {
 func TestManagerDelete(t *testing.T) {
 	dbRepo := &mocks.DbRepository{}
 
-	manager := planet.NewManager(dbRepo, nil)
+	manager := planet.NewManager(dbRepo, nil, planet.ManagerOptions{}, zerolog.Nop())
 
 	pID := primitive.NewObjectID()
 	pIDNotFound := primitive.NewObjectID()
 	pIDErr := primitive.NewObjectID()
 
 	dbRepo.
-		On("GetById", pID).
+		On("GetById", mock.Anything, pID).
 		Return(planet.Planet{}, nil)
 
 	dbRepo.
-		On("GetById", pIDErr).
+		On("GetById", mock.Anything, pIDErr).
 		Return(planet.Planet{}, nil)
 
 	dbRepo.
-		On("GetById", pIDNotFound).
+		On("GetById", mock.Anything, pIDNotFound).
 		Return(planet.Planet{}, domain.ErrNotFound)
 
 	dbRepo.
-		On("Delete", pID).
+		On("Delete", mock.Anything, pID).
 		Return(nil)
 
 	dbRepo.
-		On("Delete", pIDErr).
+		On("Delete", mock.Anything, pIDErr).
 		Return(errors.New("delete error"))
 
 	// Testing delete success
-	err := manager.Delete(pID)
+	err := manager.Delete(context.Background(), pID)
 	assert.Nil(t, err)
 
 	// Testing planet not found
-	err = manager.Delete(pIDNotFound)
+	err = manager.Delete(context.Background(), pIDNotFound)
 	assert.NotNil(t, err)
 	assert.Equal(t, domain.ErrNotFound, err)
 
 	// Testing delete error
-	err = manager.Delete(pIDErr)
+	err = manager.Delete(context.Background(), pIDErr)
 	assert.NotNil(t, err)
 	assert.Equal(t, "delete error", err.Error())
 }
+
+func TestManagerRestore(t *testing.T) {
+	dbRepo := &mocks.DbRepository{}
+
+	manager := planet.NewManager(dbRepo, nil, planet.ManagerOptions{}, zerolog.Nop())
+
+	pID := primitive.NewObjectID()
+	pIDNotFound := primitive.NewObjectID()
+
+	dbRepo.
+		On("Restore", mock.Anything, pID).
+		Return(nil)
+
+	dbRepo.
+		On("Restore", mock.Anything, pIDNotFound).
+		Return(domain.ErrNotFound)
+
+	// Testing restore success
+	err := manager.Restore(context.Background(), pID)
+	assert.Nil(t, err)
+
+	// Testing planet not in the trash
+	err = manager.Restore(context.Background(), pIDNotFound)
+	assert.Equal(t, domain.ErrNotFound, err)
+}
+
+func TestManagerFindTrash(t *testing.T) {
+	dbRepo := &mocks.DbRepository{}
+
+	manager := planet.NewManager(dbRepo, nil, planet.ManagerOptions{}, zerolog.Nop())
+
+	req := planet.PageRequest{Limit: 10}
+
+	dbRepo.
+		On("FindTrash", mock.Anything, req).
+		Return(planet.Page{Planets: []planet.Planet{{Name: "Deleted"}}}, nil)
+
+	// Testing listing success
+	page, err := manager.FindTrash(context.Background(), req)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(page.Planets))
+
+	// Testing invalid limit
+	_, err = manager.FindTrash(context.Background(), planet.PageRequest{Limit: planet.MaxPageLimit + 1})
+	assert.ErrorIs(t, err, domain.ErrBadParamInput)
+}
+
+func TestManagerUpdate(t *testing.T) {
+	dbRepo := &mocks.DbRepository{}
+	swapiRepo := &mocks.SwapiRepository{}
+
+	manager := planet.NewManager(dbRepo, swapiRepo, planet.ManagerOptions{}, zerolog.Nop())
+
+	pIDSameName := primitive.NewObjectID()
+	pIDNewName := primitive.NewObjectID()
+	pIDConflict := primitive.NewObjectID()
+	pIDNotFound := primitive.NewObjectID()
+	pIDSwapiError := primitive.NewObjectID()
+
+	pSameName := &planet.Planet{ID: pIDSameName, Name: "Same Name", Climate: "arid"}
+	pNewName := &planet.Planet{ID: pIDNewName, Name: "New Name"}
+	pConflict := &planet.Planet{ID: pIDConflict, Name: "Conflict"}
+	pNotFound := &planet.Planet{ID: pIDNotFound, Name: "Not Found"}
+	pSwapiError := &planet.Planet{ID: pIDSwapiError, Name: "Swapi Error"}
+	pInvalid := &planet.Planet{ID: pIDSameName}
+
+	dbRepo.
+		On("GetById", mock.Anything, pIDSameName).
+		Return(planet.Planet{ID: pIDSameName, Name: "Same Name", Apparitions: 3, Films: testFilms(3)}, nil)
+
+	dbRepo.
+		On("GetById", mock.Anything, pIDNewName).
+		Return(planet.Planet{ID: pIDNewName, Name: "Old Name", Apparitions: 3, Films: testFilms(3)}, nil)
+
+	dbRepo.
+		On("GetById", mock.Anything, pIDConflict).
+		Return(planet.Planet{ID: pIDConflict, Name: "Old Name"}, nil)
+
+	dbRepo.
+		On("GetById", mock.Anything, pIDSwapiError).
+		Return(planet.Planet{ID: pIDSwapiError, Name: "Old Name"}, nil)
+
+	dbRepo.
+		On("GetById", mock.Anything, pIDNotFound).
+		Return(planet.Planet{}, domain.ErrNotFound)
+
+	dbRepo.
+		On("GetByName", mock.Anything, pNewName.Name).
+		Return(planet.Planet{}, domain.ErrNotFound)
+
+	dbRepo.
+		On("GetByName", mock.Anything, pSwapiError.Name).
+		Return(planet.Planet{}, domain.ErrNotFound)
+
+	dbRepo.
+		On("GetByName", mock.Anything, pConflict.Name).
+		Return(planet.Planet{ID: primitive.NewObjectID()}, nil)
+
+	swapiRepo.
+		On("GetPlanetFilms", mock.Anything, pNewName.Name).
+		Return(testFilms(1), nil)
+
+	swapiRepo.
+		On("GetPlanetFilms", mock.Anything, pSwapiError.Name).
+		Return(testFilms(0), errors.New("swapi error"))
+
+	dbRepo.
+		On("Update", mock.Anything, pSameName).
+		Return(nil)
+
+	dbRepo.
+		On("Update", mock.Anything, pNewName).
+		Return(nil)
+
+	// Testing update keeping the name
+	err := manager.Update(context.Background(), pSameName)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), pSameName.Apparitions)
+	assert.Equal(t, 3, len(pSameName.Films))
+	swapiRepo.AssertNotCalled(t, "GetPlanetFilms", mock.Anything, pSameName.Name)
+
+	// Testing update changing the name
+	err = manager.Update(context.Background(), pNewName)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), pNewName.Apparitions)
+
+	// Testing invalid planet
+	err = manager.Update(context.Background(), pInvalid)
+	assert.NotNil(t, err)
+	assert.ErrorIs(t, err, domain.ErrBadParamInput)
+
+	// Testing planet not found
+	err = manager.Update(context.Background(), pNotFound)
+	assert.NotNil(t, err)
+	assert.Equal(t, domain.ErrNotFound, err)
+
+	// Testing name conflict
+	err = manager.Update(context.Background(), pConflict)
+	assert.NotNil(t, err)
+	assert.Equal(t, domain.ErrConflict, err)
+
+	// Testing swapi error
+	err = manager.Update(context.Background(), pSwapiError)
+	assert.NotNil(t, err)
+	assert.Equal(t, "swapi error", err.Error())
+}
+
+func TestManagerUpdateWithPolicy(t *testing.T) {
+	dbRepo := &mocks.DbRepository{}
+	swapiRepo := &mocks.SwapiRepository{}
+
+	manager := planet.NewManager(dbRepo, swapiRepo, planet.ManagerOptions{}, zerolog.Nop())
+	strictManager := planet.NewManager(dbRepo, swapiRepo, planet.ManagerOptions{InsertPolicy: planet.InsertStrict}, zerolog.Nop())
+
+	pID := primitive.NewObjectID()
+
+	dbRepo.
+		On("GetById", mock.Anything, pID).
+		Return(planet.Planet{ID: pID, Name: "Alderaan", Apparitions: 2, Films: testFilms(2)}, nil)
+
+	dbRepo.
+		On("GetByName", mock.Anything, mock.Anything).
+		Return(planet.Planet{}, domain.ErrNotFound)
+
+	dbRepo.
+		On("Update", mock.Anything, mock.AnythingOfType("*planet.Planet")).
+		Return(nil)
+
+	swapiRepo.
+		On("GetPlanetFilms", mock.Anything, "Unknown").
+		Return(nil, domain.ErrNotFound)
+
+	swapiRepo.
+		On("GetPlanetFilms", mock.Anything, "Tatooine").
+		Return(testFilms(5), nil)
+
+	swapiRepo.
+		On("GetPlanet", mock.Anything, "Tatooine").
+		Return(planet.SwapiPlanet{Name: "Tatooine", Climate: "arid", Terrain: "desert", Films: testFilms(5)}, nil)
+
+	// Testing strict policy rejecting a rename to a planet missing from swapi
+	err := manager.UpdateWithPolicy(context.Background(), &planet.Planet{ID: pID, Name: "Unknown"}, planet.InsertStrict)
+	assert.ErrorIs(t, err, planet.ErrNotInSwapi)
+	dbRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+
+	// Testing strict policy as the manager default
+	err = strictManager.Update(context.Background(), &planet.Planet{ID: pID, Name: "Unknown"})
+	assert.ErrorIs(t, err, planet.ErrNotInSwapi)
+
+	p := &planet.Planet{ID: pID, Name: "Tatooine"}
+	err = manager.UpdateWithPolicy(context.Background(), p, planet.InsertStrict)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(5), p.Apparitions)
+	assert.Equal(t, "", p.Climate)
+
+	// Testing lenient policy overriding the manager default
+	p = &planet.Planet{ID: pID, Name: "Unknown"}
+	err = strictManager.UpdateWithPolicy(context.Background(), p, planet.InsertLenient)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(0), p.Apparitions)
+	assert.Empty(t, p.Films)
+
+	p = &planet.Planet{ID: pID, Name: "Tatooine"}
+	err = manager.UpdateWithPolicy(context.Background(), p, planet.InsertLenient)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(5), p.Apparitions)
+	assert.Equal(t, "", p.Terrain)
+	swapiRepo.AssertNotCalled(t, "GetPlanet", mock.Anything, mock.Anything)
+
+	// Testing enrich policy filling only the missing fields
+	p = &planet.Planet{ID: pID, Name: "Tatooine", Climate: "temperate"}
+	err = manager.UpdateWithPolicy(context.Background(), p, planet.InsertEnrich)
+	assert.Nil(t, err)
+	assert.Equal(t, "temperate", p.Climate)
+	assert.Equal(t, "desert", p.Terrain)
+	assert.Equal(t, testFilms(5), p.Films)
+	swapiRepo.AssertNumberOfCalls(t, "GetPlanet", 1)
+
+	// Testing unknown policy
+	err = manager.UpdateWithPolicy(context.Background(), &planet.Planet{ID: pID, Name: "Tatooine"}, planet.InsertPolicy("loose"))
+	assert.ErrorIs(t, err, domain.ErrBadParamInput)
+
+	dbRepo.AssertNumberOfCalls(t, "Update", 4)
+}
+
+func TestManagerFindPage(t *testing.T) {
+	dbRepo := &mocks.DbRepository{}
+
+	manager := planet.NewManager(dbRepo, nil, planet.ManagerOptions{}, zerolog.Nop())
+
+	pOne := planet.Planet{ID: primitive.NewObjectID(), Name: "One"}
+	pTwo := planet.Planet{ID: primitive.NewObjectID(), Name: "Two"}
+
+	req := planet.PageRequest{Limit: 2}
+	reqErr := planet.PageRequest{After: planet.Cursor{ID: pTwo.ID}, Limit: 2}
+
+	dbRepo.
+		On("FindPage", mock.Anything, req).
+		Return(planet.Page{Planets: []planet.Planet{pOne, pTwo}, NextCursor: planet.Cursor{ID: pTwo.ID}, HasMore: true}, nil)
+
+	dbRepo.
+		On("FindPage", mock.Anything, reqErr).
+		Return(planet.Page{}, errors.New("find page error"))
+
+	// Testing find page success
+	result, err := manager.FindPage(context.Background(), req)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(result.Planets))
+	assert.Equal(t, pTwo.ID, result.NextCursor.ID)
+	assert.True(t, result.HasMore)
+
+	// Testing invalid limits
+	_, err = manager.FindPage(context.Background(), planet.PageRequest{})
+	assert.ErrorIs(t, err, domain.ErrBadParamInput)
+
+	_, err = manager.FindPage(context.Background(), planet.PageRequest{Limit: planet.MaxPageLimit + 1})
+	assert.ErrorIs(t, err, domain.ErrBadParamInput)
+
+	// Testing invalid query
+	negative := int32(-1)
+	_, err = manager.FindPage(context.Background(), planet.PageRequest{Query: planet.Query{ApparitionsGte: &negative}, Limit: 2})
+	assert.ErrorIs(t, err, domain.ErrBadParamInput)
+
+	// Testing find page error
+	_, err = manager.FindPage(context.Background(), reqErr)
+	assert.NotNil(t, err)
+	assert.Equal(t, "find page error", err.Error())
+}
+
+func TestManagerInsertNormalizesName(t *testing.T) {
+	dbRepo := &mocks.DbRepository{}
+	swapiRepo := &mocks.SwapiRepository{}
+
+	manager := planet.NewManager(dbRepo, swapiRepo, planet.ManagerOptions{}, zerolog.Nop())
+
+	p := &planet.Planet{Name: "  Yavin   IV ", Climate: " temperate "}
+
+	swapiRepo.
+		On("GetPlanetFilms", mock.Anything, "Yavin IV").
+		Return(testFilms(1), nil)
+
+	dbRepo.
+		On("GetByName", mock.Anything, "Yavin IV").
+		Return(planet.Planet{}, domain.ErrNotFound)
+
+	dbRepo.
+		On("Insert", mock.Anything, p).
+		Return(nil)
+
+	err := manager.Insert(context.Background(), p)
+	assert.Nil(t, err)
+	assert.Equal(t, "Yavin IV", p.Name)
+	assert.Equal(t, "temperate", p.Climate)
+
+	// Testing blank name
+	err = manager.Insert(context.Background(), &planet.Planet{Name: "   "})
+	assert.ErrorIs(t, err, domain.ErrBadParamInput)
+}
+
+func TestManagerInsertCanceled(t *testing.T) {
+	dbRepo := &mocks.DbRepository{}
+	swapiRepo := &mocks.SwapiRepository{}
+
+	manager := planet.NewManager(dbRepo, swapiRepo, planet.ManagerOptions{}, zerolog.Nop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	swapiRepo.
+		On("GetPlanetFilms", ctx, "Tatooine").
+		Return(nil, context.Canceled)
+
+	// Testing the caller context reaches the repositories and stops the insert
+	err := manager.Insert(ctx, &planet.Planet{Name: "Tatooine"})
+	assert.Equal(t, context.Canceled, err)
+
+	dbRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+}
+
+func TestManagerInsertLogsRequestID(t *testing.T) {
+	dbRepo := &mocks.DbRepository{}
+	swapiRepo := &mocks.SwapiRepository{}
+
+	var out bytes.Buffer
+	manager := planet.NewManager(dbRepo, swapiRepo, planet.ManagerOptions{}, logger.New(&out, "info"))
+
+	swapiRepo.
+		On("GetPlanetFilms", mock.Anything, "Tatooine").
+		Return(testFilms(5), nil)
+
+	dbRepo.
+		On("GetByName", mock.Anything, "Tatooine").
+		Return(planet.Planet{}, domain.ErrNotFound)
+
+	dbRepo.
+		On("Insert", mock.Anything, mock.AnythingOfType("*planet.Planet")).
+		Return(nil)
+
+	// Testing the log line carries the request id from the context
+	ctx := logger.WithRequestID(context.Background(), "req-1")
+	err := manager.Insert(ctx, &planet.Planet{Name: "Tatooine"})
+	assert.Nil(t, err)
+
+	var line map[string]interface{}
+	err = json.Unmarshal(out.Bytes(), &line)
+	assert.Nil(t, err)
+	assert.Equal(t, "planet created", line["message"])
+	assert.Equal(t, "req-1", line["request_id"])
+	assert.Equal(t, "manager", line["component"])
+	assert.Equal(t, "Tatooine", line["name"])
+}
+
+func TestManagerInsertWithPolicy(t *testing.T) {
+	dbRepo := &mocks.DbRepository{}
+	swapiRepo := &mocks.SwapiRepository{}
+
+	manager := planet.NewManager(dbRepo, swapiRepo, planet.ManagerOptions{}, zerolog.Nop())
+	strictManager := planet.NewManager(dbRepo, swapiRepo, planet.ManagerOptions{InsertPolicy: planet.InsertStrict}, zerolog.Nop())
+
+	swapiRepo.
+		On("GetPlanetFilms", mock.Anything, "Unknown").
+		Return(nil, domain.ErrNotFound)
+
+	swapiRepo.
+		On("GetPlanetFilms", mock.Anything, "Tatooine").
+		Return(testFilms(5), nil)
+
+	swapiRepo.
+		On("GetPlanet", mock.Anything, "Tatooine").
+		Return(planet.SwapiPlanet{Name: "Tatooine", Climate: "arid", Terrain: "desert", Films: testFilms(5)}, nil)
+
+	swapiRepo.
+		On("GetPlanet", mock.Anything, "Unknown").
+		Return(planet.SwapiPlanet{}, domain.ErrNotFound)
+
+	dbRepo.
+		On("GetByName", mock.Anything, mock.Anything).
+		Return(planet.Planet{}, domain.ErrNotFound)
+
+	dbRepo.
+		On("Insert", mock.Anything, mock.AnythingOfType("*planet.Planet")).
+		Return(nil)
+
+	// Testing strict policy rejecting planets not found on swapi
+	err := manager.InsertWithPolicy(context.Background(), &planet.Planet{Name: "Unknown"}, planet.InsertStrict)
+	assert.ErrorIs(t, err, planet.ErrNotInSwapi)
+	assert.Equal(t, domain.CodeValidationFailed, domain.CodeOf(err))
+	dbRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+
+	// Testing strict policy as the manager default
+	err = strictManager.Insert(context.Background(), &planet.Planet{Name: "Unknown"})
+	assert.ErrorIs(t, err, planet.ErrNotInSwapi)
+
+	p := &planet.Planet{Name: "Tatooine"}
+	err = strictManager.Insert(context.Background(), p)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(5), p.Apparitions)
+
+	// Testing lenient policy overriding the manager default
+	p = &planet.Planet{Name: "Unknown"}
+	err = strictManager.InsertWithPolicy(context.Background(), p, planet.InsertLenient)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(0), p.Apparitions)
+
+	// Testing enrich policy filling only the missing fields
+	p = &planet.Planet{Name: "Tatooine", Climate: "temperate"}
+	err = manager.InsertWithPolicy(context.Background(), p, planet.InsertEnrich)
+	assert.Nil(t, err)
+	assert.Equal(t, "temperate", p.Climate)
+	assert.Equal(t, "desert", p.Terrain)
+	assert.Equal(t, testFilms(5), p.Films)
+
+	p = &planet.Planet{Name: "Unknown"}
+	err = manager.InsertWithPolicy(context.Background(), p, planet.InsertEnrich)
+	assert.Nil(t, err)
+	assert.Equal(t, "", p.Climate)
+
+	// Testing enrich policy skipping the full record when nothing is missing
+	p = &planet.Planet{Name: "Tatooine", Climate: "temperate", Terrain: "ocean"}
+	err = manager.InsertWithPolicy(context.Background(), p, planet.InsertEnrich)
+	assert.Nil(t, err)
+	assert.Equal(t, "ocean", p.Terrain)
+	swapiRepo.AssertNumberOfCalls(t, "GetPlanet", 2)
+
+	// Testing unknown policy
+	err = manager.InsertWithPolicy(context.Background(), &planet.Planet{Name: "Tatooine"}, planet.InsertPolicy("loose"))
+	assert.ErrorIs(t, err, domain.ErrBadParamInput)
+}
+
+func TestManagerImport(t *testing.T) {
+	dbRepo := &mocks.DbRepository{}
+	swapiRepo := &mocks.SwapiRepository{}
+
+	manager := planet.NewManager(dbRepo, swapiRepo, planet.ManagerOptions{}, zerolog.Nop())
+
+	tatooine := planet.SwapiPlanet{
+		Name:       "Tatooine",
+		Climate:    "arid",
+		Terrain:    "desert",
+		Population: "200000",
+		Diameter:   "10465",
+		Gravity:    "1 standard",
+		Films:      testFilms(5),
+	}
+
+	swapiRepo.
+		On("GetPlanet", mock.Anything, "tatooine").
+		Return(tatooine, nil)
+
+	swapiRepo.
+		On("GetPlanetByID", mock.Anything, 2).
+		Return(planet.SwapiPlanet{Name: "Alderaan", Population: "unknown", Diameter: "12500", Gravity: "unknown"}, nil)
+
+	swapiRepo.
+		On("GetPlanetByID", mock.Anything, 99).
+		Return(planet.SwapiPlanet{}, domain.ErrNotFound)
+
+	swapiRepo.
+		On("GetPlanetByID", mock.Anything, 3).
+		Return(planet.SwapiPlanet{}, domain.ErrUnavailable)
+
+	dbRepo.
+		On("GetByName", mock.Anything, "Tatooine").
+		Return(planet.Planet{}, domain.ErrNotFound).
+		Once()
+
+	dbRepo.
+		On("GetByName", mock.Anything, "Tatooine").
+		Return(planet.Planet{ID: primitive.NewObjectID(), Name: "Tatooine"}, nil)
+
+	dbRepo.
+		On("GetByName", mock.Anything, "Alderaan").
+		Return(planet.Planet{}, domain.ErrNotFound)
+
+	dbRepo.
+		On("Insert", mock.Anything, mock.AnythingOfType("*planet.Planet")).
+		Return(nil)
+
+	// Testing import by name with every field mapped
+	p, err := manager.Import(context.Background(), planet.SwapiReference{Name: "tatooine"})
+	assert.Nil(t, err)
+	assert.NotEqual(t, primitive.NilObjectID, p.ID)
+	assert.Equal(t, "Tatooine", p.Name)
+	assert.Equal(t, "arid", p.Climate)
+	assert.Equal(t, "desert", p.Terrain)
+	assert.Equal(t, int64(200000), *p.Population)
+	assert.Equal(t, int64(10465), *p.Diameter)
+	assert.Equal(t, "1 standard", p.Gravity)
+	assert.Equal(t, int32(5), p.Apparitions)
+	assert.False(t, p.ApparitionsUpdatedAt.IsZero())
+
+	// Testing conflict with an already saved planet
+	_, err = manager.Import(context.Background(), planet.SwapiReference{Name: "tatooine"})
+	assert.ErrorIs(t, err, domain.ErrConflict)
+
+	// Testing import by id with unknown values
+	p, err = manager.Import(context.Background(), planet.SwapiReference{ID: 2})
+	assert.Nil(t, err)
+	assert.Nil(t, p.Population)
+	assert.Equal(t, int64(12500), *p.Diameter)
+	assert.Equal(t, "", p.Gravity)
+
+	// Testing planet not found on swapi
+	_, err = manager.Import(context.Background(), planet.SwapiReference{ID: 99})
+	assert.ErrorIs(t, err, domain.ErrValidationFailed)
+	var domainErr *domain.Error
+	assert.True(t, errors.As(err, &domainErr))
+	assert.Equal(t, "id", domainErr.Fields[0].Field)
+
+	// Testing swapi unavailable
+	_, err = manager.Import(context.Background(), planet.SwapiReference{ID: 3})
+	assert.ErrorIs(t, err, domain.ErrUnavailable)
+
+	dbRepo.AssertNumberOfCalls(t, "Insert", 2)
+}
+
+func TestManagerUpsert(t *testing.T) {
+	dbRepo := &mocks.DbRepository{}
+	swapiRepo := &mocks.SwapiRepository{}
+
+	manager := planet.NewManager(dbRepo, swapiRepo, planet.ManagerOptions{}, zerolog.Nop())
+
+	population := int64(1000)
+	current := planet.Planet{ID: primitive.NewObjectID(), Name: "Yavin IV", Climate: "temperate", Population: &population}
+
+	dbRepo.
+		On("GetByName", mock.Anything, "Yavin IV").
+		Return(current, nil)
+
+	dbRepo.
+		On("GetByName", mock.Anything, "Deleted").
+		Return(planet.Planet{}, domain.ErrNotFound)
+
+	dbRepo.
+		On("GetDeletedByName", mock.Anything, "Deleted").
+		Return(planet.Planet{ID: primitive.NewObjectID(), Name: "Deleted"}, nil)
+
+	dbRepo.
+		On("Update", mock.Anything, mock.AnythingOfType("*planet.Planet")).
+		Return(nil)
+
+	// Testing planet already up to date
+	samePopulation := int64(1000)
+	p := &planet.Planet{Name: "Yavin IV", Climate: "temperate", Population: &samePopulation}
+	result, err := manager.Upsert(context.Background(), p)
+	assert.Nil(t, err)
+	assert.Equal(t, planet.UpsertSkipped, result)
+	assert.Equal(t, current.ID, p.ID)
+
+	// Testing planet changed on swapi
+	p = &planet.Planet{Name: "Yavin IV", Climate: "temperate, tropical", Population: &samePopulation}
+	result, err = manager.Upsert(context.Background(), p)
+	assert.Nil(t, err)
+	assert.Equal(t, planet.UpsertUpdated, result)
+	assert.Equal(t, current.ID, p.ID)
+	dbRepo.AssertNumberOfCalls(t, "Update", 1)
+
+	// Testing planet in the trash not recreated
+	result, err = manager.Upsert(context.Background(), &planet.Planet{Name: "Deleted"})
+	assert.Nil(t, err)
+	assert.Equal(t, planet.UpsertSkipped, result)
+	dbRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+
+	// Testing invalid planet
+	_, err = manager.Upsert(context.Background(), &planet.Planet{Name: " "})
+	assert.ErrorIs(t, err, domain.ErrBadParamInput)
+}
+
+func TestManagerInsertMany(t *testing.T) {
+	dbRepo := &mocks.DbRepository{}
+	swapiRepo := &mocks.SwapiRepository{}
+
+	manager := planet.NewManager(dbRepo, swapiRepo, planet.ManagerOptions{BatchConcurrency: 2}, zerolog.Nop())
+
+	swapiRepo.
+		On("GetPlanetFilms", mock.Anything, "Tatooine").
+		Return(testFilms(5), nil)
+
+	swapiRepo.
+		On("GetPlanetFilms", mock.Anything, "Hoth").
+		Return(testFilms(1), nil)
+
+	swapiRepo.
+		On("GetPlanetFilms", mock.Anything, "Unknown").
+		Return(nil, domain.ErrNotFound)
+
+	swapiRepo.
+		On("GetPlanetFilms", mock.Anything, "Alderaan").
+		Return(testFilms(2), nil)
+
+	dbRepo.
+		On("GetByName", mock.Anything, "Alderaan").
+		Return(planet.Planet{ID: primitive.NewObjectID(), Name: "Alderaan"}, nil)
+
+	dbRepo.
+		On("GetByName", mock.Anything, mock.Anything).
+		Return(planet.Planet{}, domain.ErrNotFound)
+
+	dbRepo.
+		On("InsertMany", mock.Anything, mock.AnythingOfType("[]*planet.Planet")).
+		Return(func(ctx context.Context, planets []*planet.Planet) []error {
+			errs := make([]error, len(planets))
+			for i, p := range planets {
+				if p.Name == "Hoth" {
+					errs[i] = domain.ErrConflict
+				}
+			}
+			return errs
+		})
+
+	planets := []*planet.Planet{
+		{Name: "Tatooine"},
+		{Name: " tatooine "},
+		{Name: ""},
+		{Name: "Alderaan"},
+		{Name: "Hoth"},
+		{Name: "Unknown"},
+	}
+
+	// Testing per-item results of a mixed batch
+	errs := manager.InsertManyWithPolicy(context.Background(), planets, planet.InsertStrict)
+	assert.Len(t, errs, 6)
+	assert.Nil(t, errs[0])
+	assert.NotEqual(t, primitive.NilObjectID, planets[0].ID)
+	assert.Equal(t, int32(5), planets[0].Apparitions)
+	assert.Equal(t, domain.ErrConflict, errs[1])
+	assert.Equal(t, domain.CodeInvalidInput, domain.CodeOf(errs[2]))
+	assert.Equal(t, domain.ErrConflict, errs[3])
+	assert.Equal(t, domain.ErrConflict, errs[4])
+	assert.Equal(t, primitive.NilObjectID, planets[4].ID)
+	assert.ErrorIs(t, errs[5], planet.ErrNotInSwapi)
+	dbRepo.AssertNumberOfCalls(t, "InsertMany", 1)
+
+	// Testing invalid policy failing every planet
+	errs = manager.InsertManyWithPolicy(context.Background(), []*planet.Planet{{Name: "Hoth"}}, planet.InsertPolicy("unknown"))
+	assert.Len(t, errs, 1)
+	assert.Equal(t, domain.CodeInvalidInput, domain.CodeOf(errs[0]))
+
+	// Testing batch without valid planets skipping the database
+	errs = manager.InsertMany(context.Background(), []*planet.Planet{{Name: "Alderaan"}})
+	assert.Equal(t, []error{domain.ErrConflict}, errs)
+	dbRepo.AssertNumberOfCalls(t, "InsertMany", 1)
+}
+
+func TestManagerDeleteMany(t *testing.T) {
+	dbRepo := &mocks.DbRepository{}
+
+	manager := planet.NewManager(dbRepo, nil, planet.ManagerOptions{}, zerolog.Nop())
+
+	pID := primitive.NewObjectID()
+	pIDNotFound := primitive.NewObjectID()
+
+	dbRepo.
+		On("DeleteMany", mock.Anything, []primitive.ObjectID{pID, pIDNotFound}).
+		Return([]error{nil, domain.ErrNotFound})
+
+	// Testing per-item results
+	errs := manager.DeleteMany(context.Background(), []primitive.ObjectID{pID, pIDNotFound})
+	assert.Equal(t, []error{nil, domain.ErrNotFound}, errs)
+}
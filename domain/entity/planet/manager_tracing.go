@@ -0,0 +1,183 @@
+package planet
+
+import (
+	"b2w/swapi-challenge/infra/tracing"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "b2w/swapi-challenge/domain/entity/planet"
+
+// tracedManager cria um span para cada operação do Manager, agrupando as
+// consultas à SWAPI e ao banco de dados feitas por ela
+type tracedManager struct {
+	next Manager
+}
+
+func NewTracedManager(next Manager) *tracedManager {
+	return &tracedManager{next: next}
+}
+
+func startManagerSpan(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Start(ctx, tracerName, "planet.Manager/"+operation, trace.WithAttributes(attrs...))
+}
+
+func planetIDAttribute(id primitive.ObjectID) attribute.KeyValue {
+	return attribute.String("planet.id", id.Hex())
+}
+
+// endBatchSpan registra quantos itens de uma operação em lote falharam. As
+// falhas de itens não marcam o span como erro, já que fazem parte do resultado.
+func endBatchSpan(span trace.Span, errs []error) {
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	span.SetAttributes(attribute.Int("planet.failed", failed))
+	tracing.End(span, nil)
+}
+
+func (m *tracedManager) Insert(ctx context.Context, p *Planet) error {
+	ctx, span := startManagerSpan(ctx, "Insert", attribute.String("planet.name", p.Name))
+	err := m.next.Insert(ctx, p)
+	if err == nil {
+		span.SetAttributes(planetIDAttribute(p.ID), attribute.Int("planet.apparitions", int(p.Apparitions)))
+	}
+	tracing.End(span, err)
+	return err
+}
+
+func (m *tracedManager) InsertWithPolicy(ctx context.Context, p *Planet, policy InsertPolicy) error {
+	ctx, span := startManagerSpan(ctx, "Insert", attribute.String("planet.name", p.Name), attribute.String("planet.insert_policy", string(policy)))
+	err := m.next.InsertWithPolicy(ctx, p, policy)
+	if err == nil {
+		span.SetAttributes(planetIDAttribute(p.ID), attribute.Int("planet.apparitions", int(p.Apparitions)))
+	}
+	tracing.End(span, err)
+	return err
+}
+
+func (m *tracedManager) InsertMany(ctx context.Context, planets []*Planet) []error {
+	ctx, span := startManagerSpan(ctx, "InsertMany", attribute.Int("planet.count", len(planets)))
+	errs := m.next.InsertMany(ctx, planets)
+	endBatchSpan(span, errs)
+	return errs
+}
+
+func (m *tracedManager) InsertManyWithPolicy(ctx context.Context, planets []*Planet, policy InsertPolicy) []error {
+	ctx, span := startManagerSpan(ctx, "InsertMany", attribute.Int("planet.count", len(planets)), attribute.String("planet.insert_policy", string(policy)))
+	errs := m.next.InsertManyWithPolicy(ctx, planets, policy)
+	endBatchSpan(span, errs)
+	return errs
+}
+
+func (m *tracedManager) Import(ctx context.Context, ref SwapiReference) (Planet, error) {
+	ctx, span := startManagerSpan(ctx, "Import", attribute.Int("swapi.id", ref.ID), attribute.String("planet.name", ref.Name))
+	p, err := m.next.Import(ctx, ref)
+	if err == nil {
+		span.SetAttributes(planetIDAttribute(p.ID), attribute.Int("planet.apparitions", int(p.Apparitions)))
+	}
+	tracing.End(span, err)
+	return p, err
+}
+
+func (m *tracedManager) Upsert(ctx context.Context, p *Planet) (UpsertResult, error) {
+	ctx, span := startManagerSpan(ctx, "Upsert", attribute.String("planet.name", p.Name))
+	result, err := m.next.Upsert(ctx, p)
+	if err == nil {
+		span.SetAttributes(planetIDAttribute(p.ID), attribute.String("planet.upsert_result", string(result)))
+	}
+	tracing.End(span, err)
+	return result, err
+}
+
+func (m *tracedManager) FindPage(ctx context.Context, req PageRequest) (Page, error) {
+	ctx, span := startManagerSpan(ctx, "FindPage", attribute.Int64("page.limit", req.Limit))
+	page, err := m.next.FindPage(ctx, req)
+	tracing.End(span, err)
+	return page, err
+}
+
+func (m *tracedManager) GetById(ctx context.Context, id primitive.ObjectID) (Planet, error) {
+	ctx, span := startManagerSpan(ctx, "GetById", planetIDAttribute(id))
+	p, err := m.next.GetById(ctx, id)
+	tracing.End(span, err)
+	return p, err
+}
+
+func (m *tracedManager) GetByName(ctx context.Context, name string) (Planet, error) {
+	ctx, span := startManagerSpan(ctx, "GetByName", attribute.String("planet.name", name))
+	p, err := m.next.GetByName(ctx, name)
+	tracing.End(span, err)
+	return p, err
+}
+
+func (m *tracedManager) GetDeletedByName(ctx context.Context, name string) (Planet, error) {
+	ctx, span := startManagerSpan(ctx, "GetDeletedByName", attribute.String("planet.name", name))
+	p, err := m.next.GetDeletedByName(ctx, name)
+	tracing.End(span, err)
+	return p, err
+}
+
+func (m *tracedManager) Update(ctx context.Context, p *Planet) error {
+	ctx, span := startManagerSpan(ctx, "Update", planetIDAttribute(p.ID), attribute.String("planet.name", p.Name))
+	err := m.next.Update(ctx, p)
+	tracing.End(span, err)
+	return err
+}
+
+func (m *tracedManager) UpdateWithPolicy(ctx context.Context, p *Planet, policy InsertPolicy) error {
+	ctx, span := startManagerSpan(ctx, "Update", planetIDAttribute(p.ID), attribute.String("planet.name", p.Name), attribute.String("planet.insert_policy", string(policy)))
+	err := m.next.UpdateWithPolicy(ctx, p, policy)
+	tracing.End(span, err)
+	return err
+}
+
+func (m *tracedManager) UpdateFilms(ctx context.Context, id primitive.ObjectID, films []Film, updatedAt time.Time) error {
+	ctx, span := startManagerSpan(ctx, "UpdateFilms", planetIDAttribute(id))
+	err := m.next.UpdateFilms(ctx, id, films, updatedAt)
+	tracing.End(span, err)
+	return err
+}
+
+func (m *tracedManager) Delete(ctx context.Context, id primitive.ObjectID) error {
+	ctx, span := startManagerSpan(ctx, "Delete", planetIDAttribute(id))
+	err := m.next.Delete(ctx, id)
+	tracing.End(span, err)
+	return err
+}
+
+func (m *tracedManager) DeleteMany(ctx context.Context, ids []primitive.ObjectID) []error {
+	ctx, span := startManagerSpan(ctx, "DeleteMany", attribute.Int("planet.count", len(ids)))
+	errs := m.next.DeleteMany(ctx, ids)
+	endBatchSpan(span, errs)
+	return errs
+}
+
+func (m *tracedManager) FindTrash(ctx context.Context, req PageRequest) (Page, error) {
+	ctx, span := startManagerSpan(ctx, "FindTrash", attribute.Int64("page.limit", req.Limit))
+	page, err := m.next.FindTrash(ctx, req)
+	tracing.End(span, err)
+	return page, err
+}
+
+func (m *tracedManager) Restore(ctx context.Context, id primitive.ObjectID) error {
+	ctx, span := startManagerSpan(ctx, "Restore", planetIDAttribute(id))
+	err := m.next.Restore(ctx, id)
+	tracing.End(span, err)
+	return err
+}
+
+func (m *tracedManager) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	ctx, span := startManagerSpan(ctx, "Purge")
+	purged, err := m.next.Purge(ctx, deletedBefore)
+	span.SetAttributes(attribute.Int64("planet.purged", purged))
+	tracing.End(span, err)
+	return purged, err
+}
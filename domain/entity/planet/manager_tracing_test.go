@@ -0,0 +1,63 @@
+package planet_test
+
+import (
+	"b2w/swapi-challenge/domain"
+	"b2w/swapi-challenge/domain/entity/planet"
+	"b2w/swapi-challenge/domain/entity/planet/mocks"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracedManager(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	next := &mocks.Manager{}
+	manager := planet.NewTracedManager(next)
+
+	id := primitive.NewObjectID()
+
+	next.
+		On("Insert", mock.MatchedBy(func(ctx context.Context) bool {
+			// As operações internas devem receber o contexto com o span do Manager
+			return trace.SpanContextFromContext(ctx).IsValid()
+		}), mock.AnythingOfType("*planet.Planet")).
+		Return(func(ctx context.Context, p *planet.Planet) error {
+			p.ID = id
+			return nil
+		})
+
+	next.
+		On("GetById", mock.Anything, id).
+		Return(planet.Planet{}, domain.ErrNotFound)
+
+	// Testing successful operation
+	err := manager.Insert(context.Background(), &planet.Planet{Name: "Tatooine"})
+	assert.Nil(t, err)
+
+	// Testing failed operation
+	_, err = manager.GetById(context.Background(), id)
+	assert.Equal(t, domain.ErrNotFound, err)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+
+	assert.Equal(t, "planet.Manager/Insert", spans[0].Name())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Contains(t, spans[0].Attributes(), attribute.String("planet.name", "Tatooine"))
+	assert.Contains(t, spans[0].Attributes(), attribute.String("planet.id", id.Hex()))
+
+	assert.Equal(t, "planet.Manager/GetById", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
@@ -3,11 +3,15 @@
 package mocks
 
 import (
+	context "context"
+
 	planet "b2w/swapi-challenge/domain/entity/planet"
 
 	mock "github.com/stretchr/testify/mock"
 
 	primitive "go.mongodb.org/mongo-driver/bson/primitive"
+
+	time "time"
 )
 
 // Manager is an autogenerated mock type for the Manager type
@@ -15,13 +19,13 @@ type Manager struct {
 	mock.Mock
 }
 
-// Delete provides a mock function with given fields: id
-func (_m *Manager) Delete(id primitive.ObjectID) error {
-	ret := _m.Called(id)
+// Delete provides a mock function with given fields: ctx, id
+func (_m *Manager) Delete(ctx context.Context, id primitive.ObjectID) error {
+	ret := _m.Called(ctx, id)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(primitive.ObjectID) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, primitive.ObjectID) error); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -29,22 +33,78 @@ func (_m *Manager) Delete(id primitive.ObjectID) error {
 	return r0
 }
 
-// FindAll provides a mock function with given fields:
-func (_m *Manager) FindAll() ([]planet.Planet, error) {
-	ret := _m.Called()
+// DeleteMany provides a mock function with given fields: ctx, ids
+func (_m *Manager) DeleteMany(ctx context.Context, ids []primitive.ObjectID) []error {
+	ret := _m.Called(ctx, ids)
 
-	var r0 []planet.Planet
-	if rf, ok := ret.Get(0).(func() []planet.Planet); ok {
-		r0 = rf()
+	var r0 []error
+	if rf, ok := ret.Get(0).(func(context.Context, []primitive.ObjectID) []error); ok {
+		r0 = rf(ctx, ids)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]planet.Planet)
+			r0 = ret.Get(0).([]error)
 		}
 	}
 
+	return r0
+}
+
+// FindPage provides a mock function with given fields: ctx, req
+func (_m *Manager) FindPage(ctx context.Context, req planet.PageRequest) (planet.Page, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 planet.Page
+	if rf, ok := ret.Get(0).(func(context.Context, planet.PageRequest) planet.Page); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Get(0).(planet.Page)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, planet.PageRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindTrash provides a mock function with given fields: ctx, req
+func (_m *Manager) FindTrash(ctx context.Context, req planet.PageRequest) (planet.Page, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 planet.Page
+	if rf, ok := ret.Get(0).(func(context.Context, planet.PageRequest) planet.Page); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Get(0).(planet.Page)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, planet.PageRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetById provides a mock function with given fields: ctx, id
+func (_m *Manager) GetById(ctx context.Context, id primitive.ObjectID) (planet.Planet, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 planet.Planet
+	if rf, ok := ret.Get(0).(func(context.Context, primitive.ObjectID) planet.Planet); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(planet.Planet)
+	}
+
 	var r1 error
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context, primitive.ObjectID) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -52,20 +112,20 @@ func (_m *Manager) FindAll() ([]planet.Planet, error) {
 	return r0, r1
 }
 
-// GetById provides a mock function with given fields: id
-func (_m *Manager) GetById(id primitive.ObjectID) (planet.Planet, error) {
-	ret := _m.Called(id)
+// GetByName provides a mock function with given fields: ctx, name
+func (_m *Manager) GetByName(ctx context.Context, name string) (planet.Planet, error) {
+	ret := _m.Called(ctx, name)
 
 	var r0 planet.Planet
-	if rf, ok := ret.Get(0).(func(primitive.ObjectID) planet.Planet); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) planet.Planet); ok {
+		r0 = rf(ctx, name)
 	} else {
 		r0 = ret.Get(0).(planet.Planet)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(primitive.ObjectID) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -73,20 +133,122 @@ func (_m *Manager) GetById(id primitive.ObjectID) (planet.Planet, error) {
 	return r0, r1
 }
 
-// GetByName provides a mock function with given fields: name
-func (_m *Manager) GetByName(name string) (planet.Planet, error) {
-	ret := _m.Called(name)
+// GetDeletedByName provides a mock function with given fields: ctx, name
+func (_m *Manager) GetDeletedByName(ctx context.Context, name string) (planet.Planet, error) {
+	ret := _m.Called(ctx, name)
 
 	var r0 planet.Planet
-	if rf, ok := ret.Get(0).(func(string) planet.Planet); ok {
-		r0 = rf(name)
+	if rf, ok := ret.Get(0).(func(context.Context, string) planet.Planet); ok {
+		r0 = rf(ctx, name)
 	} else {
 		r0 = ret.Get(0).(planet.Planet)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(name)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Import provides a mock function with given fields: ctx, ref
+func (_m *Manager) Import(ctx context.Context, ref planet.SwapiReference) (planet.Planet, error) {
+	ret := _m.Called(ctx, ref)
+
+	var r0 planet.Planet
+	if rf, ok := ret.Get(0).(func(context.Context, planet.SwapiReference) planet.Planet); ok {
+		r0 = rf(ctx, ref)
+	} else {
+		r0 = ret.Get(0).(planet.Planet)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, planet.SwapiReference) error); ok {
+		r1 = rf(ctx, ref)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Insert provides a mock function with given fields: ctx, p
+func (_m *Manager) Insert(ctx context.Context, p *planet.Planet) error {
+	ret := _m.Called(ctx, p)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *planet.Planet) error); ok {
+		r0 = rf(ctx, p)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertMany provides a mock function with given fields: ctx, planets
+func (_m *Manager) InsertMany(ctx context.Context, planets []*planet.Planet) []error {
+	ret := _m.Called(ctx, planets)
+
+	var r0 []error
+	if rf, ok := ret.Get(0).(func(context.Context, []*planet.Planet) []error); ok {
+		r0 = rf(ctx, planets)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]error)
+		}
+	}
+
+	return r0
+}
+
+// InsertManyWithPolicy provides a mock function with given fields: ctx, planets, policy
+func (_m *Manager) InsertManyWithPolicy(ctx context.Context, planets []*planet.Planet, policy planet.InsertPolicy) []error {
+	ret := _m.Called(ctx, planets, policy)
+
+	var r0 []error
+	if rf, ok := ret.Get(0).(func(context.Context, []*planet.Planet, planet.InsertPolicy) []error); ok {
+		r0 = rf(ctx, planets, policy)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]error)
+		}
+	}
+
+	return r0
+}
+
+// InsertWithPolicy provides a mock function with given fields: ctx, p, policy
+func (_m *Manager) InsertWithPolicy(ctx context.Context, p *planet.Planet, policy planet.InsertPolicy) error {
+	ret := _m.Called(ctx, p, policy)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *planet.Planet, planet.InsertPolicy) error); ok {
+		r0 = rf(ctx, p, policy)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Purge provides a mock function with given fields: ctx, deletedBefore
+func (_m *Manager) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	ret := _m.Called(ctx, deletedBefore)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, deletedBefore)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, deletedBefore)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -94,16 +256,79 @@ func (_m *Manager) GetByName(name string) (planet.Planet, error) {
 	return r0, r1
 }
 
-// Insert provides a mock function with given fields: p
-func (_m *Manager) Insert(p *planet.Planet) error {
-	ret := _m.Called(p)
+// Restore provides a mock function with given fields: ctx, id
+func (_m *Manager) Restore(ctx context.Context, id primitive.ObjectID) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, primitive.ObjectID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: ctx, p
+func (_m *Manager) Update(ctx context.Context, p *planet.Planet) error {
+	ret := _m.Called(ctx, p)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *planet.Planet) error); ok {
+		r0 = rf(ctx, p)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateFilms provides a mock function with given fields: ctx, id, films, updatedAt
+func (_m *Manager) UpdateFilms(ctx context.Context, id primitive.ObjectID, films []planet.Film, updatedAt time.Time) error {
+	ret := _m.Called(ctx, id, films, updatedAt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, primitive.ObjectID, []planet.Film, time.Time) error); ok {
+		r0 = rf(ctx, id, films, updatedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateWithPolicy provides a mock function with given fields: ctx, p, policy
+func (_m *Manager) UpdateWithPolicy(ctx context.Context, p *planet.Planet, policy planet.InsertPolicy) error {
+	ret := _m.Called(ctx, p, policy)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*planet.Planet) error); ok {
-		r0 = rf(p)
+	if rf, ok := ret.Get(0).(func(context.Context, *planet.Planet, planet.InsertPolicy) error); ok {
+		r0 = rf(ctx, p, policy)
 	} else {
 		r0 = ret.Error(0)
 	}
 
 	return r0
 }
+
+// Upsert provides a mock function with given fields: ctx, p
+func (_m *Manager) Upsert(ctx context.Context, p *planet.Planet) (planet.UpsertResult, error) {
+	ret := _m.Called(ctx, p)
+
+	var r0 planet.UpsertResult
+	if rf, ok := ret.Get(0).(func(context.Context, *planet.Planet) planet.UpsertResult); ok {
+		r0 = rf(ctx, p)
+	} else {
+		r0 = ret.Get(0).(planet.UpsertResult)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *planet.Planet) error); ok {
+		r1 = rf(ctx, p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -2,27 +2,98 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	planet "b2w/swapi-challenge/domain/entity/planet"
+
+	mock "github.com/stretchr/testify/mock"
+)
 
 // SwapiRepository is an autogenerated mock type for the SwapiRepository type
 type SwapiRepository struct {
 	mock.Mock
 }
 
-// GetPlanetApparitions provides a mock function with given fields: name
-func (_m *SwapiRepository) GetPlanetApparitions(name string) (int32, error) {
-	ret := _m.Called(name)
+// GetPlanet provides a mock function with given fields: ctx, name
+func (_m *SwapiRepository) GetPlanet(ctx context.Context, name string) (planet.SwapiPlanet, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 planet.SwapiPlanet
+	if rf, ok := ret.Get(0).(func(context.Context, string) planet.SwapiPlanet); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Get(0).(planet.SwapiPlanet)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPlanetByID provides a mock function with given fields: ctx, id
+func (_m *SwapiRepository) GetPlanetByID(ctx context.Context, id int) (planet.SwapiPlanet, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 planet.SwapiPlanet
+	if rf, ok := ret.Get(0).(func(context.Context, int) planet.SwapiPlanet); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(planet.SwapiPlanet)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPlanetFilms provides a mock function with given fields: ctx, name
+func (_m *SwapiRepository) GetPlanetFilms(ctx context.Context, name string) ([]planet.Film, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 []planet.Film
+	if rf, ok := ret.Get(0).(func(context.Context, string) []planet.Film); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]planet.Film)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPlanets provides a mock function with given fields: ctx, page
+func (_m *SwapiRepository) ListPlanets(ctx context.Context, page int) (planet.SwapiPlanetPage, error) {
+	ret := _m.Called(ctx, page)
 
-	var r0 int32
-	if rf, ok := ret.Get(0).(func(string) int32); ok {
-		r0 = rf(name)
+	var r0 planet.SwapiPlanetPage
+	if rf, ok := ret.Get(0).(func(context.Context, int) planet.SwapiPlanetPage); ok {
+		r0 = rf(ctx, page)
 	} else {
-		r0 = ret.Get(0).(int32)
+		r0 = ret.Get(0).(planet.SwapiPlanetPage)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(name)
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, page)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,78 @@
+package planet
+
+import (
+	"fmt"
+	"reflect"
+
+	"b2w/swapi-challenge/domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	DefaultPageLimit int64 = 20
+	MaxPageLimit     int64 = 100
+)
+
+type PageRequest struct {
+	Query Query
+	After Cursor
+	Limit int64
+}
+
+type Page struct {
+	Planets    []Planet
+	NextCursor Cursor
+	HasMore    bool
+}
+
+// Cursor marca onde a próxima página começa. Além do ID do último planeta da
+// página, guarda os valores dele nos campos da ordenação, de forma que a
+// próxima página não depende de o planeta continuar salvo.
+type Cursor struct {
+	ID     primitive.ObjectID `bson:"id"`
+	Sort   []string           `bson:"sort,omitempty"`
+	Values []interface{}      `bson:"values,omitempty"`
+}
+
+// NewCursor cria o cursor que aponta para depois do planeta na ordenação
+func NewCursor(p Planet, sort []SortField) Cursor {
+	c := Cursor{ID: p.ID}
+	for _, s := range sort {
+		c.Sort = append(c.Sort, s.String())
+		c.Values = append(c.Values, sortValue(p, s.Field))
+	}
+
+	return c
+}
+
+func (c Cursor) IsZero() bool {
+	return c.ID == primitive.NilObjectID
+}
+
+// matches indica se o cursor foi gerado por uma listagem com a mesma ordenação.
+// Os valores vêm do cliente, então também precisam ter o tipo de cada campo.
+func (c Cursor) matches(sort []SortField) bool {
+	if len(c.Sort) != len(sort) || len(c.Values) != len(sort) {
+		return false
+	}
+	for i, s := range sort {
+		if c.Sort[i] != s.String() || reflect.TypeOf(c.Values[i]) != reflect.TypeOf(sortValue(Planet{}, s.Field)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func invalidListing(fields ...domain.FieldError) error {
+	return domain.NewError(domain.CodeInvalidInput, "Invalid listing params", fields...)
+}
+
+func (r PageRequest) Validate() error {
+	if r.Limit <= 0 || r.Limit > MaxPageLimit {
+		return invalidListing(domain.FieldError{Field: "limit", Message: fmt.Sprintf("must be between 1 and %d", MaxPageLimit)})
+	}
+
+	return r.Query.Validate()
+}
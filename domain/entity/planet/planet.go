@@ -1,7 +1,10 @@
 package planet
 
 import (
-	"errors"
+	"strings"
+	"time"
+
+	"b2w/swapi-challenge/domain"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -12,12 +15,64 @@ type Planet struct {
 	Climate     string             `bson:"climate"`
 	Terrain     string             `bson:"terrain"`
 	Apparitions int32              `bson:"apparitions"`
+	Films       []Film             `bson:"films"`
+
+	// Dados preenchidos apenas na importação da SWAPI
+	Population *int64 `bson:"population,omitempty"`
+	Diameter   *int64 `bson:"diameter,omitempty"`
+	Gravity    string `bson:"gravity,omitempty"`
+
+	ApparitionsUpdatedAt time.Time  `bson:"apparitions_updated_at"`
+	DeletedAt            *time.Time `bson:"deleted_at,omitempty"`
+}
+
+type Film struct {
+	Title       string `bson:"title"`
+	EpisodeID   int    `bson:"episode_id"`
+	ReleaseDate string `bson:"release_date"`
 }
 
 func (p Planet) Validate() error {
 	if p.Name == "" {
-		return errors.New("invalid name param")
+		return invalidPlanet(domain.FieldError{Field: "name", Message: "must not be empty"})
 	}
 
 	return nil
 }
+
+func invalidPlanet(fields ...domain.FieldError) error {
+	return domain.NewError(domain.CodeInvalidInput, "Invalid planet input params", fields...)
+}
+
+// Normalize remove espaços extras dos campos do planeta. A comparação de
+// nomes sem diferenciar maiúsculas é feita pela collation do banco de dados.
+func (p *Planet) Normalize() {
+	p.Name = NormalizeName(p.Name)
+	p.Climate = strings.TrimSpace(p.Climate)
+	p.Terrain = strings.TrimSpace(p.Terrain)
+}
+
+// SetFilms atualiza os filmes do planeta, mantendo as aparições
+// compatíveis com a quantidade de filmes
+func (p *Planet) SetFilms(films []Film) {
+	p.Films = films
+	p.Apparitions = int32(len(films))
+}
+
+func FilmsEqual(a, b []Film) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func NormalizeName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
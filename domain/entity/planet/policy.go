@@ -0,0 +1,54 @@
+package planet
+
+import (
+	"b2w/swapi-challenge/domain"
+	"fmt"
+)
+
+// InsertPolicy define como a criação trata planetas que não existem na SWAPI
+type InsertPolicy string
+
+const (
+	// InsertStrict recusa planetas que não existem na SWAPI
+	InsertStrict InsertPolicy = "strict"
+	// InsertLenient salva planetas que não existem na SWAPI, sem aparições
+	InsertLenient InsertPolicy = "lenient"
+	// InsertEnrich funciona como InsertLenient, mas preenche o clima e o
+	// terreno não informados com os dados da SWAPI
+	InsertEnrich InsertPolicy = "enrich"
+)
+
+var ErrNotInSwapi = domain.NewError(domain.CodeValidationFailed, "Planet not found in the Star Wars API",
+	domain.FieldError{Field: "name", Message: "must be a planet from the Star Wars API"})
+
+// notInSwapi aponta o campo usado para buscar o planeta na SWAPI
+func notInSwapi(ref SwapiReference) error {
+	if ref.ID == 0 {
+		return ErrNotInSwapi
+	}
+
+	return domain.NewError(domain.CodeValidationFailed, ErrNotInSwapi.Message,
+		domain.FieldError{Field: "id", Message: "must be a planet from the Star Wars API"})
+}
+
+// ParseInsertPolicy interpreta o nome da política. Um valor vazio é aceito e
+// indica a política padrão do gerenciador.
+func ParseInsertPolicy(value string) (InsertPolicy, error) {
+	policy := InsertPolicy(value)
+	switch policy {
+	case "", InsertStrict, InsertLenient, InsertEnrich:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown insert policy: %q", value)
+	}
+}
+
+// Enrich preenche o clima e o terreno não informados com os do registro da SWAPI
+func (p *Planet) Enrich(swapiP SwapiPlanet) {
+	if p.Climate == "" {
+		p.Climate = swapiP.Climate
+	}
+	if p.Terrain == "" {
+		p.Terrain = swapiP.Terrain
+	}
+}
@@ -0,0 +1,82 @@
+package planet
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type PurgerOptions struct {
+	Interval  time.Duration
+	Retention time.Duration
+}
+
+// trashPurger remove definitivamente os planetas que estão na lixeira há
+// mais tempo que o período de retenção configurado
+type trashPurger struct {
+	dbRepo  DbRepository
+	options PurgerOptions
+	log     zerolog.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewPurger(dbR DbRepository, opts PurgerOptions, log zerolog.Logger) *trashPurger {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &trashPurger{
+		dbRepo:  dbR,
+		options: opts,
+		log:     log.With().Str("component", "trash_purger").Logger(),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Start executa a limpeza periodicamente, de acordo com o intervalo configurado
+func (p *trashPurger) Start() {
+	if p.options.Interval <= 0 || p.options.Retention <= 0 {
+		return
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(p.options.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				p.runLogged()
+			case <-p.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop interrompe o agendamento e aguarda a limpeza em andamento terminar
+func (p *trashPurger) Stop() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+func (p *trashPurger) Purge(ctx context.Context) (int64, error) {
+	return p.dbRepo.Purge(ctx, time.Now().Add(-p.options.Retention))
+}
+
+func (p *trashPurger) runLogged() {
+	purged, err := p.Purge(p.ctx)
+	if err != nil {
+		p.log.Error().Err(err).Msg("trash purge failed")
+		return
+	}
+
+	p.log.Info().Int64("purged", purged).Msg("trash purge finished")
+}
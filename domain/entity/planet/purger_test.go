@@ -0,0 +1,57 @@
+package planet_test
+
+import (
+	"b2w/swapi-challenge/domain/entity/planet"
+	"b2w/swapi-challenge/domain/entity/planet/mocks"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPurgerPurge(t *testing.T) {
+	dbRepo := &mocks.DbRepository{}
+
+	purger := planet.NewPurger(dbRepo, planet.PurgerOptions{Retention: 24 * time.Hour}, zerolog.Nop())
+
+	dbRepo.
+		On("Purge", mock.Anything, mock.MatchedBy(func(deletedBefore time.Time) bool {
+			// O limite deve estar um período de retenção no passado
+			elapsed := time.Since(deletedBefore)
+			return elapsed >= 24*time.Hour && elapsed < 25*time.Hour
+		})).
+		Return(int64(2), nil)
+
+	purged, err := purger.Purge(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), purged)
+}
+
+func TestPurgerStart(t *testing.T) {
+	dbRepo := &mocks.DbRepository{}
+
+	purger := planet.NewPurger(dbRepo, planet.PurgerOptions{Interval: 5 * time.Millisecond, Retention: time.Hour}, zerolog.Nop())
+
+	dbRepo.
+		On("Purge", mock.Anything, mock.AnythingOfType("time.Time")).
+		Return(int64(0), nil)
+
+	// Testing the purge is scheduled and stops cleanly
+	purger.Start()
+	time.Sleep(20 * time.Millisecond)
+	purger.Stop()
+
+	dbRepo.AssertCalled(t, "Purge", mock.Anything, mock.AnythingOfType("time.Time"))
+
+	// Testing the purge is disabled without retention
+	disabledRepo := &mocks.DbRepository{}
+	disabled := planet.NewPurger(disabledRepo, planet.PurgerOptions{Interval: 5 * time.Millisecond}, zerolog.Nop())
+	disabled.Start()
+	time.Sleep(20 * time.Millisecond)
+	disabled.Stop()
+
+	disabledRepo.AssertNotCalled(t, "Purge", mock.Anything, mock.Anything)
+}
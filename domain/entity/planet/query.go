@@ -0,0 +1,92 @@
+package planet
+
+import (
+	"strings"
+
+	"b2w/swapi-challenge/domain"
+)
+
+const (
+	FieldName        = "name"
+	FieldClimate     = "climate"
+	FieldTerrain     = "terrain"
+	FieldApparitions = "apparitions"
+)
+
+// Campos permitidos na ordenação da listagem
+var sortableFields = map[string]bool{
+	FieldName:        true,
+	FieldClimate:     true,
+	FieldTerrain:     true,
+	FieldApparitions: true,
+}
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// String retorna o campo no formato aceito por ParseSort
+func (s SortField) String() string {
+	if s.Desc {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+type Query struct {
+	NameContains   string
+	Climate        string
+	Terrain        string
+	ApparitionsGte *int32
+	ApparitionsLte *int32
+	Sort           []SortField
+}
+
+// ParseSort interpreta uma lista separada por vírgulas como "-apparitions,name",
+// onde o prefixo "-" indica ordem decrescente
+func ParseSort(sort string) ([]SortField, error) {
+	if strings.TrimSpace(sort) == "" {
+		return nil, nil
+	}
+
+	var fields []SortField
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(sort, ",") {
+		item = strings.TrimSpace(item)
+
+		field := SortField{Field: item}
+		if strings.HasPrefix(item, "-") {
+			field = SortField{Field: item[1:], Desc: true}
+		}
+
+		if !sortableFields[field.Field] || seen[field.Field] {
+			return nil, invalidListing(domain.FieldError{Field: "sort", Message: "unknown or repeated field " + field.Field})
+		}
+
+		seen[field.Field] = true
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+func (q Query) Validate() error {
+	if q.ApparitionsGte != nil && *q.ApparitionsGte < 0 {
+		return invalidListing(domain.FieldError{Field: "apparitions_gte", Message: "must not be negative"})
+	}
+	if q.ApparitionsLte != nil && *q.ApparitionsLte < 0 {
+		return invalidListing(domain.FieldError{Field: "apparitions_lte", Message: "must not be negative"})
+	}
+	if q.ApparitionsGte != nil && q.ApparitionsLte != nil && *q.ApparitionsGte > *q.ApparitionsLte {
+		return invalidListing(domain.FieldError{Field: "apparitions_gte", Message: "must not be greater than apparitions_lte"})
+	}
+
+	for _, s := range q.Sort {
+		if !sortableFields[s.Field] {
+			return invalidListing(domain.FieldError{Field: "sort", Message: "unknown field " + s.Field})
+		}
+	}
+
+	return nil
+}
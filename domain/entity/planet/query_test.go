@@ -0,0 +1,47 @@
+package planet_test
+
+import (
+	"b2w/swapi-challenge/domain/entity/planet"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSort(t *testing.T) {
+	// Testing empty sort
+	result, err := planet.ParseSort("")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(result))
+
+	// Testing multiple fields
+	result, err = planet.ParseSort("-apparitions, name")
+	assert.Nil(t, err)
+	assert.Equal(t, []planet.SortField{
+		{Field: planet.FieldApparitions, Desc: true},
+		{Field: planet.FieldName},
+	}, result)
+
+	// Testing field not allowed
+	_, err = planet.ParseSort("password")
+	assert.NotNil(t, err)
+
+	// Testing repeated field
+	_, err = planet.ParseSort("name,-name")
+	assert.NotNil(t, err)
+
+	// Testing empty field
+	_, err = planet.ParseSort("name,")
+	assert.NotNil(t, err)
+}
+
+func TestQueryValidate(t *testing.T) {
+	one := int32(1)
+	two := int32(2)
+	negative := int32(-1)
+
+	assert.Nil(t, planet.Query{}.Validate())
+	assert.Nil(t, planet.Query{ApparitionsGte: &one, ApparitionsLte: &two}.Validate())
+	assert.NotNil(t, planet.Query{ApparitionsGte: &two, ApparitionsLte: &one}.Validate())
+	assert.NotNil(t, planet.Query{ApparitionsGte: &negative}.Validate())
+	assert.NotNil(t, planet.Query{Sort: []planet.SortField{{Field: "_id"}}}.Validate())
+}
@@ -0,0 +1,203 @@
+package planet
+
+import (
+	"b2w/swapi-challenge/domain"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const refreshPageSize int64 = 100
+
+type RefresherOptions struct {
+	Interval    time.Duration
+	Concurrency int
+}
+
+type RefreshResult struct {
+	Checked int `json:"checked"`
+	Updated int `json:"updated"`
+	Failed  int `json:"failed"`
+}
+
+// apparitionsRefresher consulta novamente a SWAPI para todos os planetas
+// salvos, atualizando as aparições que mudaram desde a última consulta
+type apparitionsRefresher struct {
+	dbRepo    DbRepository
+	swapiRepo SwapiRepository
+	options   RefresherOptions
+	log       zerolog.Logger
+
+	running int32
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func NewRefresher(dbR DbRepository, swapiR SwapiRepository, opts RefresherOptions, log zerolog.Logger) *apparitionsRefresher {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+
+	// O contexto é cancelado pelo Stop, interrompendo as consultas em andamento
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &apparitionsRefresher{
+		dbRepo:    dbR,
+		swapiRepo: swapiR,
+		options:   opts,
+		log:       log.With().Str("component", "apparitions_refresher").Logger(),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Start executa a atualização periodicamente, de acordo com o intervalo configurado
+func (r *apparitionsRefresher) Start() {
+	if r.options.Interval <= 0 {
+		return
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.options.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				r.runLogged()
+			case <-r.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop interrompe o agendamento e a atualização em andamento, aguardando seu término
+func (r *apparitionsRefresher) Stop() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+// TriggerRefresh inicia uma atualização em segundo plano, retornando
+// domain.ErrConflict caso já exista uma em andamento
+func (r *apparitionsRefresher) TriggerRefresh() error {
+	if !atomic.CompareAndSwapInt32(&r.running, 0, 1) {
+		return domain.ErrConflict
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer atomic.StoreInt32(&r.running, 0)
+
+		r.logResult(r.refreshAll(r.ctx))
+	}()
+
+	return nil
+}
+
+func (r *apparitionsRefresher) Refresh(ctx context.Context) (RefreshResult, error) {
+	if !atomic.CompareAndSwapInt32(&r.running, 0, 1) {
+		return RefreshResult{}, domain.ErrConflict
+	}
+	defer atomic.StoreInt32(&r.running, 0)
+
+	return r.refreshAll(ctx)
+}
+
+func (r *apparitionsRefresher) runLogged() {
+	result, err := r.Refresh(r.ctx)
+	if errors.Is(err, domain.ErrConflict) {
+		r.log.Info().Msg("apparitions refresh skipped: already running")
+		return
+	}
+
+	r.logResult(result, err)
+}
+
+func (r *apparitionsRefresher) logResult(result RefreshResult, err error) {
+	if err != nil {
+		r.log.Error().Err(err).Msg("apparitions refresh failed")
+		return
+	}
+
+	r.log.Info().
+		Int("checked", result.Checked).
+		Int("updated", result.Updated).
+		Int("failed", result.Failed).
+		Msg("apparitions refresh finished")
+}
+
+func (r *apparitionsRefresher) refreshAll(ctx context.Context) (RefreshResult, error) {
+	var result RefreshResult
+	var mu sync.Mutex
+
+	var after Cursor
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		page, err := r.dbRepo.FindPage(ctx, PageRequest{After: after, Limit: refreshPageSize})
+		if err != nil {
+			return result, err
+		}
+
+		sem := make(chan struct{}, r.options.Concurrency)
+		var wg sync.WaitGroup
+		for _, p := range page.Planets {
+			sem <- struct{}{}
+			wg.Add(1)
+
+			go func(p Planet) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				updated, err := r.refreshPlanet(ctx, p)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				result.Checked++
+				if err != nil {
+					result.Failed++
+					r.log.Warn().Err(err).Str("planet_id", p.ID.Hex()).Msg("apparitions refresh of planet failed")
+				} else if updated {
+					result.Updated++
+				}
+			}(p)
+		}
+		wg.Wait()
+
+		if !page.HasMore {
+			return result, nil
+		}
+		after = page.NextCursor
+	}
+}
+
+func (r *apparitionsRefresher) refreshPlanet(ctx context.Context, p Planet) (bool, error) {
+	films, err := r.swapiRepo.GetPlanetFilms(ctx, p.Name)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return false, err
+	}
+
+	// Planetas salvos antes dos filmes serem guardados também são atualizados
+	if FilmsEqual(films, p.Films) && int32(len(films)) == p.Apparitions {
+		return false, nil
+	}
+
+	if err := r.dbRepo.UpdateFilms(ctx, p.ID, films, time.Now()); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,175 @@
+package planet_test
+
+import (
+	"b2w/swapi-challenge/domain"
+	"b2w/swapi-challenge/domain/entity/planet"
+	"b2w/swapi-challenge/domain/entity/planet/mocks"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRefresherRefresh(t *testing.T) {
+	dbRepo := &mocks.DbRepository{}
+	swapiRepo := &mocks.SwapiRepository{}
+
+	refresher := planet.NewRefresher(dbRepo, swapiRepo, planet.RefresherOptions{Concurrency: 2}, zerolog.Nop())
+
+	pUnchanged := planet.Planet{ID: primitive.NewObjectID(), Name: "Unchanged", Apparitions: 2, Films: testFilms(2)}
+	pChanged := planet.Planet{ID: primitive.NewObjectID(), Name: "Changed", Apparitions: 1, Films: testFilms(1)}
+	pNotFound := planet.Planet{ID: primitive.NewObjectID(), Name: "Not Found", Apparitions: 3, Films: testFilms(3)}
+	pSwapiError := planet.Planet{ID: primitive.NewObjectID(), Name: "Swapi Error"}
+	pWithoutFilms := planet.Planet{ID: primitive.NewObjectID(), Name: "Without Films", Apparitions: 2}
+
+	dbRepo.
+		On("FindPage", mock.Anything, mock.MatchedBy(func(req planet.PageRequest) bool {
+			return req.After.IsZero()
+		})).
+		Return(planet.Page{Planets: []planet.Planet{pUnchanged, pChanged}, NextCursor: planet.Cursor{ID: pChanged.ID}, HasMore: true}, nil)
+
+	dbRepo.
+		On("FindPage", mock.Anything, mock.MatchedBy(func(req planet.PageRequest) bool {
+			return req.After.ID == pChanged.ID
+		})).
+		Return(planet.Page{Planets: []planet.Planet{pNotFound, pSwapiError, pWithoutFilms}}, nil)
+
+	swapiRepo.On("GetPlanetFilms", mock.Anything, pUnchanged.Name).Return(testFilms(2), nil)
+	swapiRepo.On("GetPlanetFilms", mock.Anything, pChanged.Name).Return(testFilms(4), nil)
+	swapiRepo.On("GetPlanetFilms", mock.Anything, pNotFound.Name).Return(nil, domain.ErrNotFound)
+	swapiRepo.On("GetPlanetFilms", mock.Anything, pSwapiError.Name).Return(nil, errors.New("swapi error"))
+	swapiRepo.On("GetPlanetFilms", mock.Anything, pWithoutFilms.Name).Return(testFilms(2), nil)
+
+	dbRepo.
+		On("UpdateFilms", mock.Anything, pChanged.ID, testFilms(4), mock.AnythingOfType("time.Time")).
+		Return(nil)
+
+	dbRepo.
+		On("UpdateFilms", mock.Anything, pNotFound.ID, []planet.Film(nil), mock.AnythingOfType("time.Time")).
+		Return(nil)
+
+	dbRepo.
+		On("UpdateFilms", mock.Anything, pWithoutFilms.ID, testFilms(2), mock.AnythingOfType("time.Time")).
+		Return(nil)
+
+	result, err := refresher.Refresh(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, planet.RefreshResult{Checked: 5, Updated: 3, Failed: 1}, result)
+	dbRepo.AssertNotCalled(t, "UpdateFilms", mock.Anything, pUnchanged.ID, mock.Anything, mock.Anything)
+}
+
+func TestRefresherFindError(t *testing.T) {
+	dbRepo := &mocks.DbRepository{}
+
+	refresher := planet.NewRefresher(dbRepo, nil, planet.RefresherOptions{}, zerolog.Nop())
+
+	dbRepo.
+		On("FindPage", mock.Anything, mock.AnythingOfType("planet.PageRequest")).
+		Return(planet.Page{}, errors.New("find page error"))
+
+	_, err := refresher.Refresh(context.Background())
+	assert.NotNil(t, err)
+	assert.Equal(t, "find page error", err.Error())
+}
+
+func TestRefresherTrigger(t *testing.T) {
+	dbRepo := &mocks.DbRepository{}
+
+	refresher := planet.NewRefresher(dbRepo, nil, planet.RefresherOptions{}, zerolog.Nop())
+
+	release := make(chan struct{})
+	dbRepo.
+		On("FindPage", mock.Anything, mock.AnythingOfType("planet.PageRequest")).
+		Return(func(ctx context.Context, req planet.PageRequest) planet.Page {
+			<-release
+			return planet.Page{}
+		}, nil)
+
+	// Testing trigger while another refresh is running
+	err := refresher.TriggerRefresh()
+	assert.Nil(t, err)
+
+	err = refresher.TriggerRefresh()
+	assert.Equal(t, domain.ErrConflict, err)
+
+	_, err = refresher.Refresh(context.Background())
+	assert.Equal(t, domain.ErrConflict, err)
+
+	close(release)
+	refresher.Stop()
+
+	// Testing trigger after the refresh finishes
+	err = refresher.TriggerRefresh()
+	assert.Nil(t, err)
+	refresher.Stop()
+}
+
+func TestRefresherSchedule(t *testing.T) {
+	dbRepo := &mocks.DbRepository{}
+
+	refresher := planet.NewRefresher(dbRepo, nil, planet.RefresherOptions{Interval: 5 * time.Millisecond}, zerolog.Nop())
+
+	called := make(chan struct{}, 10)
+	dbRepo.
+		On("FindPage", mock.Anything, mock.AnythingOfType("planet.PageRequest")).
+		Return(func(ctx context.Context, req planet.PageRequest) planet.Page {
+			called <- struct{}{}
+			return planet.Page{}
+		}, nil)
+
+	refresher.Start()
+
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		t.Fatal("scheduled refresh did not run")
+	}
+
+	refresher.Stop()
+}
+
+func TestRefresherStopCancelsRefresh(t *testing.T) {
+	dbRepo := &mocks.DbRepository{}
+	swapiRepo := &mocks.SwapiRepository{}
+
+	refresher := planet.NewRefresher(dbRepo, swapiRepo, planet.RefresherOptions{}, zerolog.Nop())
+
+	p := planet.Planet{ID: primitive.NewObjectID(), Name: "Slow"}
+
+	dbRepo.
+		On("FindPage", mock.Anything, mock.AnythingOfType("planet.PageRequest")).
+		Return(planet.Page{Planets: []planet.Planet{p}}, nil)
+
+	started := make(chan struct{})
+	swapiRepo.
+		On("GetPlanetFilms", mock.Anything, p.Name).
+		Run(func(args mock.Arguments) {
+			close(started)
+			<-args.Get(0).(context.Context).Done()
+		}).
+		Return(nil, context.Canceled)
+
+	err := refresher.TriggerRefresh()
+	assert.Nil(t, err)
+	<-started
+
+	// Testing Stop cancels the SWAPI call in progress instead of waiting for it
+	stopped := make(chan struct{})
+	go func() {
+		refresher.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("refresher did not stop")
+	}
+
+	dbRepo.AssertNotCalled(t, "UpdateFilms", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -4,23 +4,35 @@ import (
 	"b2w/swapi-challenge/config"
 	"b2w/swapi-challenge/domain"
 	"b2w/swapi-challenge/infra/database"
+	"b2w/swapi-challenge/infra/logger"
+	"b2w/swapi-challenge/infra/metrics"
 	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/rs/zerolog"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Collation usada para comparar nomes sem diferenciar maiúsculas e minúsculas
+var nameCollation = &options.Collation{Locale: "en", Strength: 2}
+
 type mongoRepo struct {
 	db             database.DatabaseHelper
 	commandTimeout time.Duration
+	log            zerolog.Logger
 }
 
 func (r mongoRepo) CollectionName() string { return "planets" }
 
-func NewMongoRepository(db database.DatabaseHelper) *mongoRepo {
+func NewMongoRepository(db database.DatabaseHelper, log zerolog.Logger) *mongoRepo {
 	timeout := config.Data.Database.CommandTimeout
 	if timeout == 0 {
 		timeout = 15 * time.Second
@@ -29,18 +41,157 @@ func NewMongoRepository(db database.DatabaseHelper) *mongoRepo {
 	return &mongoRepo{
 		db:             db,
 		commandTimeout: timeout,
+		log:            log.With().Str("component", "mongo_repository").Logger(),
 	}
 }
 
-func (r *mongoRepo) Insert(p *Planet) error {
+// legacyNameIndex é o índice único de nomes das versões anteriores, que
+// também incluía os planetas da lixeira
+const legacyNameIndex = "name_unique"
+
+// EnsureIndexes cria o índice único de nomes, garantindo que inserções
+// concorrentes de um mesmo planeta não sejam aceitas. Os planetas da lixeira
+// ficam fora do índice, então um nome removido pode ser usado novamente.
+func (r *mongoRepo) EnsureIndexes(ctx context.Context) error {
 	collection := r.db.Collection(r.CollectionName())
 
-	ctx, cancel := context.WithTimeout(context.Background(), r.commandTimeout)
+	ctx, cancel := context.WithTimeout(ctx, r.commandTimeout)
+	defer cancel()
+
+	// O MongoDB não aceita dois índices com as mesmas chaves e filtros diferentes
+	err := collection.DropIndex(ctx, legacyNameIndex)
+	if err != nil && !database.IsIndexNotFoundError(err) {
+		return err
+	}
+
+	_, err = collection.CreateIndex(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().
+			SetName("name_unique_live").
+			SetUnique(true).
+			SetCollation(nameCollation).
+			SetPartialFilterExpression(notDeleted(bson.M{})),
+	})
+
+	return err
+}
+
+// NameCollision reúne os planetas salvos cujos nomes só diferem por maiúsculas,
+// minúsculas ou espaços extras, o que impede a criação do índice único de nomes
+type NameCollision struct {
+	Name      string
+	PlanetIDs []primitive.ObjectID
+	Names     []string
+}
+
+// MigrateNames prepara os planetas salvos antes da versão com nomes únicos para o
+// índice criado por EnsureIndexes, removendo os espaços extras dos nomes. Os
+// planetas fora da lixeira cujos nomes colidem não são alterados e são
+// retornados para que sejam renomeados ou removidos manualmente.
+func (r *mongoRepo) MigrateNames(ctx context.Context) ([]NameCollision, error) {
+	collection := r.db.Collection(r.CollectionName())
+
+	findCtx, cancel := context.WithTimeout(ctx, r.commandTimeout)
+	defer cancel()
+
+	opts := options.Find().
+		SetProjection(bson.M{"name": 1, "deleted_at": 1}).
+		SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := collection.Find(findCtx, bson.M{}, opts)
+	if err != nil {
+		return nil, r.fail(ctx, "migrate_names", err)
+	}
+	defer cursor.Close(findCtx)
+
+	var planets []Planet
+	if err = cursor.All(findCtx, &planets); err != nil {
+		return nil, r.fail(ctx, "migrate_names", err)
+	}
+
+	var keys []string
+	groups := make(map[string][]Planet)
+	for _, p := range planets {
+		key := strings.ToLower(NormalizeName(p.Name))
+		if p.DeletedAt != nil {
+			// Os planetas da lixeira ficam fora do índice e não colidem
+			key = p.ID.Hex()
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], p)
+	}
+
+	var collisions []NameCollision
+	normalized := 0
+	for _, key := range keys {
+		group := groups[key]
+		if len(group) > 1 {
+			collisions = append(collisions, newNameCollision(group))
+			continue
+		}
+
+		p := group[0]
+		name := NormalizeName(p.Name)
+		if name == p.Name {
+			continue
+		}
+
+		err := r.rename(ctx, p.ID, name)
+		if database.IsDuplicateKeyError(err) {
+			// O índice já existe e a collation considera outro nome igual a este
+			collisions = append(collisions, newNameCollision(group))
+			continue
+		}
+		if err != nil {
+			return collisions, r.fail(ctx, "migrate_names", err)
+		}
+		normalized++
+	}
+
+	r.log.Info().
+		Int("checked", len(planets)).
+		Int("normalized", normalized).
+		Int("collisions", len(collisions)).
+		Msg("planet names migrated")
+
+	return collisions, nil
+}
+
+func (r *mongoRepo) rename(ctx context.Context, id primitive.ObjectID, name string) error {
+	collection := r.db.Collection(r.CollectionName())
+
+	ctx, cancel := context.WithTimeout(ctx, r.commandTimeout)
+	defer cancel()
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"name": name}})
+	return err
+}
+
+func newNameCollision(group []Planet) NameCollision {
+	collision := NameCollision{Name: NormalizeName(group[0].Name)}
+	for _, p := range group {
+		collision.PlanetIDs = append(collision.PlanetIDs, p.ID)
+		collision.Names = append(collision.Names, p.Name)
+	}
+
+	return collision
+}
+
+func (r *mongoRepo) Insert(ctx context.Context, p *Planet) error {
+	defer observeMongo("insert", time.Now())
+
+	collection := r.db.Collection(r.CollectionName())
+
+	ctx, cancel := context.WithTimeout(ctx, r.commandTimeout)
 	defer cancel()
 
 	res, err := collection.InsertOne(ctx, p)
 	if err != nil {
-		return err
+		if database.IsDuplicateKeyError(err) {
+			return domain.ErrConflict
+		}
+		return r.fail(ctx, "insert", err)
 	}
 
 	p.ID = res.InsertedID.(primitive.ObjectID)
@@ -48,60 +199,459 @@ func (r *mongoRepo) Insert(p *Planet) error {
 	return nil
 }
 
-func (r *mongoRepo) FindAll() ([]Planet, error) {
+// InsertMany insere os planetas em uma única operação não ordenada, de forma
+// que a falha de um planeta não impede os demais. Retorna o erro de cada
+// planeta, na mesma ordem; os IDs já devem estar preenchidos.
+func (r *mongoRepo) InsertMany(ctx context.Context, planets []*Planet) []error {
+	defer observeMongo("insert_many", time.Now())
+
+	errs := make([]error, len(planets))
+	if len(planets) == 0 {
+		return errs
+	}
+
+	collection := r.db.Collection(r.CollectionName())
+
+	ctx, cancel := context.WithTimeout(ctx, r.commandTimeout)
+	defer cancel()
+
+	docs := make([]interface{}, 0, len(planets))
+	for _, p := range planets {
+		docs = append(docs, p)
+	}
+
+	_, err := collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err == nil {
+		return errs
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil {
+		for _, writeErr := range bulkErr.WriteErrors {
+			if writeErr.Index < 0 || writeErr.Index >= len(errs) {
+				continue
+			}
+			if database.IsDuplicateKeyCode(writeErr.Code) {
+				errs[writeErr.Index] = domain.ErrConflict
+			} else {
+				errs[writeErr.Index] = r.fail(ctx, "insert_many", writeErr)
+			}
+		}
+		return errs
+	}
+
+	err = r.fail(ctx, "insert_many", err)
+	for i := range errs {
+		errs[i] = err
+	}
+
+	return errs
+}
+
+func (r *mongoRepo) FindPage(ctx context.Context, req PageRequest) (Page, error) {
+	defer observeMongo("find_page", time.Now())
+
+	return r.findPage(ctx, req, notDeleted)
+}
+
+func (r *mongoRepo) FindTrash(ctx context.Context, req PageRequest) (Page, error) {
+	defer observeMongo("find_trash", time.Now())
+
+	return r.findPage(ctx, req, deleted)
+}
+
+func (r *mongoRepo) findPage(ctx context.Context, req PageRequest, scope func(bson.M) bson.M) (Page, error) {
 	collection := r.db.Collection(r.CollectionName())
 
-	ctx, cancel := context.WithTimeout(context.Background(), r.commandTimeout)
+	ctx, cancel := context.WithTimeout(ctx, r.commandTimeout)
 	defer cancel()
 
-	cursor, err := collection.Find(ctx, bson.M{})
+	filter := scope(queryFilter(req.Query))
+	if !req.After.IsZero() {
+		if !req.After.matches(req.Query.Sort) {
+			return Page{}, invalidListing(domain.FieldError{Field: "cursor", Message: "does not match the sort"})
+		}
+		if len(req.Query.Sort) == 0 {
+			filter["_id"] = bson.M{"$gt": req.After.ID}
+		} else {
+			filter["$or"] = keysetFilter(req.Query.Sort, req.After)
+		}
+	}
+
+	// Buscando um item a mais para saber se existe uma próxima página
+	opts := options.Find().
+		SetSort(querySort(req.Query.Sort)).
+		SetLimit(req.Limit + 1)
+
+	cursor, err := collection.Find(ctx, filter, opts)
 	if err != nil {
-		return nil, err
+		return Page{}, r.fail(ctx, "find_page", err)
 	}
 	defer cursor.Close(ctx)
 
 	var result []Planet
 	if err = cursor.All(ctx, &result); err != nil {
-		return result, err
+		return Page{}, r.fail(ctx, "find_page", err)
 	}
 
-	return result, nil
+	page := Page{Planets: result}
+	if int64(len(result)) > req.Limit {
+		page.Planets = result[:req.Limit]
+		page.NextCursor = NewCursor(page.Planets[req.Limit-1], req.Query.Sort)
+		page.HasMore = true
+	}
+
+	return page, nil
+}
+
+// fail registra os erros inesperados do banco antes de repassá-los
+func (r *mongoRepo) fail(ctx context.Context, operation string, err error) error {
+	logger.Ctx(ctx, r.log).Error().Err(err).Str("operation", operation).Msg("mongo operation failed")
+	return err
 }
 
-func (r *mongoRepo) GetById(id primitive.ObjectID) (Planet, error) {
-	return r.findOne(bson.M{"_id": id})
+// observeMongo registra a duração de uma operação do repositório
+func observeMongo(operation string, start time.Time) {
+	metrics.Since(metrics.MongoOperationDuration.WithLabelValues(operation), start)
 }
 
-func (r *mongoRepo) GetByName(name string) (Planet, error) {
-	return r.findOne(bson.M{"name": name})
+// notDeleted restringe o filtro aos planetas que não estão na lixeira
+func notDeleted(filter bson.M) bson.M {
+	filter["deleted_at"] = nil
+	return filter
+}
+
+// deleted restringe o filtro aos planetas que estão na lixeira
+func deleted(filter bson.M) bson.M {
+	filter["deleted_at"] = bson.M{"$ne": nil}
+	return filter
+}
+
+func queryFilter(q Query) bson.M {
+	filter := bson.M{}
+	if q.NameContains != "" {
+		filter["name"] = primitive.Regex{Pattern: regexp.QuoteMeta(q.NameContains), Options: "i"}
+	}
+	if q.Climate != "" {
+		filter["climate"] = listItemRegex(q.Climate)
+	}
+	if q.Terrain != "" {
+		filter["terrain"] = listItemRegex(q.Terrain)
+	}
+
+	apparitions := bson.M{}
+	if q.ApparitionsGte != nil {
+		apparitions["$gte"] = *q.ApparitionsGte
+	}
+	if q.ApparitionsLte != nil {
+		apparitions["$lte"] = *q.ApparitionsLte
+	}
+	if len(apparitions) > 0 {
+		filter["apparitions"] = apparitions
+	}
+
+	return filter
+}
+
+// Clima e terreno são listas separadas por vírgula, como "grasslands, mountains"
+func listItemRegex(item string) primitive.Regex {
+	pattern := fmt.Sprintf(`(^|,)\s*%s\s*(,|$)`, regexp.QuoteMeta(strings.TrimSpace(item)))
+	return primitive.Regex{Pattern: pattern, Options: "i"}
+}
+
+func querySort(sort []SortField) bson.D {
+	result := bson.D{}
+	for _, s := range sort {
+		result = append(result, bson.E{Key: s.Field, Value: sortDirection(s.Desc)})
+	}
+
+	// O ID garante uma ordem estável entre planetas com os mesmos valores
+	return append(result, bson.E{Key: "_id", Value: 1})
+}
+
+// keysetFilter seleciona os planetas que vêm depois do cursor na ordenação
+func keysetFilter(sort []SortField, after Cursor) []bson.M {
+	var conditions []bson.M
+	equals := bson.M{}
+	for i, s := range sort {
+		op := "$gt"
+		if s.Desc {
+			op = "$lt"
+		}
+
+		condition := bson.M{s.Field: bson.M{op: after.Values[i]}}
+		for key, value := range equals {
+			condition[key] = value
+		}
+		conditions = append(conditions, condition)
+
+		equals[s.Field] = after.Values[i]
+	}
+
+	condition := bson.M{"_id": bson.M{"$gt": after.ID}}
+	for key, value := range equals {
+		condition[key] = value
+	}
+
+	return append(conditions, condition)
 }
 
-func (r *mongoRepo) findOne(filter bson.M) (Planet, error) {
+func sortDirection(desc bool) int {
+	if desc {
+		return -1
+	}
+	return 1
+}
+
+func sortValue(p Planet, field string) interface{} {
+	switch field {
+	case FieldName:
+		return p.Name
+	case FieldClimate:
+		return p.Climate
+	case FieldTerrain:
+		return p.Terrain
+	case FieldApparitions:
+		return p.Apparitions
+	}
+	return nil
+}
+
+// Count retorna a quantidade de planetas salvos, sem contar os que estão na lixeira
+func (r *mongoRepo) Count(ctx context.Context) (int64, error) {
+	defer observeMongo("count", time.Now())
+
 	collection := r.db.Collection(r.CollectionName())
 
-	ctx, cancel := context.WithTimeout(context.Background(), r.commandTimeout)
+	ctx, cancel := context.WithTimeout(ctx, r.commandTimeout)
+	defer cancel()
+
+	return collection.CountDocuments(ctx, notDeleted(bson.M{}))
+}
+
+func (r *mongoRepo) GetById(ctx context.Context, id primitive.ObjectID) (Planet, error) {
+	defer observeMongo("get_by_id", time.Now())
+
+	return r.findOne(ctx, notDeleted(bson.M{"_id": id}))
+}
+
+func (r *mongoRepo) GetByName(ctx context.Context, name string) (Planet, error) {
+	defer observeMongo("get_by_name", time.Now())
+
+	return r.findOne(ctx, notDeleted(bson.M{"name": name}), options.FindOne().SetCollation(nameCollation))
+}
+
+// GetDeletedByName busca o planeta de mesmo nome que está na lixeira, o mais
+// recente quando houver mais de um
+func (r *mongoRepo) GetDeletedByName(ctx context.Context, name string) (Planet, error) {
+	defer observeMongo("get_deleted_by_name", time.Now())
+
+	opts := options.FindOne().
+		SetCollation(nameCollation).
+		SetSort(bson.D{{Key: "deleted_at", Value: -1}})
+	return r.findOne(ctx, deleted(bson.M{"name": name}), opts)
+}
+
+func (r *mongoRepo) findOne(ctx context.Context, filter bson.M, opts ...*options.FindOneOptions) (Planet, error) {
+	collection := r.db.Collection(r.CollectionName())
+
+	ctx, cancel := context.WithTimeout(ctx, r.commandTimeout)
 	defer cancel()
 
 	var result Planet
-	if err := collection.FindOne(ctx, filter).Decode(&result); err != nil {
+	if err := collection.FindOne(ctx, filter, opts...).Decode(&result); err != nil {
 		if err == mongo.ErrNoDocuments {
 			return result, domain.ErrNotFound
 		}
-		return result, err
+		return result, r.fail(ctx, "find_one", err)
 	}
 
 	return result, nil
 }
 
-func (r *mongoRepo) Delete(id primitive.ObjectID) error {
+func (r *mongoRepo) Update(ctx context.Context, p *Planet) error {
+	defer observeMongo("update", time.Now())
+
 	collection := r.db.Collection(r.CollectionName())
 
-	ctx, cancel := context.WithTimeout(context.Background(), r.commandTimeout)
+	ctx, cancel := context.WithTimeout(ctx, r.commandTimeout)
 	defer cancel()
 
-	if _, err := collection.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
-		return err
+	res, err := collection.ReplaceOne(ctx, notDeleted(bson.M{"_id": p.ID}), p)
+	if err != nil {
+		if database.IsDuplicateKeyError(err) {
+			return domain.ErrConflict
+		}
+		return r.fail(ctx, "update", err)
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrNotFound
 	}
 
 	return nil
 }
+
+func (r *mongoRepo) UpdateFilms(ctx context.Context, id primitive.ObjectID, films []Film, updatedAt time.Time) error {
+	defer observeMongo("update_films", time.Now())
+
+	collection := r.db.Collection(r.CollectionName())
+
+	ctx, cancel := context.WithTimeout(ctx, r.commandTimeout)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{
+		"films":                  films,
+		"apparitions":            int32(len(films)),
+		"apparitions_updated_at": updatedAt,
+	}}
+
+	res, err := collection.UpdateOne(ctx, notDeleted(bson.M{"_id": id}), update)
+	if err != nil {
+		return r.fail(ctx, "update_films", err)
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete move o planeta para a lixeira. O nome continua reservado pelo índice
+// único até que o planeta seja removido definitivamente pelo Purge.
+func (r *mongoRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	defer observeMongo("delete", time.Now())
+
+	collection := r.db.Collection(r.CollectionName())
+
+	ctx, cancel := context.WithTimeout(ctx, r.commandTimeout)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"deleted_at": time.Now()}}
+
+	res, err := collection.UpdateOne(ctx, notDeleted(bson.M{"_id": id}), update)
+	if err != nil {
+		return r.fail(ctx, "delete", err)
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteMany move os planetas para a lixeira em uma única operação. Retorna o
+// erro de cada ID, na mesma ordem, com domain.ErrNotFound para os IDs que não
+// correspondem a planetas fora da lixeira.
+func (r *mongoRepo) DeleteMany(ctx context.Context, ids []primitive.ObjectID) []error {
+	defer observeMongo("delete_many", time.Now())
+
+	errs := make([]error, len(ids))
+	if len(ids) == 0 {
+		return errs
+	}
+
+	collection := r.db.Collection(r.CollectionName())
+
+	ctx, cancel := context.WithTimeout(ctx, r.commandTimeout)
+	defer cancel()
+
+	existing, err := r.existingIDs(ctx, ids)
+	if err != nil {
+		err = r.fail(ctx, "delete_many", err)
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	found := make([]primitive.ObjectID, 0, len(existing))
+	for id := range existing {
+		found = append(found, id)
+	}
+	for i, id := range ids {
+		if !existing[id] {
+			errs[i] = domain.ErrNotFound
+		}
+	}
+	if len(found) == 0 {
+		return errs
+	}
+
+	update := bson.M{"$set": bson.M{"deleted_at": time.Now()}}
+	if _, err := collection.UpdateMany(ctx, notDeleted(bson.M{"_id": bson.M{"$in": found}}), update); err != nil {
+		err = r.fail(ctx, "delete_many", err)
+		for i, id := range ids {
+			if existing[id] {
+				errs[i] = err
+			}
+		}
+	}
+
+	return errs
+}
+
+// existingIDs retorna quais dos IDs correspondem a planetas fora da lixeira
+func (r *mongoRepo) existingIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	collection := r.db.Collection(r.CollectionName())
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	cursor, err := collection.Find(ctx, notDeleted(bson.M{"_id": bson.M{"$in": ids}}), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var found []Planet
+	if err = cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+
+	existing := make(map[primitive.ObjectID]bool, len(found))
+	for _, p := range found {
+		existing[p.ID] = true
+	}
+
+	return existing, nil
+}
+
+func (r *mongoRepo) Restore(ctx context.Context, id primitive.ObjectID) error {
+	defer observeMongo("restore", time.Now())
+
+	collection := r.db.Collection(r.CollectionName())
+
+	ctx, cancel := context.WithTimeout(ctx, r.commandTimeout)
+	defer cancel()
+
+	update := bson.M{"$unset": bson.M{"deleted_at": ""}}
+
+	res, err := collection.UpdateOne(ctx, deleted(bson.M{"_id": id}), update)
+	if err != nil {
+		// Outro planeta passou a usar o nome enquanto este estava na lixeira
+		if database.IsDuplicateKeyError(err) {
+			return domain.ErrConflict
+		}
+		return r.fail(ctx, "restore", err)
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// Purge remove definitivamente os planetas que estão na lixeira desde antes de deletedBefore
+func (r *mongoRepo) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	defer observeMongo("purge", time.Now())
+
+	collection := r.db.Collection(r.CollectionName())
+
+	ctx, cancel := context.WithTimeout(ctx, r.commandTimeout)
+	defer cancel()
+
+	res, err := collection.DeleteMany(ctx, bson.M{"deleted_at": bson.M{"$lte": deletedBefore}})
+	if err != nil {
+		return 0, r.fail(ctx, "purge", err)
+	}
+
+	return res.DeletedCount, nil
+}
@@ -7,11 +7,14 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -20,7 +23,7 @@ func TestRepoInsert(t *testing.T) {
 	dbHelper := &mocks.DatabaseHelper{}
 	collectionHelper := &mocks.CollectionHelper{}
 
-	dbRepo := planet.NewMongoRepository(dbHelper)
+	dbRepo := planet.NewMongoRepository(dbHelper, zerolog.Nop())
 
 	pID := primitive.NewObjectID()
 
@@ -40,105 +43,22 @@ func TestRepoInsert(t *testing.T) {
 		Return(collectionHelper)
 
 	// Testing insertion success
-	err := dbRepo.Insert(pSuccess)
+	err := dbRepo.Insert(context.Background(), pSuccess)
 	assert.Nil(t, err)
 	assert.Equal(t, pID, pSuccess.ID)
 
 	// Testing insertion error
-	err = dbRepo.Insert(pError)
+	err = dbRepo.Insert(context.Background(), pError)
 	assert.NotNil(t, err)
 	assert.Equal(t, "insert error", err.Error())
 }
 
-func TestRepoFindAll(t *testing.T) {
-	// Testing find and cursor success
-	dbHelper := &mocks.DatabaseHelper{}
-	collectionHelper := &mocks.CollectionHelper{}
-	cursorHelper := &mocks.CursorHelper{}
-	dbRepo := planet.NewMongoRepository(dbHelper)
-
-	pOne := planet.Planet{Name: "One"}
-	pTwo := planet.Planet{Name: "Two"}
-	pThree := planet.Planet{Name: "Two"}
-
-	cursorHelper.
-		On("Close", mock.Anything).
-		Return(nil)
-
-	cursorHelper.
-		On("All", mock.Anything, mock.AnythingOfType("*[]planet.Planet")).
-		Return(func(ctx context.Context, v interface{}) error {
-			list := v.(*[]planet.Planet)
-			*list = append(*list, pOne)
-			*list = append(*list, pTwo)
-			*list = append(*list, pThree)
-			return nil
-		})
-
-	collectionHelper.
-		On("Find", mock.Anything, bson.M{}).
-		Return(cursorHelper, nil)
-
-	dbHelper.
-		On("Collection", dbRepo.CollectionName()).
-		Return(collectionHelper)
-
-	result, err := dbRepo.FindAll()
-	assert.Nil(t, err)
-	assert.Equal(t, 3, len(result))
-
-	// Testing cursor error
-	dbHelperCursorErr := &mocks.DatabaseHelper{}
-	collectionHelperCursorErr := &mocks.CollectionHelper{}
-	cursorHelperErr := &mocks.CursorHelper{}
-	dbRepoCursorErr := planet.NewMongoRepository(dbHelperCursorErr)
-
-	cursorHelperErr.
-		On("Close", mock.Anything).
-		Return(nil)
-
-	cursorHelperErr.
-		On("All", mock.Anything, mock.AnythingOfType("*[]planet.Planet")).
-		Return(errors.New("cursor error"))
-
-	collectionHelperCursorErr.
-		On("Find", mock.Anything, bson.M{}).
-		Return(cursorHelperErr, nil)
-
-	dbHelperCursorErr.
-		On("Collection", dbRepo.CollectionName()).
-		Return(collectionHelperCursorErr)
-
-	result, err = dbRepoCursorErr.FindAll()
-	assert.NotNil(t, err)
-	assert.Equal(t, "cursor error", err.Error())
-	assert.Equal(t, 0, len(result))
-
-	// Testing find error
-	dbHelperFindErr := &mocks.DatabaseHelper{}
-	collectionHelperFindErr := &mocks.CollectionHelper{}
-	dbRepoFindErr := planet.NewMongoRepository(dbHelperFindErr)
-
-	collectionHelperFindErr.
-		On("Find", mock.Anything, bson.M{}).
-		Return(nil, errors.New("find error"))
-
-	dbHelperFindErr.
-		On("Collection", dbRepo.CollectionName()).
-		Return(collectionHelperFindErr)
-
-	result, err = dbRepoFindErr.FindAll()
-	assert.NotNil(t, err)
-	assert.Equal(t, "find error", err.Error())
-	assert.Equal(t, 0, len(result))
-}
-
 func TestRepoGetById(t *testing.T) {
 	// Testing find success
 	dbHelper := &mocks.DatabaseHelper{}
 	collectionHelper := &mocks.CollectionHelper{}
 	singleResultHelper := &mocks.SingleResultHelper{}
-	dbRepo := planet.NewMongoRepository(dbHelper)
+	dbRepo := planet.NewMongoRepository(dbHelper, zerolog.Nop())
 
 	pID := primitive.NewObjectID()
 	pIDNotFound := primitive.NewObjectID()
@@ -154,14 +74,14 @@ func TestRepoGetById(t *testing.T) {
 		})
 
 	collectionHelper.
-		On("FindOne", mock.Anything, bson.M{"_id": pID}).
+		On("FindOne", mock.Anything, bson.M{"_id": pID, "deleted_at": nil}).
 		Return(singleResultHelper)
 
 	dbHelper.
 		On("Collection", dbRepo.CollectionName()).
 		Return(collectionHelper)
 
-	result, err := dbRepo.GetById(pID)
+	result, err := dbRepo.GetById(context.Background(), pID)
 	assert.Nil(t, err)
 	assert.Equal(t, pID, result.ID)
 
@@ -173,10 +93,10 @@ func TestRepoGetById(t *testing.T) {
 		Return(mongo.ErrNoDocuments)
 
 	collectionHelper.
-		On("FindOne", mock.Anything, bson.M{"_id": pIDNotFound}).
+		On("FindOne", mock.Anything, bson.M{"_id": pIDNotFound, "deleted_at": nil}).
 		Return(singleResultHelperNotFoundErr)
 
-	result, err = dbRepo.GetById(pIDNotFound)
+	result, err = dbRepo.GetById(context.Background(), pIDNotFound)
 	assert.NotNil(t, err)
 	assert.Equal(t, domain.ErrNotFound, err)
 	assert.Equal(t, primitive.NilObjectID, result.ID)
@@ -189,21 +109,25 @@ func TestRepoGetById(t *testing.T) {
 		Return(errors.New("other decode error"))
 
 	collectionHelper.
-		On("FindOne", mock.Anything, bson.M{"_id": pIDOtherErr}).
+		On("FindOne", mock.Anything, bson.M{"_id": pIDOtherErr, "deleted_at": nil}).
 		Return(singleResultHelperOtherErr)
 
-	result, err = dbRepo.GetById(pIDOtherErr)
+	result, err = dbRepo.GetById(context.Background(), pIDOtherErr)
 	assert.NotNil(t, err)
 	assert.Equal(t, "other decode error", err.Error())
 	assert.Equal(t, primitive.NilObjectID, result.ID)
 }
 
 func TestRepoGetByName(t *testing.T) {
+	caseInsensitive := mock.MatchedBy(func(opts *options.FindOneOptions) bool {
+		return opts.Collation != nil && opts.Collation.Strength == 2
+	})
+
 	// Testing find success
 	dbHelper := &mocks.DatabaseHelper{}
 	collectionHelper := &mocks.CollectionHelper{}
 	singleResultHelper := &mocks.SingleResultHelper{}
-	dbRepo := planet.NewMongoRepository(dbHelper)
+	dbRepo := planet.NewMongoRepository(dbHelper, zerolog.Nop())
 
 	pName := "One"
 	pNameNotFound := "Not Found"
@@ -219,14 +143,14 @@ func TestRepoGetByName(t *testing.T) {
 		})
 
 	collectionHelper.
-		On("FindOne", mock.Anything, bson.M{"name": pName}).
+		On("FindOne", mock.Anything, bson.M{"name": pName, "deleted_at": nil}, caseInsensitive).
 		Return(singleResultHelper)
 
 	dbHelper.
 		On("Collection", dbRepo.CollectionName()).
 		Return(collectionHelper)
 
-	result, err := dbRepo.GetByName(pName)
+	result, err := dbRepo.GetByName(context.Background(), pName)
 	assert.Nil(t, err)
 	assert.Equal(t, pName, result.Name)
 
@@ -238,10 +162,10 @@ func TestRepoGetByName(t *testing.T) {
 		Return(mongo.ErrNoDocuments)
 
 	collectionHelper.
-		On("FindOne", mock.Anything, bson.M{"name": pNameNotFound}).
+		On("FindOne", mock.Anything, bson.M{"name": pNameNotFound, "deleted_at": nil}, caseInsensitive).
 		Return(singleResultHelperNotFoundErr)
 
-	result, err = dbRepo.GetByName(pNameNotFound)
+	result, err = dbRepo.GetByName(context.Background(), pNameNotFound)
 	assert.NotNil(t, err)
 	assert.Equal(t, domain.ErrNotFound, err)
 	assert.Equal(t, primitive.NilObjectID, result.ID)
@@ -254,10 +178,10 @@ func TestRepoGetByName(t *testing.T) {
 		Return(errors.New("other decode error"))
 
 	collectionHelper.
-		On("FindOne", mock.Anything, bson.M{"name": pNameOtherErr}).
+		On("FindOne", mock.Anything, bson.M{"name": pNameOtherErr, "deleted_at": nil}, caseInsensitive).
 		Return(singleResultHelperOtherErr)
 
-	result, err = dbRepo.GetByName(pNameOtherErr)
+	result, err = dbRepo.GetByName(context.Background(), pNameOtherErr)
 	assert.NotNil(t, err)
 	assert.Equal(t, "other decode error", err.Error())
 	assert.Equal(t, primitive.NilObjectID, result.ID)
@@ -267,17 +191,27 @@ func TestRepoDelete(t *testing.T) {
 	dbHelper := &mocks.DatabaseHelper{}
 	collectionHelper := &mocks.CollectionHelper{}
 
-	dbRepo := planet.NewMongoRepository(dbHelper)
+	dbRepo := planet.NewMongoRepository(dbHelper, zerolog.Nop())
 
 	pID := primitive.NewObjectID()
+	pIDNotFound := primitive.NewObjectID()
 	pIDErr := primitive.NewObjectID()
 
+	setsDeletedAt := mock.MatchedBy(func(update bson.M) bool {
+		_, ok := update["$set"].(bson.M)["deleted_at"].(time.Time)
+		return ok
+	})
+
+	collectionHelper.
+		On("UpdateOne", mock.Anything, bson.M{"_id": pID, "deleted_at": nil}, setsDeletedAt).
+		Return(&mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil)
+
 	collectionHelper.
-		On("DeleteOne", mock.Anything, bson.M{"_id": pID}).
-		Return(&mongo.DeleteResult{DeletedCount: 1}, nil)
+		On("UpdateOne", mock.Anything, bson.M{"_id": pIDNotFound, "deleted_at": nil}, setsDeletedAt).
+		Return(&mongo.UpdateResult{}, nil)
 
 	collectionHelper.
-		On("DeleteOne", mock.Anything, bson.M{"_id": pIDErr}).
+		On("UpdateOne", mock.Anything, bson.M{"_id": pIDErr, "deleted_at": nil}, setsDeletedAt).
 		Return(nil, errors.New("delete error"))
 
 	dbHelper.
@@ -285,11 +219,708 @@ func TestRepoDelete(t *testing.T) {
 		Return(collectionHelper)
 
 	// Testing deletion success
-	err := dbRepo.Delete(pID)
+	err := dbRepo.Delete(context.Background(), pID)
 	assert.Nil(t, err)
 
+	// Testing deletion of a missing or already deleted planet
+	err = dbRepo.Delete(context.Background(), pIDNotFound)
+	assert.Equal(t, domain.ErrNotFound, err)
+
 	// Testing deletion error
-	err = dbRepo.Delete(pIDErr)
+	err = dbRepo.Delete(context.Background(), pIDErr)
 	assert.NotNil(t, err)
 	assert.Equal(t, "delete error", err.Error())
+
+	collectionHelper.AssertNotCalled(t, "DeleteOne", mock.Anything, mock.Anything)
+}
+
+func TestRepoRestore(t *testing.T) {
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	dbRepo := planet.NewMongoRepository(dbHelper, zerolog.Nop())
+
+	pID := primitive.NewObjectID()
+	pIDNotFound := primitive.NewObjectID()
+
+	update := bson.M{"$unset": bson.M{"deleted_at": ""}}
+
+	collectionHelper.
+		On("UpdateOne", mock.Anything, bson.M{"_id": pID, "deleted_at": bson.M{"$ne": nil}}, update).
+		Return(&mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil)
+
+	collectionHelper.
+		On("UpdateOne", mock.Anything, bson.M{"_id": pIDNotFound, "deleted_at": bson.M{"$ne": nil}}, update).
+		Return(&mongo.UpdateResult{}, nil)
+
+	dbHelper.
+		On("Collection", dbRepo.CollectionName()).
+		Return(collectionHelper)
+
+	// Testing restore success
+	err := dbRepo.Restore(context.Background(), pID)
+	assert.Nil(t, err)
+
+	// Testing planet not in the trash
+	err = dbRepo.Restore(context.Background(), pIDNotFound)
+	assert.Equal(t, domain.ErrNotFound, err)
+}
+
+func TestRepoRestoreNameTaken(t *testing.T) {
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	dbRepo := planet.NewMongoRepository(dbHelper, zerolog.Nop())
+
+	pID := primitive.NewObjectID()
+	pNew := &planet.Planet{ID: primitive.NewObjectID(), Name: "Tatooine"}
+	duplicateErr := mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000, Message: "duplicate key"}}}
+
+	collectionHelper.
+		On("UpdateOne", mock.Anything, bson.M{"_id": pID, "deleted_at": nil}, mock.Anything).
+		Return(&mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil)
+
+	collectionHelper.
+		On("InsertOne", mock.Anything, pNew).
+		Return(&mongo.InsertOneResult{InsertedID: pNew.ID}, nil)
+
+	collectionHelper.
+		On("UpdateOne", mock.Anything, bson.M{"_id": pID, "deleted_at": bson.M{"$ne": nil}}, bson.M{"$unset": bson.M{"deleted_at": ""}}).
+		Return(nil, duplicateErr)
+
+	dbHelper.
+		On("Collection", dbRepo.CollectionName()).
+		Return(collectionHelper)
+
+	// Testing the name of a deleted planet is free for a new planet
+	err := dbRepo.Delete(context.Background(), pID)
+	assert.Nil(t, err)
+
+	err = dbRepo.Insert(context.Background(), pNew)
+	assert.Nil(t, err)
+
+	// Testing restore refused while the name is used by the new planet
+	err = dbRepo.Restore(context.Background(), pID)
+	assert.Equal(t, domain.ErrConflict, err)
+}
+
+func TestRepoPurge(t *testing.T) {
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	dbRepo := planet.NewMongoRepository(dbHelper, zerolog.Nop())
+
+	deletedBefore := time.Now().Add(-time.Hour)
+	deletedBeforeErr := deletedBefore.Add(-time.Hour)
+
+	collectionHelper.
+		On("DeleteMany", mock.Anything, bson.M{"deleted_at": bson.M{"$lte": deletedBefore}}).
+		Return(&mongo.DeleteResult{DeletedCount: 3}, nil)
+
+	collectionHelper.
+		On("DeleteMany", mock.Anything, bson.M{"deleted_at": bson.M{"$lte": deletedBeforeErr}}).
+		Return(nil, errors.New("purge error"))
+
+	dbHelper.
+		On("Collection", dbRepo.CollectionName()).
+		Return(collectionHelper)
+
+	// Testing purge success
+	purged, err := dbRepo.Purge(context.Background(), deletedBefore)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), purged)
+
+	// Testing purge error
+	_, err = dbRepo.Purge(context.Background(), deletedBeforeErr)
+	assert.NotNil(t, err)
+}
+
+func TestRepoFindTrash(t *testing.T) {
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+	cursorHelper := &mocks.CursorHelper{}
+
+	dbRepo := planet.NewMongoRepository(dbHelper, zerolog.Nop())
+
+	after := primitive.NewObjectID()
+
+	collectionHelper.
+		On("Find", mock.Anything, bson.M{"_id": bson.M{"$gt": after}, "deleted_at": bson.M{"$ne": nil}}, mock.Anything).
+		Return(cursorHelper, nil)
+
+	cursorHelper.
+		On("Close", mock.Anything).
+		Return(nil)
+
+	cursorHelper.
+		On("All", mock.Anything, mock.AnythingOfType("*[]planet.Planet")).
+		Return(nil)
+
+	dbHelper.
+		On("Collection", dbRepo.CollectionName()).
+		Return(collectionHelper)
+
+	// Testing listing only deleted planets
+	page, err := dbRepo.FindTrash(context.Background(), planet.PageRequest{After: planet.Cursor{ID: after}, Limit: 10})
+	assert.Nil(t, err)
+	assert.False(t, page.HasMore)
+
+	collectionHelper.AssertExpectations(t)
+}
+
+func TestRepoUpdate(t *testing.T) {
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	dbRepo := planet.NewMongoRepository(dbHelper, zerolog.Nop())
+
+	pSuccess := &planet.Planet{ID: primitive.NewObjectID(), Name: "Success"}
+	pNotFound := &planet.Planet{ID: primitive.NewObjectID(), Name: "Not Found"}
+	pError := &planet.Planet{ID: primitive.NewObjectID(), Name: "Error"}
+
+	collectionHelper.
+		On("ReplaceOne", mock.Anything, bson.M{"_id": pSuccess.ID, "deleted_at": nil}, pSuccess).
+		Return(&mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil)
+
+	collectionHelper.
+		On("ReplaceOne", mock.Anything, bson.M{"_id": pNotFound.ID, "deleted_at": nil}, pNotFound).
+		Return(&mongo.UpdateResult{}, nil)
+
+	collectionHelper.
+		On("ReplaceOne", mock.Anything, bson.M{"_id": pError.ID, "deleted_at": nil}, pError).
+		Return(nil, errors.New("update error"))
+
+	dbHelper.
+		On("Collection", dbRepo.CollectionName()).
+		Return(collectionHelper)
+
+	// Testing update success
+	err := dbRepo.Update(context.Background(), pSuccess)
+	assert.Nil(t, err)
+
+	// Testing update not found
+	err = dbRepo.Update(context.Background(), pNotFound)
+	assert.NotNil(t, err)
+	assert.Equal(t, domain.ErrNotFound, err)
+
+	// Testing update error
+	err = dbRepo.Update(context.Background(), pError)
+	assert.NotNil(t, err)
+	assert.Equal(t, "update error", err.Error())
+}
+
+func TestRepoFindPage(t *testing.T) {
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+	cursorHelper := &mocks.CursorHelper{}
+	cursorHelperLast := &mocks.CursorHelper{}
+	dbRepo := planet.NewMongoRepository(dbHelper, zerolog.Nop())
+
+	pOne := planet.Planet{ID: primitive.NewObjectID(), Name: "One"}
+	pTwo := planet.Planet{ID: primitive.NewObjectID(), Name: "Two"}
+	pThree := planet.Planet{ID: primitive.NewObjectID(), Name: "Three"}
+
+	cursorHelper.
+		On("Close", mock.Anything).
+		Return(nil)
+
+	cursorHelper.
+		On("All", mock.Anything, mock.AnythingOfType("*[]planet.Planet")).
+		Return(func(ctx context.Context, v interface{}) error {
+			list := v.(*[]planet.Planet)
+			*list = append(*list, pOne, pTwo, pThree)
+			return nil
+		})
+
+	cursorHelperLast.
+		On("Close", mock.Anything).
+		Return(nil)
+
+	cursorHelperLast.
+		On("All", mock.Anything, mock.AnythingOfType("*[]planet.Planet")).
+		Return(func(ctx context.Context, v interface{}) error {
+			list := v.(*[]planet.Planet)
+			*list = append(*list, pThree)
+			return nil
+		})
+
+	limitMatches := func(limit int64) interface{} {
+		return mock.MatchedBy(func(opts *options.FindOptions) bool {
+			return opts.Limit != nil && *opts.Limit == limit
+		})
+	}
+
+	collectionHelper.
+		On("Find", mock.Anything, bson.M{"deleted_at": nil}, limitMatches(3)).
+		Return(cursorHelper, nil)
+
+	collectionHelper.
+		On("Find", mock.Anything, bson.M{"_id": bson.M{"$gt": pTwo.ID}, "deleted_at": nil}, limitMatches(3)).
+		Return(cursorHelperLast, nil)
+
+	collectionHelper.
+		On("Find", mock.Anything, bson.M{"deleted_at": nil}, limitMatches(6)).
+		Return(nil, errors.New("find error"))
+
+	dbHelper.
+		On("Collection", dbRepo.CollectionName()).
+		Return(collectionHelper)
+
+	// Testing first page
+	page, err := dbRepo.FindPage(context.Background(), planet.PageRequest{Limit: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(page.Planets))
+	assert.Equal(t, planet.Cursor{ID: pTwo.ID}, page.NextCursor)
+	assert.True(t, page.HasMore)
+
+	// Testing last page
+	page, err = dbRepo.FindPage(context.Background(), planet.PageRequest{After: planet.Cursor{ID: pTwo.ID}, Limit: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(page.Planets))
+	assert.True(t, page.NextCursor.IsZero())
+	assert.False(t, page.HasMore)
+
+	// Testing find error
+	_, err = dbRepo.FindPage(context.Background(), planet.PageRequest{Limit: 5})
+	assert.NotNil(t, err)
+	assert.Equal(t, "find error", err.Error())
+}
+
+func TestRepoFindPageWithQuery(t *testing.T) {
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+	cursorHelper := &mocks.CursorHelper{}
+	dbRepo := planet.NewMongoRepository(dbHelper, zerolog.Nop())
+
+	gte := int32(1)
+	lte := int32(5)
+	last := planet.Planet{ID: primitive.NewObjectID(), Name: "Tatooine", Apparitions: 5}
+	next := planet.Planet{ID: primitive.NewObjectID(), Name: "Yavin IV", Apparitions: 5}
+
+	query := planet.Query{
+		NameContains:   "a.b",
+		Climate:        "arid",
+		ApparitionsGte: &gte,
+		ApparitionsLte: &lte,
+		Sort:           []planet.SortField{{Field: planet.FieldApparitions, Desc: true}, {Field: planet.FieldName}},
+	}
+
+	cursorHelper.
+		On("Close", mock.Anything).
+		Return(nil)
+
+	cursorHelper.
+		On("All", mock.Anything, mock.AnythingOfType("*[]planet.Planet")).
+		Return(func(ctx context.Context, v interface{}) error {
+			list := v.(*[]planet.Planet)
+			*list = append(*list, next, planet.Planet{ID: primitive.NewObjectID()})
+			return nil
+		})
+
+	filterMatches := mock.MatchedBy(func(filter bson.M) bool {
+		name := filter["name"].(primitive.Regex)
+		climate := filter["climate"].(primitive.Regex)
+		keyset := filter["$or"].([]bson.M)
+		deletedAt, scoped := filter["deleted_at"]
+
+		return scoped && deletedAt == nil &&
+			name.Pattern == `a\.b` && name.Options == "i" &&
+			climate.Pattern == `(^|,)\s*arid\s*(,|$)` &&
+			assert.ObjectsAreEqual(bson.M{"$gte": gte, "$lte": lte}, filter["apparitions"]) &&
+			len(keyset) == 3 &&
+			assert.ObjectsAreEqual(bson.M{"apparitions": bson.M{"$lt": int32(5)}}, keyset[0]) &&
+			assert.ObjectsAreEqual(bson.M{"apparitions": int32(5), "name": bson.M{"$gt": "Tatooine"}}, keyset[1]) &&
+			assert.ObjectsAreEqual(bson.M{"apparitions": int32(5), "name": "Tatooine", "_id": bson.M{"$gt": last.ID}}, keyset[2])
+	})
+
+	sortMatches := mock.MatchedBy(func(opts *options.FindOptions) bool {
+		return assert.ObjectsAreEqual(bson.D{
+			{Key: "apparitions", Value: -1},
+			{Key: "name", Value: 1},
+			{Key: "_id", Value: 1},
+		}, opts.Sort)
+	})
+
+	collectionHelper.
+		On("Find", mock.Anything, filterMatches, sortMatches).
+		Return(cursorHelper, nil)
+
+	dbHelper.
+		On("Collection", dbRepo.CollectionName()).
+		Return(collectionHelper)
+
+	// Testing filters, sort and cursor translation without reading the last planet again
+	after := planet.NewCursor(last, query.Sort)
+	page, err := dbRepo.FindPage(context.Background(), planet.PageRequest{Query: query, After: after, Limit: 1})
+	assert.Nil(t, err)
+	assert.True(t, page.HasMore)
+	assert.Equal(t, planet.NewCursor(next, query.Sort), page.NextCursor)
+	assert.Equal(t, []interface{}{int32(5), "Yavin IV"}, page.NextCursor.Values)
+	collectionHelper.AssertNotCalled(t, "FindOne", mock.Anything, mock.Anything)
+
+	// Testing cursor created with another sort
+	otherSort := planet.NewCursor(last, []planet.SortField{{Field: planet.FieldName}})
+	_, err = dbRepo.FindPage(context.Background(), planet.PageRequest{Query: query, After: otherSort, Limit: 1})
+	assert.ErrorIs(t, err, domain.ErrBadParamInput)
+
+	// Testing cursor with values of the wrong type
+	tampered := planet.Cursor{ID: last.ID, Sort: after.Sort, Values: []interface{}{bson.M{"$ne": nil}, "Tatooine"}}
+	_, err = dbRepo.FindPage(context.Background(), planet.PageRequest{Query: query, After: tampered, Limit: 1})
+	assert.ErrorIs(t, err, domain.ErrBadParamInput)
+	collectionHelper.AssertNumberOfCalls(t, "Find", 1)
+}
+
+func TestRepoInsertDuplicateKey(t *testing.T) {
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	dbRepo := planet.NewMongoRepository(dbHelper, zerolog.Nop())
+
+	pDuplicate := &planet.Planet{Name: "Duplicate"}
+	duplicateErr := mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000, Message: "duplicate key"}}}
+
+	collectionHelper.
+		On("InsertOne", mock.Anything, pDuplicate).
+		Return(nil, duplicateErr)
+
+	collectionHelper.
+		On("ReplaceOne", mock.Anything, bson.M{"_id": pDuplicate.ID, "deleted_at": nil}, pDuplicate).
+		Return(nil, duplicateErr)
+
+	dbHelper.
+		On("Collection", dbRepo.CollectionName()).
+		Return(collectionHelper)
+
+	// Testing duplicate key on insertion
+	err := dbRepo.Insert(context.Background(), pDuplicate)
+	assert.Equal(t, domain.ErrConflict, err)
+
+	// Testing duplicate key on update
+	err = dbRepo.Update(context.Background(), pDuplicate)
+	assert.Equal(t, domain.ErrConflict, err)
+}
+
+func TestRepoEnsureIndexes(t *testing.T) {
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	dbRepo := planet.NewMongoRepository(dbHelper, zerolog.Nop())
+
+	collectionHelper.
+		On("DropIndex", mock.Anything, "name_unique").
+		Return(mongo.CommandError{Code: 27, Message: "index not found with name [name_unique]"})
+
+	collectionHelper.
+		On("CreateIndex", mock.Anything, mock.MatchedBy(func(model mongo.IndexModel) bool {
+			return assert.ObjectsAreEqual(bson.D{{Key: "name", Value: 1}}, model.Keys) &&
+				model.Options.Unique != nil && *model.Options.Unique &&
+				model.Options.Collation != nil && model.Options.Collation.Strength == 2 &&
+				assert.ObjectsAreEqual(bson.M{"deleted_at": nil}, model.Options.PartialFilterExpression)
+		})).
+		Return("name_unique_live", nil)
+
+	dbHelper.
+		On("Collection", dbRepo.CollectionName()).
+		Return(collectionHelper)
+
+	// Testing the unique index leaves the trash out, without the legacy index
+	err := dbRepo.EnsureIndexes(context.Background())
+	assert.Nil(t, err)
+	collectionHelper.AssertExpectations(t)
+
+	// Testing error dropping the legacy index
+	collectionHelperErr := &mocks.CollectionHelper{}
+	dbHelperErr := &mocks.DatabaseHelper{}
+	dbRepoErr := planet.NewMongoRepository(dbHelperErr, zerolog.Nop())
+
+	collectionHelperErr.
+		On("DropIndex", mock.Anything, "name_unique").
+		Return(errors.New("drop error"))
+
+	dbHelperErr.
+		On("Collection", dbRepoErr.CollectionName()).
+		Return(collectionHelperErr)
+
+	err = dbRepoErr.EnsureIndexes(context.Background())
+	assert.Equal(t, "drop error", err.Error())
+	collectionHelperErr.AssertNotCalled(t, "CreateIndex", mock.Anything, mock.Anything)
+}
+
+func TestRepoMigrateNames(t *testing.T) {
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+	cursorHelper := &mocks.CursorHelper{}
+
+	dbRepo := planet.NewMongoRepository(dbHelper, zerolog.Nop())
+
+	pTatooine := planet.Planet{ID: primitive.NewObjectID(), Name: "Tatooine"}
+	pTatooineLower := planet.Planet{ID: primitive.NewObjectID(), Name: " tatooine "}
+	pHoth := planet.Planet{ID: primitive.NewObjectID(), Name: "  Hoth "}
+	pNaboo := planet.Planet{ID: primitive.NewObjectID(), Name: "Naboo"}
+	deletedAt := time.Now()
+	pNabooDeleted := planet.Planet{ID: primitive.NewObjectID(), Name: "naboo ", DeletedAt: &deletedAt}
+
+	collectionHelper.
+		On("Find", mock.Anything, bson.M{}, mock.Anything).
+		Return(cursorHelper, nil)
+
+	cursorHelper.
+		On("Close", mock.Anything).
+		Return(nil)
+
+	cursorHelper.
+		On("All", mock.Anything, mock.AnythingOfType("*[]planet.Planet")).
+		Return(func(ctx context.Context, v interface{}) error {
+			list := v.(*[]planet.Planet)
+			*list = append(*list, pTatooine, pHoth, pTatooineLower, pNaboo, pNabooDeleted)
+			return nil
+		})
+
+	collectionHelper.
+		On("UpdateOne", mock.Anything, bson.M{"_id": pHoth.ID}, bson.M{"$set": bson.M{"name": "Hoth"}}).
+		Return(&mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil)
+
+	collectionHelper.
+		On("UpdateOne", mock.Anything, bson.M{"_id": pNabooDeleted.ID}, bson.M{"$set": bson.M{"name": "naboo"}}).
+		Return(&mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil)
+
+	dbHelper.
+		On("Collection", dbRepo.CollectionName()).
+		Return(collectionHelper)
+
+	// Testing normalization of stored names and report of colliding names,
+	// ignoring the planets in the trash
+	collisions, err := dbRepo.MigrateNames(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []planet.NameCollision{{
+		Name:      "Tatooine",
+		PlanetIDs: []primitive.ObjectID{pTatooine.ID, pTatooineLower.ID},
+		Names:     []string{"Tatooine", " tatooine "},
+	}}, collisions)
+	collectionHelper.AssertNumberOfCalls(t, "UpdateOne", 2)
+
+	// Testing find error
+	dbHelperErr := &mocks.DatabaseHelper{}
+	collectionHelperErr := &mocks.CollectionHelper{}
+	dbRepoErr := planet.NewMongoRepository(dbHelperErr, zerolog.Nop())
+
+	collectionHelperErr.
+		On("Find", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, errors.New("find error"))
+
+	dbHelperErr.
+		On("Collection", dbRepoErr.CollectionName()).
+		Return(collectionHelperErr)
+
+	_, err = dbRepoErr.MigrateNames(context.Background())
+	assert.NotNil(t, err)
+	assert.Equal(t, "find error", err.Error())
+}
+
+func TestRepoUpdateFilms(t *testing.T) {
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	dbRepo := planet.NewMongoRepository(dbHelper, zerolog.Nop())
+
+	pID := primitive.NewObjectID()
+	pIDNotFound := primitive.NewObjectID()
+	updatedAt := time.Now()
+
+	films := []planet.Film{{Title: "A New Hope", EpisodeID: 4, ReleaseDate: "1977-05-25"}}
+	update := bson.M{"$set": bson.M{"films": films, "apparitions": int32(1), "apparitions_updated_at": updatedAt}}
+
+	collectionHelper.
+		On("UpdateOne", mock.Anything, bson.M{"_id": pID, "deleted_at": nil}, update).
+		Return(&mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil)
+
+	collectionHelper.
+		On("UpdateOne", mock.Anything, bson.M{"_id": pIDNotFound, "deleted_at": nil}, update).
+		Return(&mongo.UpdateResult{}, nil)
+
+	dbHelper.
+		On("Collection", dbRepo.CollectionName()).
+		Return(collectionHelper)
+
+	// Testing update success
+	err := dbRepo.UpdateFilms(context.Background(), pID, films, updatedAt)
+	assert.Nil(t, err)
+
+	// Testing planet not found
+	err = dbRepo.UpdateFilms(context.Background(), pIDNotFound, films, updatedAt)
+	assert.Equal(t, domain.ErrNotFound, err)
+}
+
+func TestRepoContextCancellation(t *testing.T) {
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+	singleResultHelper := &mocks.SingleResultHelper{}
+
+	dbRepo := planet.NewMongoRepository(dbHelper, zerolog.Nop())
+
+	pID := primitive.NewObjectID()
+
+	// O contexto repassado ao banco deve ser derivado do contexto do chamador
+	canceledCtx := mock.MatchedBy(func(ctx context.Context) bool {
+		_, hasDeadline := ctx.Deadline()
+		return hasDeadline && ctx.Err() == context.Canceled
+	})
+
+	collectionHelper.
+		On("FindOne", canceledCtx, bson.M{"_id": pID, "deleted_at": nil}).
+		Return(singleResultHelper)
+
+	singleResultHelper.
+		On("Decode", mock.AnythingOfType("*planet.Planet")).
+		Return(context.Canceled)
+
+	dbHelper.
+		On("Collection", dbRepo.CollectionName()).
+		Return(collectionHelper)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := dbRepo.GetById(ctx, pID)
+	assert.Equal(t, context.Canceled, err)
+
+	collectionHelper.AssertExpectations(t)
+}
+
+func TestRepoCount(t *testing.T) {
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	dbRepo := planet.NewMongoRepository(dbHelper, zerolog.Nop())
+
+	collectionHelper.
+		On("CountDocuments", mock.Anything, bson.M{"deleted_at": nil}).
+		Return(int64(7), nil)
+
+	dbHelper.
+		On("Collection", dbRepo.CollectionName()).
+		Return(collectionHelper)
+
+	// Testing count ignoring deleted planets
+	total, err := dbRepo.Count(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), total)
+}
+
+func TestRepoInsertMany(t *testing.T) {
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+
+	dbRepo := planet.NewMongoRepository(dbHelper, zerolog.Nop())
+
+	pOne := &planet.Planet{ID: primitive.NewObjectID(), Name: "One"}
+	pTwo := &planet.Planet{ID: primitive.NewObjectID(), Name: "Two"}
+	pThree := &planet.Planet{ID: primitive.NewObjectID(), Name: "Three"}
+
+	bulkErr := mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{
+		{WriteError: mongo.WriteError{Index: 1, Code: 11000, Message: "duplicate key"}},
+		{WriteError: mongo.WriteError{Index: 2, Code: 2, Message: "bad value"}},
+	}}
+
+	collectionHelper.
+		On("InsertMany", mock.Anything, []interface{}{pOne}, mock.Anything).
+		Return(&mongo.InsertManyResult{InsertedIDs: []interface{}{pOne.ID}}, nil)
+
+	collectionHelper.
+		On("InsertMany", mock.Anything, []interface{}{pOne, pTwo, pThree}, mock.Anything).
+		Return(nil, bulkErr)
+
+	collectionHelper.
+		On("InsertMany", mock.Anything, []interface{}{pTwo, pThree}, mock.Anything).
+		Return(nil, errors.New("insert error"))
+
+	dbHelper.
+		On("Collection", dbRepo.CollectionName()).
+		Return(collectionHelper)
+
+	// Testing insertion success
+	errs := dbRepo.InsertMany(context.Background(), []*planet.Planet{pOne})
+	assert.Equal(t, []error{nil}, errs)
+
+	// Testing per-item write errors
+	errs = dbRepo.InsertMany(context.Background(), []*planet.Planet{pOne, pTwo, pThree})
+	assert.Len(t, errs, 3)
+	assert.Nil(t, errs[0])
+	assert.Equal(t, domain.ErrConflict, errs[1])
+	assert.NotNil(t, errs[2])
+
+	// Testing insertion error affecting every planet
+	errs = dbRepo.InsertMany(context.Background(), []*planet.Planet{pTwo, pThree})
+	assert.Len(t, errs, 2)
+	assert.Equal(t, "insert error", errs[0].Error())
+	assert.Equal(t, "insert error", errs[1].Error())
+
+	// Testing empty batch
+	errs = dbRepo.InsertMany(context.Background(), nil)
+	assert.Empty(t, errs)
+	collectionHelper.AssertNumberOfCalls(t, "InsertMany", 3)
+}
+
+func TestRepoDeleteMany(t *testing.T) {
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+	cursorHelper := &mocks.CursorHelper{}
+
+	dbRepo := planet.NewMongoRepository(dbHelper, zerolog.Nop())
+
+	pID := primitive.NewObjectID()
+	pIDNotFound := primitive.NewObjectID()
+
+	setsDeletedAt := mock.MatchedBy(func(update bson.M) bool {
+		_, ok := update["$set"].(bson.M)["deleted_at"].(time.Time)
+		return ok
+	})
+
+	collectionHelper.
+		On("Find", mock.Anything, bson.M{"_id": bson.M{"$in": []primitive.ObjectID{pID, pIDNotFound}}, "deleted_at": nil}, mock.Anything).
+		Return(cursorHelper, nil)
+
+	cursorHelper.
+		On("Close", mock.Anything).
+		Return(nil)
+
+	cursorHelper.
+		On("All", mock.Anything, mock.AnythingOfType("*[]planet.Planet")).
+		Return(func(ctx context.Context, v interface{}) error {
+			list := v.(*[]planet.Planet)
+			*list = append(*list, planet.Planet{ID: pID})
+			return nil
+		})
+
+	collectionHelper.
+		On("UpdateMany", mock.Anything, bson.M{"_id": bson.M{"$in": []primitive.ObjectID{pID}}, "deleted_at": nil}, setsDeletedAt).
+		Return(&mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil)
+
+	dbHelper.
+		On("Collection", dbRepo.CollectionName()).
+		Return(collectionHelper)
+
+	// Testing deletion with a missing planet
+	errs := dbRepo.DeleteMany(context.Background(), []primitive.ObjectID{pID, pIDNotFound})
+	assert.Equal(t, []error{nil, domain.ErrNotFound}, errs)
+
+	collectionHelper.AssertNotCalled(t, "DeleteMany", mock.Anything, mock.Anything)
+
+	// Testing find error
+	dbHelperErr := &mocks.DatabaseHelper{}
+	collectionHelperErr := &mocks.CollectionHelper{}
+	dbRepoErr := planet.NewMongoRepository(dbHelperErr, zerolog.Nop())
+
+	collectionHelperErr.
+		On("Find", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, errors.New("find error"))
+
+	dbHelperErr.
+		On("Collection", dbRepoErr.CollectionName()).
+		Return(collectionHelperErr)
+
+	errs = dbRepoErr.DeleteMany(context.Background(), []primitive.ObjectID{pID})
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "find error", errs[0].Error())
+	collectionHelperErr.AssertNotCalled(t, "UpdateMany", mock.Anything, mock.Anything, mock.Anything)
 }
@@ -1,61 +1,253 @@
 package planet
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
-	"fmt"
-
+	"net"
 	"net/http"
-	"net/url"
+	"sync"
+	"time"
+
+	"b2w/swapi-challenge/domain"
+	"b2w/swapi-challenge/infra/logger"
+	"b2w/swapi-challenge/infra/metrics"
+	"b2w/swapi-challenge/infra/swapi"
+	"b2w/swapi-challenge/infra/tracing"
 
-	"b2w/swapi-challenge/config"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-type swapiRepo struct{}
+// filmFetchConcurrency limita as buscas simultâneas dos filmes de um planeta
+const filmFetchConcurrency = 4
+
+type swapiRepo struct {
+	client *swapi.Client
+	films  *filmCache
+	log    zerolog.Logger
+}
+
+func NewSWApiRepository(client *swapi.Client, log zerolog.Logger) *swapiRepo {
+	return &swapiRepo{
+		client: client,
+		films:  &filmCache{films: make(map[string]Film)},
+		log:    log.With().Str("component", "swapi_repository").Logger(),
+	}
+}
+
+// filmCache guarda os filmes já buscados pela URL. Os filmes da SWAPI não
+// mudam e são poucos, então ficam guardados enquanto a aplicação estiver no ar.
+type filmCache struct {
+	mu    sync.RWMutex
+	films map[string]Film
+}
+
+func (c *filmCache) get(filmUrl string) (Film, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 
-func (swapiRepo) Endpoint() string {
-	swapiConfig := config.Data.SWApi
-	return fmt.Sprintf("%s/%s", swapiConfig.BaseUrl, "planets")
+	film, ok := c.films[filmUrl]
+	return film, ok
 }
 
-func NewSWApiRepository() *swapiRepo {
-	return &swapiRepo{}
+func (c *filmCache) set(filmUrl string, film Film) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.films[filmUrl] = film
 }
 
-func (r swapiRepo) GetPlanetApparitions(name string) (int32, error) {
-	escapedName := url.QueryEscape(name)
-	searchUrl := fmt.Sprintf("%s/?search=%s", r.Endpoint(), escapedName)
-	response, err := http.Get(searchUrl)
+func (r swapiRepo) GetPlanetFilms(ctx context.Context, name string) ([]Film, error) {
+	p, err := r.GetPlanet(ctx, name)
+	return p.Films, err
+}
+
+func (r swapiRepo) GetPlanet(ctx context.Context, name string) (SwapiPlanet, error) {
+	logCtx := func(e *zerolog.Event) *zerolog.Event { return e.Str("name", name) }
+	return r.observe(ctx, "get_planet", "swapi.GetPlanet", attribute.String("planet.name", name), logCtx, func(ctx context.Context) (swapi.Planet, error) {
+		return r.client.FindPlanetByName(ctx, name)
+	})
+}
+
+func (r swapiRepo) GetPlanetByID(ctx context.Context, id int) (SwapiPlanet, error) {
+	logCtx := func(e *zerolog.Event) *zerolog.Event { return e.Int("swapi_id", id) }
+	return r.observe(ctx, "get_planet_by_id", "swapi.GetPlanetByID", attribute.Int("swapi.id", id), logCtx, func(ctx context.Context) (swapi.Planet, error) {
+		return r.client.GetPlanet(ctx, id)
+	})
+}
+
+// observe registra o span, as métricas e os logs de uma busca de planeta
+func (r swapiRepo) observe(ctx context.Context, operation, spanName string, attr attribute.KeyValue,
+	logCtx func(*zerolog.Event) *zerolog.Event, fetch func(context.Context) (swapi.Planet, error)) (SwapiPlanet, error) {
+	ctx, span := tracing.Start(ctx, tracerName, spanName, trace.WithAttributes(attr))
+	start := time.Now()
+	p, err := r.getPlanet(ctx, fetch)
+
+	metrics.Since(metrics.SWApiRequestDuration.WithLabelValues(operation), start)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		metrics.SWApiRequestErrors.WithLabelValues(operation).Inc()
+		logCtx(logger.Ctx(ctx, r.log).Warn()).
+			Err(err).
+			Dur("duration_ms", time.Since(start)).
+			Msg("swapi request failed")
+	} else {
+		logCtx(logger.Ctx(ctx, r.log).Debug()).
+			Int("films", len(p.Films)).
+			Dur("duration_ms", time.Since(start)).
+			Msg("swapi request finished")
+	}
+
+	span.SetAttributes(attribute.Int("planet.films", len(p.Films)))
+	if errors.Is(err, domain.ErrNotFound) {
+		span.SetAttributes(attribute.Bool("planet.found", false))
+		tracing.End(span, nil)
+	} else {
+		tracing.End(span, err)
+	}
+
+	return p, err
+}
+
+// ListPlanets busca uma página da lista de planetas da SWAPI, começando pela página 1
+func (r swapiRepo) ListPlanets(ctx context.Context, page int) (SwapiPlanetPage, error) {
+	ctx, span := tracing.Start(ctx, tracerName, "swapi.ListPlanets", trace.WithAttributes(attribute.Int("swapi.page", page)))
+	start := time.Now()
+	result, err := r.listPlanets(ctx, page)
+
+	metrics.Since(metrics.SWApiRequestDuration.WithLabelValues("list_planets"), start)
 	if err != nil {
-		return 0, err
+		metrics.SWApiRequestErrors.WithLabelValues("list_planets").Inc()
+		logger.Ctx(ctx, r.log).Warn().
+			Err(err).
+			Int("page", page).
+			Dur("duration_ms", time.Since(start)).
+			Msg("swapi request failed")
+	} else {
+		logger.Ctx(ctx, r.log).Debug().
+			Int("page", page).
+			Int("planets", len(result.Planets)).
+			Dur("duration_ms", time.Since(start)).
+			Msg("swapi request finished")
 	}
-	defer response.Body.Close()
 
-	data := make(map[string]interface{})
-	err = json.NewDecoder(response.Body).Decode(&data)
+	span.SetAttributes(attribute.Int("swapi.planets", len(result.Planets)))
+	tracing.End(span, err)
+
+	return result, err
+}
+
+func (r swapiRepo) listPlanets(ctx context.Context, page int) (SwapiPlanetPage, error) {
+	p, err := r.client.GetPlanetPage(ctx, page)
+	if err != nil {
+		return SwapiPlanetPage{}, swapiError(err)
+	}
+
+	result := SwapiPlanetPage{
+		Planets: make([]SwapiPlanet, 0, len(p.Results)),
+		HasMore: p.Next != nil,
+	}
+	for _, swapiP := range p.Results {
+		planetFilms, err := r.getFilms(ctx, swapiP.Films)
+		if err != nil {
+			return SwapiPlanetPage{}, err
+		}
+
+		result.Planets = append(result.Planets, newSwapiPlanet(swapiP, planetFilms))
+	}
+
+	return result, nil
+}
+
+func (r swapiRepo) getPlanet(ctx context.Context, fetch func(context.Context) (swapi.Planet, error)) (SwapiPlanet, error) {
+	p, err := fetch(ctx)
+	if err != nil {
+		if errors.Is(err, swapi.ErrNotFound) {
+			return SwapiPlanet{}, domain.ErrNotFound
+		}
+		return SwapiPlanet{}, swapiError(err)
+	}
+
+	films, err := r.getFilms(ctx, p.Films)
 	if err != nil {
-		return 0, err
+		return SwapiPlanet{}, err
 	}
 
-	var results []interface{}
-	var typeOk bool
-	if results, typeOk = data["results"].([]interface{}); !typeOk {
-		return 0, errors.New("wrong result type")
+	return newSwapiPlanet(p, films), nil
+}
+
+func newSwapiPlanet(p swapi.Planet, films []Film) SwapiPlanet {
+	return SwapiPlanet{
+		Name:       p.Name,
+		Climate:    p.Climate,
+		Terrain:    p.Terrain,
+		Population: p.Population,
+		Diameter:   p.Diameter,
+		Gravity:    p.Gravity,
+		Films:      films,
 	}
-	if len(results) <= 0 {
-		return 0, nil
+}
+
+// getFilms busca os filmes pelas URLs. Os filmes já buscados vêm do cache e os
+// demais são buscados em paralelo.
+func (r swapiRepo) getFilms(ctx context.Context, filmUrls []string) ([]Film, error) {
+	films := make([]Film, len(filmUrls))
+	errs := make([]error, len(filmUrls))
+
+	sem := make(chan struct{}, filmFetchConcurrency)
+	var wg sync.WaitGroup
+	for i, filmUrl := range filmUrls {
+		if film, ok := r.films.get(filmUrl); ok {
+			films[i] = film
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+
+		go func(i int, filmUrl string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			f, err := r.client.GetFilmByUrl(ctx, filmUrl)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+
+			films[i] = Film{
+				Title:       f.Title,
+				EpisodeID:   f.EpisodeID,
+				ReleaseDate: f.ReleaseDate,
+			}
+			r.films.set(filmUrl, films[i])
+		}(i, filmUrl)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, swapiError(err)
+		}
 	}
 
-	var firstResult map[string]interface{}
-	if firstResult, typeOk = results[0].(map[string]interface{}); !typeOk {
-		return 0, errors.New("wrong first result type")
+	return films, nil
+}
+
+// swapiError traduz as falhas que indicam indisponibilidade da SWAPI, depois
+// das novas tentativas do cliente, para domain.ErrUnavailable. O cancelamento
+// pelo chamador e as demais falhas são mantidos.
+func swapiError(err error) error {
+	if errors.Is(err, swapi.ErrCircuitOpen) {
+		return domain.ErrUnavailable
 	}
 
-	var firstResultFilms []interface{}
-	if firstResultFilms, typeOk = firstResult["films"].([]interface{}); !typeOk {
-		return 0, errors.New("wrong first result films type")
+	var statusErr *swapi.StatusError
+	var netErr net.Error
+	if (errors.As(err, &statusErr) && statusErr.StatusCode >= http.StatusInternalServerError) ||
+		errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return domain.Wrap(err, domain.CodeUnavailable, domain.ErrUnavailable.Message)
 	}
 
-	apparitions := len(firstResultFilms)
-	return int32(apparitions), nil
+	return err
 }
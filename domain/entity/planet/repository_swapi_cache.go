@@ -0,0 +1,234 @@
+package planet
+
+import (
+	"b2w/swapi-challenge/config"
+	"b2w/swapi-challenge/domain"
+	"b2w/swapi-challenge/infra/cache"
+	"b2w/swapi-challenge/infra/database"
+	"b2w/swapi-challenge/infra/logger"
+	"b2w/swapi-challenge/infra/metrics"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CacheOptions struct {
+	Size        int
+	TTL         time.Duration
+	NegativeTTL time.Duration
+}
+
+// cachedSwapiRepo guarda as consultas à SWAPI em memória e, opcionalmente,
+// em um armazenamento persistente. Planetas não encontrados também são
+// guardados, com um tempo de expiração próprio.
+type cachedSwapiRepo struct {
+	next    SwapiRepository
+	lru     *cache.LRU
+	store   SwapiCacheStore
+	options CacheOptions
+	log     zerolog.Logger
+}
+
+func NewCachedSWApiRepository(next SwapiRepository, store SwapiCacheStore, opts CacheOptions, log zerolog.Logger) *cachedSwapiRepo {
+	return &cachedSwapiRepo{
+		next:    next,
+		lru:     cache.NewLRU(opts.Size),
+		store:   store,
+		options: opts,
+		log:     log.With().Str("component", "swapi_cache").Logger(),
+	}
+}
+
+func (r *cachedSwapiRepo) GetPlanetFilms(ctx context.Context, name string) ([]Film, error) {
+	p, err := r.GetPlanet(ctx, name)
+	return p.Films, err
+}
+
+func (r *cachedSwapiRepo) GetPlanet(ctx context.Context, name string) (SwapiPlanet, error) {
+	key := cacheKey(name)
+
+	if entry, ok := r.lookup(ctx, key); ok {
+		if !entry.Found {
+			metrics.SWApiCacheLookups.WithLabelValues("negative_hit").Inc()
+			return SwapiPlanet{}, domain.ErrNotFound
+		}
+
+		metrics.SWApiCacheLookups.WithLabelValues("hit").Inc()
+		return entry.Planet, nil
+	}
+	metrics.SWApiCacheLookups.WithLabelValues("miss").Inc()
+
+	p, err := r.next.GetPlanet(ctx, name)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return p, err
+	}
+
+	entry := SwapiCacheEntry{Planet: p, Found: err == nil}
+	entry.ExpiresAt = time.Now().Add(r.options.TTL)
+	if !entry.Found {
+		entry.ExpiresAt = time.Now().Add(r.options.NegativeTTL)
+	}
+	r.save(ctx, key, entry)
+
+	return p, err
+}
+
+// GetPlanetByID não usa o cache, que é indexado pelo nome. As buscas por ID
+// só acontecem nas importações, que são raras.
+func (r *cachedSwapiRepo) GetPlanetByID(ctx context.Context, id int) (SwapiPlanet, error) {
+	return r.next.GetPlanetByID(ctx, id)
+}
+
+// ListPlanets não usa o cache, já que a lista só é percorrida na importação
+// do catálogo, que precisa dos dados atuais da SWAPI
+func (r *cachedSwapiRepo) ListPlanets(ctx context.Context, page int) (SwapiPlanetPage, error) {
+	return r.next.ListPlanets(ctx, page)
+}
+
+func (r *cachedSwapiRepo) lookup(ctx context.Context, key string) (SwapiCacheEntry, bool) {
+	if value, ok := r.lru.Get(key); ok {
+		return value.(SwapiCacheEntry), true
+	}
+
+	if r.store == nil {
+		return SwapiCacheEntry{}, false
+	}
+
+	entry, err := r.store.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, domain.ErrNotFound) {
+			logger.Ctx(ctx, r.log).Warn().Err(err).Str("key", key).Msg("swapi cache store get failed")
+		}
+		return SwapiCacheEntry{}, false
+	}
+	if !time.Now().Before(entry.ExpiresAt) {
+		return SwapiCacheEntry{}, false
+	}
+
+	r.lru.Set(key, entry, entry.ExpiresAt)
+	return entry, true
+}
+
+func (r *cachedSwapiRepo) save(ctx context.Context, key string, entry SwapiCacheEntry) {
+	r.lru.Set(key, entry, entry.ExpiresAt)
+
+	if r.store == nil {
+		return
+	}
+
+	// Falhas no armazenamento persistente não impedem o uso do resultado
+	if err := r.store.Set(ctx, key, entry); err != nil {
+		logger.Ctx(ctx, r.log).Warn().Err(err).Str("key", key).Msg("swapi cache store set failed")
+	}
+}
+
+func cacheKey(name string) string {
+	return strings.ToLower(NormalizeName(name))
+}
+
+type swapiCacheDocument struct {
+	Key        string    `bson:"_id"`
+	Name       string    `bson:"name"`
+	Climate    string    `bson:"climate"`
+	Terrain    string    `bson:"terrain"`
+	Population string    `bson:"population"`
+	Diameter   string    `bson:"diameter"`
+	Gravity    string    `bson:"gravity"`
+	Films      []Film    `bson:"films"`
+	Found      bool      `bson:"found"`
+	ExpiresAt  time.Time `bson:"expires_at"`
+}
+
+type mongoCacheStore struct {
+	db             database.DatabaseHelper
+	commandTimeout time.Duration
+}
+
+func (s mongoCacheStore) CollectionName() string { return "swapi_cache" }
+
+func NewMongoCacheStore(db database.DatabaseHelper) *mongoCacheStore {
+	timeout := config.Data.Database.CommandTimeout
+	if timeout == 0 {
+		timeout = 15 * time.Second
+	}
+
+	return &mongoCacheStore{
+		db:             db,
+		commandTimeout: timeout,
+	}
+}
+
+// EnsureIndexes cria o índice TTL que remove do banco as consultas expiradas
+func (s *mongoCacheStore) EnsureIndexes(ctx context.Context) error {
+	collection := s.db.Collection(s.CollectionName())
+
+	ctx, cancel := context.WithTimeout(ctx, s.commandTimeout)
+	defer cancel()
+
+	_, err := collection.CreateIndex(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().
+			SetName("expires_at_ttl").
+			SetExpireAfterSeconds(0),
+	})
+
+	return err
+}
+
+func (s *mongoCacheStore) Get(ctx context.Context, key string) (SwapiCacheEntry, error) {
+	collection := s.db.Collection(s.CollectionName())
+
+	ctx, cancel := context.WithTimeout(ctx, s.commandTimeout)
+	defer cancel()
+
+	var doc swapiCacheDocument
+	if err := collection.FindOne(ctx, bson.M{"_id": key}).Decode(&doc); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return SwapiCacheEntry{}, domain.ErrNotFound
+		}
+		return SwapiCacheEntry{}, err
+	}
+
+	return SwapiCacheEntry{
+		Planet: SwapiPlanet{
+			Name:       doc.Name,
+			Climate:    doc.Climate,
+			Terrain:    doc.Terrain,
+			Population: doc.Population,
+			Diameter:   doc.Diameter,
+			Gravity:    doc.Gravity,
+			Films:      doc.Films,
+		},
+		Found:     doc.Found,
+		ExpiresAt: doc.ExpiresAt,
+	}, nil
+}
+
+func (s *mongoCacheStore) Set(ctx context.Context, key string, entry SwapiCacheEntry) error {
+	collection := s.db.Collection(s.CollectionName())
+
+	ctx, cancel := context.WithTimeout(ctx, s.commandTimeout)
+	defer cancel()
+
+	doc := swapiCacheDocument{
+		Key:        key,
+		Name:       entry.Planet.Name,
+		Climate:    entry.Planet.Climate,
+		Terrain:    entry.Planet.Terrain,
+		Population: entry.Planet.Population,
+		Diameter:   entry.Planet.Diameter,
+		Gravity:    entry.Planet.Gravity,
+		Films:      entry.Planet.Films,
+		Found:      entry.Found,
+		ExpiresAt:  entry.ExpiresAt,
+	}
+
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": key}, doc, options.Replace().SetUpsert(true))
+	return err
+}
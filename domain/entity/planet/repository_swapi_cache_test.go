@@ -0,0 +1,213 @@
+package planet_test
+
+import (
+	"b2w/swapi-challenge/domain"
+	"b2w/swapi-challenge/domain/entity/planet"
+	"b2w/swapi-challenge/domain/entity/planet/mocks"
+	dbMocks "b2w/swapi-challenge/infra/database/mocks"
+	"b2w/swapi-challenge/infra/metrics"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestCachedSwapiRepo(t *testing.T) {
+	swapiRepo := &mocks.SwapiRepository{}
+
+	cachedRepo := planet.NewCachedSWApiRepository(swapiRepo, nil, planet.CacheOptions{
+		Size:        10,
+		TTL:         time.Minute,
+		NegativeTTL: 20 * time.Millisecond,
+	}, zerolog.Nop())
+
+	swapiRepo.
+		On("GetPlanet", mock.Anything, "Tatooine").
+		Return(planet.SwapiPlanet{Name: "Tatooine", Climate: "arid", Films: testFilms(5)}, nil).
+		Once()
+
+	swapiRepo.
+		On("GetPlanet", mock.Anything, "Kamino").
+		Return(planet.SwapiPlanet{}, domain.ErrNotFound).
+		Twice()
+
+	swapiRepo.
+		On("GetPlanet", mock.Anything, "Error").
+		Return(planet.SwapiPlanet{}, errors.New("swapi error")).
+		Twice()
+
+	hits := metrics.SWApiCacheLookups.WithLabelValues("hit")
+	negativeHits := metrics.SWApiCacheLookups.WithLabelValues("negative_hit")
+	misses := metrics.SWApiCacheLookups.WithLabelValues("miss")
+	hitsBefore := testutil.ToFloat64(hits)
+	negativeHitsBefore := testutil.ToFloat64(negativeHits)
+	missesBefore := testutil.ToFloat64(misses)
+
+	// Testing miss followed by hits with a normalized name
+	films, err := cachedRepo.GetPlanetFilms(context.Background(), "Tatooine")
+	assert.Nil(t, err)
+	assert.Equal(t, testFilms(5), films)
+
+	films, err = cachedRepo.GetPlanetFilms(context.Background(), " tatooine ")
+	assert.Nil(t, err)
+	assert.Equal(t, testFilms(5), films)
+
+	p, err := cachedRepo.GetPlanet(context.Background(), "TATOOINE")
+	assert.Nil(t, err)
+	assert.Equal(t, "arid", p.Climate)
+
+	// Testing negative caching
+	_, err = cachedRepo.GetPlanetFilms(context.Background(), "Kamino")
+	assert.Equal(t, domain.ErrNotFound, err)
+
+	_, err = cachedRepo.GetPlanetFilms(context.Background(), "Kamino")
+	assert.Equal(t, domain.ErrNotFound, err)
+
+	// Testing negative entry expiration
+	time.Sleep(30 * time.Millisecond)
+	_, err = cachedRepo.GetPlanetFilms(context.Background(), "Kamino")
+	assert.Equal(t, domain.ErrNotFound, err)
+
+	// Testing errors are not cached
+	_, err = cachedRepo.GetPlanetFilms(context.Background(), "Error")
+	assert.NotNil(t, err)
+	_, err = cachedRepo.GetPlanetFilms(context.Background(), "Error")
+	assert.NotNil(t, err)
+
+	swapiRepo.AssertExpectations(t)
+	assert.Equal(t, hitsBefore+2, testutil.ToFloat64(hits))
+	assert.Equal(t, negativeHitsBefore+1, testutil.ToFloat64(negativeHits))
+	assert.Equal(t, missesBefore+5, testutil.ToFloat64(misses))
+}
+
+func TestCachedSwapiRepoWithStore(t *testing.T) {
+	swapiRepo := &mocks.SwapiRepository{}
+	store := &mocks.SwapiCacheStore{}
+
+	cachedRepo := planet.NewCachedSWApiRepository(swapiRepo, store, planet.CacheOptions{
+		Size:        10,
+		TTL:         time.Minute,
+		NegativeTTL: time.Minute,
+	}, zerolog.Nop())
+
+	store.
+		On("Get", mock.Anything, "alderaan").
+		Return(planet.SwapiCacheEntry{Planet: planet.SwapiPlanet{Films: testFilms(2)}, Found: true, ExpiresAt: time.Now().Add(time.Minute)}, nil).
+		Once()
+
+	store.
+		On("Get", mock.Anything, "yavin iv").
+		Return(planet.SwapiCacheEntry{Planet: planet.SwapiPlanet{Films: testFilms(1)}, Found: true, ExpiresAt: time.Now().Add(-time.Minute)}, nil)
+
+	store.
+		On("Get", mock.Anything, "hoth").
+		Return(planet.SwapiCacheEntry{}, errors.New("store error"))
+
+	store.
+		On("Set", mock.Anything, "yavin iv", mock.MatchedBy(func(entry planet.SwapiCacheEntry) bool {
+			return entry.Found && len(entry.Planet.Films) == 3
+		})).
+		Return(nil)
+
+	store.
+		On("Set", mock.Anything, "hoth", mock.AnythingOfType("planet.SwapiCacheEntry")).
+		Return(errors.New("store error"))
+
+	swapiRepo.
+		On("GetPlanet", mock.Anything, "Yavin IV").
+		Return(planet.SwapiPlanet{Films: testFilms(3)}, nil)
+
+	swapiRepo.
+		On("GetPlanet", mock.Anything, "Hoth").
+		Return(planet.SwapiPlanet{Films: testFilms(4)}, nil)
+
+	// Testing hit on the persistent store, then on memory
+	films, err := cachedRepo.GetPlanetFilms(context.Background(), "Alderaan")
+	assert.Nil(t, err)
+	assert.Equal(t, testFilms(2), films)
+
+	films, err = cachedRepo.GetPlanetFilms(context.Background(), "Alderaan")
+	assert.Nil(t, err)
+	assert.Equal(t, testFilms(2), films)
+
+	// Testing expired persistent entry
+	films, err = cachedRepo.GetPlanetFilms(context.Background(), "Yavin IV")
+	assert.Nil(t, err)
+	assert.Equal(t, testFilms(3), films)
+
+	// Testing store errors don't break lookups
+	films, err = cachedRepo.GetPlanetFilms(context.Background(), "Hoth")
+	assert.Nil(t, err)
+	assert.Equal(t, testFilms(4), films)
+
+	store.AssertExpectations(t)
+}
+
+func TestMongoCacheStore(t *testing.T) {
+	dbHelper := &dbMocks.DatabaseHelper{}
+	collectionHelper := &dbMocks.CollectionHelper{}
+	singleResultHelper := &dbMocks.SingleResultHelper{}
+	singleResultHelperNotFound := &dbMocks.SingleResultHelper{}
+
+	store := planet.NewMongoCacheStore(dbHelper)
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	singleResultHelper.
+		On("Decode", mock.Anything).
+		Return(func(v interface{}) error {
+			return bson.Unmarshal(mustMarshal(bson.M{"_id": "tatooine", "climate": "arid", "films": bson.A{bson.M{"title": "A New Hope", "episode_id": 4}}, "found": true, "expires_at": expiresAt}), v)
+		})
+
+	singleResultHelperNotFound.
+		On("Decode", mock.Anything).
+		Return(mongo.ErrNoDocuments)
+
+	collectionHelper.
+		On("FindOne", mock.Anything, bson.M{"_id": "tatooine"}).
+		Return(singleResultHelper)
+
+	collectionHelper.
+		On("FindOne", mock.Anything, bson.M{"_id": "kamino"}).
+		Return(singleResultHelperNotFound)
+
+	collectionHelper.
+		On("ReplaceOne", mock.Anything, bson.M{"_id": "kamino"}, mock.Anything, mock.MatchedBy(func(opts interface{}) bool {
+			return opts != nil
+		})).
+		Return(&mongo.UpdateResult{UpsertedCount: 1}, nil)
+
+	dbHelper.
+		On("Collection", store.CollectionName()).
+		Return(collectionHelper)
+
+	// Testing get entry
+	entry, err := store.Get(context.Background(), "tatooine")
+	assert.Nil(t, err)
+	assert.Equal(t, []planet.Film{{Title: "A New Hope", EpisodeID: 4}}, entry.Planet.Films)
+	assert.Equal(t, "arid", entry.Planet.Climate)
+	assert.True(t, entry.Found)
+
+	// Testing entry not found
+	_, err = store.Get(context.Background(), "kamino")
+	assert.Equal(t, domain.ErrNotFound, err)
+
+	// Testing upsert entry
+	err = store.Set(context.Background(), "kamino", planet.SwapiCacheEntry{ExpiresAt: expiresAt})
+	assert.Nil(t, err)
+}
+
+func mustMarshal(v interface{}) []byte {
+	data, err := bson.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
@@ -0,0 +1,127 @@
+package planet_test
+
+import (
+	"b2w/swapi-challenge/domain"
+	"b2w/swapi-challenge/domain/entity/planet"
+	"b2w/swapi-challenge/infra/metrics"
+	"b2w/swapi-challenge/infra/swapi"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSwapiRepoGetPlanetFilms(t *testing.T) {
+	var filmRequests int32
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/films/") {
+			atomic.AddInt32(&filmRequests, 1)
+		}
+
+		switch {
+		case r.URL.Path == "/films/1/":
+			fmt.Fprint(w, `{"title":"A New Hope","episode_id":4,"release_date":"1977-05-25"}`)
+		case r.URL.Path == "/films/2/":
+			fmt.Fprint(w, `{"title":"Return of the Jedi","episode_id":6,"release_date":"1983-05-25"}`)
+		case r.URL.Path == "/planets/1/":
+			fmt.Fprintf(w, `{"name":"Tatooine","climate":"arid","terrain":"desert","population":"200000","diameter":"10465","gravity":"1 standard","films":["%s/films/1/"]}`, ts.URL)
+		case r.URL.Path != "/planets/":
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Query().Get("search") == "Tatooine":
+			fmt.Fprintf(w, `{"count":1,"next":null,"results":[{"name":"Tatooine","climate":"arid","terrain":"desert","films":["%[1]s/films/1/","%[1]s/films/2/"]}]}`, ts.URL)
+		case r.URL.Query().Get("search") == "Missing Film":
+			fmt.Fprintf(w, `{"count":1,"next":null,"results":[{"name":"Missing Film","films":["%s/films/3/"]}]}`, ts.URL)
+		case r.URL.Query().Get("search") == "Error":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			fmt.Fprint(w, `{"count":0,"next":null,"results":[]}`)
+		}
+	}))
+	defer ts.Close()
+
+	swapiRepo := planet.NewSWApiRepository(swapi.NewClient(ts.URL, ts.Client(), swapi.Options{}), zerolog.Nop())
+
+	// Testing planet found
+	films, err := swapiRepo.GetPlanetFilms(context.Background(), "Tatooine")
+	assert.Nil(t, err)
+	assert.Equal(t, []planet.Film{
+		{Title: "A New Hope", EpisodeID: 4, ReleaseDate: "1977-05-25"},
+		{Title: "Return of the Jedi", EpisodeID: 6, ReleaseDate: "1983-05-25"},
+	}, films)
+
+	// Testing the planet record
+	p, err := swapiRepo.GetPlanet(context.Background(), "Tatooine")
+	assert.Nil(t, err)
+	assert.Equal(t, "Tatooine", p.Name)
+	assert.Equal(t, "arid", p.Climate)
+	assert.Equal(t, "desert", p.Terrain)
+	assert.Equal(t, films, p.Films)
+
+	// Testing films fetched once and reused by the next lookups
+	assert.Equal(t, int32(2), atomic.LoadInt32(&filmRequests))
+
+	// Testing the planet record by id
+	p, err = swapiRepo.GetPlanetByID(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "Tatooine", p.Name)
+	assert.Equal(t, "200000", p.Population)
+	assert.Equal(t, "10465", p.Diameter)
+	assert.Equal(t, "1 standard", p.Gravity)
+	assert.Equal(t, films[:1], p.Films)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&filmRequests))
+
+	_, err = swapiRepo.GetPlanetByID(context.Background(), 99)
+	assert.Equal(t, domain.ErrNotFound, err)
+
+	// Testing planet not found
+	films, err = swapiRepo.GetPlanetFilms(context.Background(), "Kamino")
+	assert.Equal(t, domain.ErrNotFound, err)
+	assert.Equal(t, 0, len(films))
+
+	// Testing film not found is not reported as planet not found
+	_, err = swapiRepo.GetPlanetFilms(context.Background(), "Missing Film")
+	assert.NotNil(t, err)
+	assert.NotEqual(t, domain.ErrNotFound, err)
+
+	// Testing swapi error counted, unlike planets not found
+	swapiErrors := metrics.SWApiRequestErrors.WithLabelValues("get_planet")
+	errorsBefore := testutil.ToFloat64(swapiErrors)
+
+	_, err = swapiRepo.GetPlanetFilms(context.Background(), "Error")
+	assert.NotNil(t, err)
+	assert.Equal(t, errorsBefore+1, testutil.ToFloat64(swapiErrors))
+
+	_, err = swapiRepo.GetPlanetFilms(context.Background(), "Kamino")
+	assert.Equal(t, domain.ErrNotFound, err)
+	assert.Equal(t, errorsBefore+1, testutil.ToFloat64(swapiErrors))
+}
+
+func TestSwapiRepoCircuitOpen(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	client := swapi.NewClient(ts.URL, ts.Client(), swapi.Options{BreakerThreshold: 1, BreakerCooldown: time.Minute})
+	swapiRepo := planet.NewSWApiRepository(client, zerolog.Nop())
+
+	// Testing the failure that opens the circuit keeps the cause
+	_, err := swapiRepo.GetPlanetFilms(context.Background(), "Tatooine")
+	assert.ErrorIs(t, err, domain.ErrUnavailable)
+
+	var statusErr *swapi.StatusError
+	assert.ErrorAs(t, err, &statusErr)
+
+	// Testing fail fast mapped to the domain error
+	_, err = swapiRepo.GetPlanetFilms(context.Background(), "Tatooine")
+	assert.Equal(t, domain.ErrUnavailable, err)
+}
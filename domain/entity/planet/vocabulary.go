@@ -0,0 +1,57 @@
+package planet
+
+import "strings"
+
+// Climas e terrenos usados pela SWAPI. Assim como na SWAPI, um planeta pode
+// ter vários climas ou terrenos separados por vírgula (ex.: "temperate, arid").
+var (
+	Climates = newVocabulary(
+		"arid", "arctic", "artic", "artificial temperate", "frigid", "frozen",
+		"hot", "humid", "moist", "murky", "polluted", "rocky", "subarctic",
+		"subartic", "superheated", "temperate", "tropical", "unknown", "windy",
+	)
+
+	Terrains = newVocabulary(
+		"acid pools", "airless asteroid", "ash", "barren", "bogs", "canyons",
+		"caves", "cities", "cityscape", "cliffs", "deserts", "desert", "fields",
+		"forests", "fungus forests", "gas giant", "glaciers", "grass", "grasslands",
+		"grassy hills", "hills", "ice", "ice canyons", "ice caves", "islands",
+		"jungle", "jungles", "lakes", "lava rivers", "mesas", "mountain ranges",
+		"mountains", "ocean", "oceans", "plains", "plateaus", "rainforests",
+		"reefs", "rivers", "rock", "rock arches", "rocky", "rocky canyons",
+		"rocky deserts", "rocky islands", "savanna", "savannahs", "savannas",
+		"scrublands", "sea", "seas", "sinkholes", "swamp", "swamps",
+		"toxic cloudsea", "tundra", "unknown", "urban", "valleys", "verdant",
+		"vines", "volcanoes",
+	)
+)
+
+// Vocabulary é o conjunto de termos aceitos em um campo com valores separados por vírgula
+type Vocabulary map[string]bool
+
+func newVocabulary(terms ...string) Vocabulary {
+	v := make(Vocabulary, len(terms))
+	for _, term := range terms {
+		v[term] = true
+	}
+
+	return v
+}
+
+// Unknown retorna os termos de value que não fazem parte do vocabulário, sem
+// diferenciar maiúsculas. Um valor vazio não tem termos desconhecidos.
+func (v Vocabulary) Unknown(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+
+	var unknown []string
+	for _, term := range strings.Split(value, ",") {
+		term = strings.ToLower(strings.TrimSpace(term))
+		if !v[term] {
+			unknown = append(unknown, term)
+		}
+	}
+
+	return unknown
+}
@@ -2,8 +2,90 @@ package domain
 
 import "errors"
 
+// Code identifica o tipo de um erro de forma estável, podendo ser usado
+// pelos clientes da API para tratar cada caso
+type Code string
+
+const (
+	CodeInvalidInput     Code = "invalid_input"
+	CodeUnauthorized     Code = "unauthorized"
+	CodeForbidden        Code = "forbidden"
+	CodeCorsForbidden    Code = "cors_forbidden"
+	CodeNotFound         Code = "not_found"
+	CodeConflict         Code = "conflict"
+	CodeValidationFailed Code = "validation_failed"
+	CodeRateLimited      Code = "rate_limited"
+	CodeInternal         Code = "internal"
+	CodeUnavailable      Code = "unavailable"
+	CodeCanceled         Code = "canceled"
+)
+
 var (
-	ErrNotFound      = errors.New("Your requested Item is not found")
-	ErrConflict      = errors.New("Your Item already exist")
-	ErrBadParamInput = errors.New("Given Param is not valid")
+	ErrNotFound         = NewError(CodeNotFound, "Your requested Item is not found")
+	ErrConflict         = NewError(CodeConflict, "Your Item already exist")
+	ErrBadParamInput    = NewError(CodeInvalidInput, "Given Param is not valid")
+	ErrValidationFailed = NewError(CodeValidationFailed, "Given Params failed validation")
+	ErrUnavailable      = NewError(CodeUnavailable, "External service is unavailable")
 )
+
+// FieldError descreve o problema encontrado em um campo específico da entrada
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error é o erro tipado do domínio. A mensagem pode ser exposta aos clientes,
+// enquanto a causa fica disponível apenas para errors.Is/As e para os logs.
+type Error struct {
+	Code    Code
+	Message string
+	Cause   error
+	Fields  []FieldError
+}
+
+func NewError(code Code, message string, fields ...FieldError) *Error {
+	return &Error{Code: code, Message: message, Fields: fields}
+}
+
+// Wrap cria um erro de domínio com a causa informada, mantendo os detalhes
+// dos campos caso a causa também seja um erro de domínio
+func Wrap(err error, code Code, message string) *Error {
+	e := &Error{Code: code, Message: message, Cause: err}
+
+	var cause *Error
+	if errors.As(err, &cause) {
+		e.Fields = cause.Fields
+	}
+
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return e.Message + ": " + e.Cause.Error()
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Is considera equivalentes os erros de domínio com o mesmo código, permitindo
+// comparar com os erros padrão, como em errors.Is(err, domain.ErrNotFound)
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// CodeOf retorna o código do erro de domínio encontrado em err. Erros fora
+// do domínio são considerados internos.
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+
+	return CodeInternal
+}
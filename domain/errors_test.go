@@ -0,0 +1,40 @@
+package domain_test
+
+import (
+	"b2w/swapi-challenge/domain"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError(t *testing.T) {
+	cause := errors.New("connection refused")
+	field := domain.FieldError{Field: "name", Message: "must not be empty"}
+
+	// Testing comparison by code
+	err := domain.NewError(domain.CodeNotFound, "Planet not found")
+	assert.True(t, errors.Is(err, domain.ErrNotFound))
+	assert.False(t, errors.Is(err, domain.ErrConflict))
+	assert.True(t, errors.Is(fmt.Errorf("get by id: %w", err), domain.ErrNotFound))
+
+	// Testing wrapped cause
+	wrapped := domain.Wrap(cause, domain.CodeUnavailable, "Star Wars API is unavailable")
+	assert.True(t, errors.Is(wrapped, cause))
+	assert.True(t, errors.Is(wrapped, domain.ErrUnavailable))
+	assert.Equal(t, "Star Wars API is unavailable: connection refused", wrapped.Error())
+
+	// Testing field details kept when wrapping domain errors
+	invalid := domain.NewError(domain.CodeInvalidInput, "Invalid planet input params", field)
+	wrapped = domain.Wrap(invalid, domain.CodeInvalidInput, "Invalid filter params")
+	assert.Equal(t, []domain.FieldError{field}, wrapped.Fields)
+
+	var target *domain.Error
+	assert.True(t, errors.As(fmt.Errorf("insert: %w", invalid), &target))
+	assert.Equal(t, invalid, target)
+
+	// Testing codes
+	assert.Equal(t, domain.CodeInvalidInput, domain.CodeOf(fmt.Errorf("insert: %w", invalid)))
+	assert.Equal(t, domain.CodeInternal, domain.CodeOf(cause))
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"b2w/swapi-challenge/config"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	ScopeRead  = "planets:read"
+	ScopeWrite = "planets:write"
+	// ScopeAdmin permite as rotas administrativas, como a importação do
+	// catálogo, e não inclui os demais escopos
+	ScopeAdmin = "planets:admin"
+)
+
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+var (
+	ErrMissingCredentials = errors.New("auth: missing credentials")
+	ErrInvalidCredentials = errors.New("auth: invalid credentials")
+)
+
+// Principal identifica quem fez a requisição e o que pode fazer
+type Principal struct {
+	Subject string
+	Method  string
+	Scopes  []string
+}
+
+func (p Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator valida as chaves de API estáticas e os tokens JWT assinados
+// com HS256 ou RS256, de acordo com as chaves configuradas
+type Authenticator struct {
+	apiKeys      []config.APIKey
+	hmacSecret   []byte
+	rsaPublicKey *rsa.PublicKey
+	issuer       string
+	audience     string
+}
+
+func NewAuthenticator(cfg config.Auth) (*Authenticator, error) {
+	a := &Authenticator{
+		apiKeys:  cfg.APIKeys,
+		issuer:   cfg.JWT.Issuer,
+		audience: cfg.JWT.Audience,
+	}
+
+	for _, k := range cfg.APIKeys {
+		if k.Key == "" {
+			return nil, fmt.Errorf("auth: api key %q without value", k.Name)
+		}
+	}
+
+	if cfg.JWT.HMACSecret != "" {
+		a.hmacSecret = []byte(cfg.JWT.HMACSecret)
+	}
+
+	if cfg.JWT.RSAPublicKeyFile != "" {
+		pem, err := ioutil.ReadFile(cfg.JWT.RSAPublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("auth: reading rsa public key: %w", err)
+		}
+
+		a.rsaPublicKey, err = jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("auth: parsing rsa public key: %w", err)
+		}
+	}
+
+	return a, nil
+}
+
+// AuthenticateAPIKey procura a chave entre as configuradas. Todas as chaves são
+// comparadas em tempo constante para não revelar prefixos válidos.
+func (a *Authenticator) AuthenticateAPIKey(key string) (Principal, error) {
+	if key == "" {
+		return Principal{}, ErrMissingCredentials
+	}
+
+	var found *config.APIKey
+	for i := range a.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(a.apiKeys[i].Key), []byte(key)) == 1 {
+			found = &a.apiKeys[i]
+		}
+	}
+	if found == nil {
+		return Principal{}, ErrInvalidCredentials
+	}
+
+	return Principal{Subject: found.Name, Method: MethodAPIKey, Scopes: found.Scopes}, nil
+}
+
+// AuthenticateToken valida a assinatura, a validade e, quando configurados, o
+// emissor e a audiência do token. A claim "exp" é obrigatória, já que tokens sem
+// ela nunca expiram. Os escopos são lidos da claim "scope", separados por
+// espaço, ou da lista "scp".
+func (a *Authenticator) AuthenticateToken(raw string) (Principal, error) {
+	if raw == "" {
+		return Principal{}, ErrMissingCredentials
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(raw, claims, a.verificationKey); err != nil {
+		return Principal{}, ErrInvalidCredentials
+	}
+
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return Principal{}, ErrInvalidCredentials
+	}
+	if a.issuer != "" && !claims.VerifyIssuer(a.issuer, true) {
+		return Principal{}, ErrInvalidCredentials
+	}
+	if a.audience != "" && !claims.VerifyAudience(a.audience, true) {
+		return Principal{}, ErrInvalidCredentials
+	}
+
+	subject, _ := claims["sub"].(string)
+
+	return Principal{Subject: subject, Method: MethodJWT, Scopes: tokenScopes(claims)}, nil
+}
+
+// O algoritmo do token define a chave usada, mas apenas se ela estiver
+// configurada. Assim um token HS256 nunca é validado com a chave pública RSA.
+func (a *Authenticator) verificationKey(token *jwt.Token) (interface{}, error) {
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		if a.hmacSecret != nil {
+			return a.hmacSecret, nil
+		}
+	case jwt.SigningMethodRS256.Alg():
+		if a.rsaPublicKey != nil {
+			return a.rsaPublicKey, nil
+		}
+	}
+
+	return nil, fmt.Errorf("auth: unexpected signing method %s", token.Method.Alg())
+}
+
+func tokenScopes(claims jwt.MapClaims) []string {
+	var scopes []string
+	if scope, ok := claims["scope"].(string); ok {
+		scopes = append(scopes, strings.Fields(scope)...)
+	}
+	if scp, ok := claims["scp"].([]interface{}); ok {
+		for _, s := range scp {
+			if s, ok := s.(string); ok {
+				scopes = append(scopes, s)
+			}
+		}
+	}
+	return scopes
+}
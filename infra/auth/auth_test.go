@@ -0,0 +1,151 @@
+package auth_test
+
+import (
+	"b2w/swapi-challenge/config"
+	"b2w/swapi-challenge/infra/auth"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	authenticator, err := auth.NewAuthenticator(config.Auth{
+		APIKeys: []config.APIKey{
+			{Name: "reader", Key: "read-key", Scopes: []string{auth.ScopeRead}},
+			{Name: "writer", Key: "write-key", Scopes: []string{auth.ScopeRead, auth.ScopeWrite}},
+		},
+	})
+	assert.Nil(t, err)
+
+	// Testing valid key
+	principal, err := authenticator.AuthenticateAPIKey("write-key")
+	assert.Nil(t, err)
+	assert.Equal(t, "writer", principal.Subject)
+	assert.Equal(t, auth.MethodAPIKey, principal.Method)
+	assert.True(t, principal.HasScope(auth.ScopeWrite))
+
+	// Testing unknown and missing keys
+	_, err = authenticator.AuthenticateAPIKey("write")
+	assert.Equal(t, auth.ErrInvalidCredentials, err)
+
+	_, err = authenticator.AuthenticateAPIKey("")
+	assert.Equal(t, auth.ErrMissingCredentials, err)
+
+	// Testing key without value
+	_, err = auth.NewAuthenticator(config.Auth{APIKeys: []config.APIKey{{Name: "empty"}}})
+	assert.NotNil(t, err)
+}
+
+func TestAuthenticateHS256Token(t *testing.T) {
+	authenticator, err := auth.NewAuthenticator(config.Auth{
+		JWT: config.JWT{HMACSecret: "secret", Issuer: "issuer", Audience: "swapi"},
+	})
+	assert.Nil(t, err)
+
+	valid := jwt.MapClaims{
+		"sub":   "user-1",
+		"iss":   "issuer",
+		"aud":   []string{"other", "swapi"},
+		"scope": "planets:read planets:write",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+
+	// Testing valid token with space separated scopes
+	principal, err := authenticator.AuthenticateToken(signHS256(t, valid, "secret"))
+	assert.Nil(t, err)
+	assert.Equal(t, "user-1", principal.Subject)
+	assert.Equal(t, auth.MethodJWT, principal.Method)
+	assert.Equal(t, []string{auth.ScopeRead, auth.ScopeWrite}, principal.Scopes)
+
+	// Testing invalid tokens
+	invalid := map[string]string{
+		"wrong secret": signHS256(t, valid, "other"),
+		"expired":      signHS256(t, withClaim(valid, "exp", time.Now().Add(-time.Minute).Unix()), "secret"),
+		"without exp":  signHS256(t, withoutClaim(valid, "exp"), "secret"),
+		"wrong issuer": signHS256(t, withClaim(valid, "iss", "other"), "secret"),
+		"wrong aud":    signHS256(t, withClaim(valid, "aud", "other"), "secret"),
+		"malformed":    "not.a.token",
+	}
+	for name, token := range invalid {
+		_, err = authenticator.AuthenticateToken(token)
+		assert.Equal(t, auth.ErrInvalidCredentials, err, name)
+	}
+
+	// Testing unsigned tokens are refused
+	unsigned, _ := jwt.NewWithClaims(jwt.SigningMethodNone, valid).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	_, err = authenticator.AuthenticateToken(unsigned)
+	assert.Equal(t, auth.ErrInvalidCredentials, err)
+}
+
+func TestAuthenticateRS256Token(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	authenticator, err := auth.NewAuthenticator(config.Auth{
+		JWT: config.JWT{RSAPublicKeyFile: writePublicKey(t, &key.PublicKey)},
+	})
+	assert.Nil(t, err)
+
+	claims := jwt.MapClaims{"sub": "service", "scp": []string{auth.ScopeWrite}, "exp": time.Now().Add(time.Hour).Unix()}
+
+	// Testing valid token with scope list
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+	assert.Nil(t, err)
+
+	principal, err := authenticator.AuthenticateToken(token)
+	assert.Nil(t, err)
+	assert.Equal(t, "service", principal.Subject)
+	assert.Equal(t, []string{auth.ScopeWrite}, principal.Scopes)
+
+	// Testing HS256 tokens are refused when only the RSA key is configured
+	_, err = authenticator.AuthenticateToken(signHS256(t, claims, "secret"))
+	assert.Equal(t, auth.ErrInvalidCredentials, err)
+
+	// Testing invalid public key file
+	_, err = auth.NewAuthenticator(config.Auth{JWT: config.JWT{RSAPublicKeyFile: "missing.pem"}})
+	assert.NotNil(t, err)
+}
+
+func signHS256(t *testing.T, claims jwt.MapClaims, secret string) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	assert.Nil(t, err)
+	return token
+}
+
+func withClaim(claims jwt.MapClaims, key string, value interface{}) jwt.MapClaims {
+	result := jwt.MapClaims{}
+	for k, v := range claims {
+		result[k] = v
+	}
+	result[key] = value
+	return result
+}
+
+func withoutClaim(claims jwt.MapClaims, key string) jwt.MapClaims {
+	result := withClaim(claims, key, nil)
+	delete(result, key)
+	return result
+}
+
+func writePublicKey(t *testing.T, key *rsa.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	assert.Nil(t, err)
+
+	file, err := ioutil.TempFile("", "public-*.pem")
+	assert.Nil(t, err)
+	defer file.Close()
+	t.Cleanup(func() { os.Remove(file.Name()) })
+
+	err = pem.Encode(file, &pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	assert.Nil(t, err)
+
+	return file.Name()
+}
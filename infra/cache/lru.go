@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type lruEntry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+// LRU é um cache em memória de tamanho fixo que descarta o item usado há
+// mais tempo quando está cheio. Itens expirados são ignorados na leitura.
+type LRU struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+func NewLRU(capacity int) *LRU {
+	if capacity <= 0 {
+		capacity = 1
+	}
+
+	return &LRU{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (c *LRU) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*lruEntry)
+	if !entry.expiresAt.IsZero() && !time.Now().Before(entry.expiresAt) {
+		c.removeElement(element)
+		return nil, false
+	}
+
+	c.ll.MoveToFront(element)
+	return entry.value, true
+}
+
+// Set adiciona ou substitui um item. Um expiresAt zerado indica que o item não expira.
+func (c *LRU) Set(key string, value interface{}, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.ll.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	if c.ll.Len() > c.capacity {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+func (c *LRU) removeElement(element *list.Element) {
+	c.ll.Remove(element)
+	delete(c.items, element.Value.(*lruEntry).key)
+}
@@ -0,0 +1,53 @@
+package cache_test
+
+import (
+	"b2w/swapi-challenge/infra/cache"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUEviction(t *testing.T) {
+	lru := cache.NewLRU(2)
+
+	lru.Set("one", 1, time.Time{})
+	lru.Set("two", 2, time.Time{})
+
+	// Reading "one" makes "two" the least recently used
+	value, ok := lru.Get("one")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	lru.Set("three", 3, time.Time{})
+	assert.Equal(t, 2, lru.Len())
+
+	_, ok = lru.Get("two")
+	assert.False(t, ok)
+
+	value, ok = lru.Get("three")
+	assert.True(t, ok)
+	assert.Equal(t, 3, value)
+
+	// Testing replacing an existing key
+	lru.Set("one", 10, time.Time{})
+	value, ok = lru.Get("one")
+	assert.True(t, ok)
+	assert.Equal(t, 10, value)
+	assert.Equal(t, 2, lru.Len())
+}
+
+func TestLRUExpiration(t *testing.T) {
+	lru := cache.NewLRU(2)
+
+	lru.Set("expired", 1, time.Now().Add(-time.Second))
+	lru.Set("valid", 2, time.Now().Add(time.Minute))
+
+	_, ok := lru.Get("expired")
+	assert.False(t, ok)
+	assert.Equal(t, 1, lru.Len())
+
+	value, ok := lru.Get("valid")
+	assert.True(t, ok)
+	assert.Equal(t, 2, value)
+}
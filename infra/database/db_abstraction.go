@@ -17,10 +17,18 @@ type DatabaseHelper interface {
 }
 
 type CollectionHelper interface {
-	Find(context.Context, interface{}) (CursorHelper, error)
-	FindOne(context.Context, interface{}) SingleResultHelper
+	Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (CursorHelper, error)
+	FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) SingleResultHelper
 	InsertOne(context.Context, interface{}) (*mongo.InsertOneResult, error)
+	InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error)
+	UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+	UpdateMany(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+	ReplaceOne(ctx context.Context, filter interface{}, replacement interface{}, opts ...*options.ReplaceOptions) (*mongo.UpdateResult, error)
 	DeleteOne(ctx context.Context, filter interface{}) (*mongo.DeleteResult, error)
+	DeleteMany(ctx context.Context, filter interface{}) (*mongo.DeleteResult, error)
+	CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error)
+	CreateIndex(ctx context.Context, model mongo.IndexModel) (string, error)
+	DropIndex(ctx context.Context, name string) error
 }
 
 type CursorHelper interface {
@@ -105,13 +113,13 @@ func (md *mongoDatabase) Client() ClientHelper {
 	return &mongoClient{cl: client}
 }
 
-func (mc *mongoCollection) Find(ctx context.Context, filter interface{}) (CursorHelper, error) {
-	cursor, err := mc.coll.Find(ctx, filter)
+func (mc *mongoCollection) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (CursorHelper, error) {
+	cursor, err := mc.coll.Find(ctx, filter, opts...)
 	return &mongoCursor{crs: cursor}, err
 }
 
-func (mc *mongoCollection) FindOne(ctx context.Context, filter interface{}) SingleResultHelper {
-	singleResult := mc.coll.FindOne(ctx, filter)
+func (mc *mongoCollection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) SingleResultHelper {
+	singleResult := mc.coll.FindOne(ctx, filter, opts...)
 	return &mongoSingleResult{sr: singleResult}
 }
 
@@ -119,10 +127,43 @@ func (mc *mongoCollection) InsertOne(ctx context.Context, document interface{})
 	return mc.coll.InsertOne(ctx, document)
 }
 
+func (mc *mongoCollection) InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+	return mc.coll.InsertMany(ctx, documents, opts...)
+}
+
+func (mc *mongoCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	return mc.coll.UpdateOne(ctx, filter, update, opts...)
+}
+
+func (mc *mongoCollection) UpdateMany(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	return mc.coll.UpdateMany(ctx, filter, update, opts...)
+}
+
+func (mc *mongoCollection) ReplaceOne(ctx context.Context, filter interface{}, replacement interface{}, opts ...*options.ReplaceOptions) (*mongo.UpdateResult, error) {
+	return mc.coll.ReplaceOne(ctx, filter, replacement, opts...)
+}
+
 func (mc *mongoCollection) DeleteOne(ctx context.Context, filter interface{}) (*mongo.DeleteResult, error) {
 	return mc.coll.DeleteOne(ctx, filter)
 }
 
+func (mc *mongoCollection) DeleteMany(ctx context.Context, filter interface{}) (*mongo.DeleteResult, error) {
+	return mc.coll.DeleteMany(ctx, filter)
+}
+
+func (mc *mongoCollection) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	return mc.coll.CountDocuments(ctx, filter, opts...)
+}
+
+func (mc *mongoCollection) CreateIndex(ctx context.Context, model mongo.IndexModel) (string, error) {
+	return mc.coll.Indexes().CreateOne(ctx, model)
+}
+
+func (mc *mongoCollection) DropIndex(ctx context.Context, name string) error {
+	_, err := mc.coll.Indexes().DropOne(ctx, name)
+	return err
+}
+
 func (sr *mongoSingleResult) Decode(v interface{}) error {
 	return sr.sr.Decode(v)
 }
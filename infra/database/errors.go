@@ -0,0 +1,49 @@
+package database
+
+import (
+	"errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Códigos de erro do MongoDB para violação de índice único
+var duplicateKeyCodes = map[int]bool{
+	11000: true,
+	11001: true,
+	12582: true,
+}
+
+// IsDuplicateKeyCode indica se o código de um erro de escrita é de violação de índice único
+func IsDuplicateKeyCode(code int) bool {
+	return duplicateKeyCodes[code]
+}
+
+// Código do MongoDB para índice inexistente
+const indexNotFoundCode = 27
+
+// IsIndexNotFoundError indica se o erro é de remoção de um índice inexistente
+func IsIndexNotFoundError(err error) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Code == indexNotFoundCode
+}
+
+func IsDuplicateKeyError(err error) bool {
+	switch e := err.(type) {
+	case mongo.WriteException:
+		for _, we := range e.WriteErrors {
+			if duplicateKeyCodes[we.Code] {
+				return true
+			}
+		}
+	case mongo.BulkWriteException:
+		for _, we := range e.WriteErrors {
+			if duplicateKeyCodes[we.Code] {
+				return true
+			}
+		}
+	case mongo.CommandError:
+		return duplicateKeyCodes[int(e.Code)]
+	}
+
+	return false
+}
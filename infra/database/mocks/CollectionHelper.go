@@ -9,6 +9,8 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	mongo "go.mongodb.org/mongo-driver/mongo"
+
+	options "go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // CollectionHelper is an autogenerated mock type for the CollectionHelper type
@@ -16,6 +18,78 @@ type CollectionHelper struct {
 	mock.Mock
 }
 
+// CountDocuments provides a mock function with given fields: ctx, filter, opts
+func (_m *CollectionHelper) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, filter)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, ...*options.CountOptions) int64); ok {
+		r0 = rf(ctx, filter, opts...)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, interface{}, ...*options.CountOptions) error); ok {
+		r1 = rf(ctx, filter, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateIndex provides a mock function with given fields: ctx, model
+func (_m *CollectionHelper) CreateIndex(ctx context.Context, model mongo.IndexModel) (string, error) {
+	ret := _m.Called(ctx, model)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, mongo.IndexModel) string); ok {
+		r0 = rf(ctx, model)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, mongo.IndexModel) error); ok {
+		r1 = rf(ctx, model)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteMany provides a mock function with given fields: ctx, filter
+func (_m *CollectionHelper) DeleteMany(ctx context.Context, filter interface{}) (*mongo.DeleteResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 *mongo.DeleteResult
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}) *mongo.DeleteResult); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*mongo.DeleteResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, interface{}) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteOne provides a mock function with given fields: ctx, filter
 func (_m *CollectionHelper) DeleteOne(ctx context.Context, filter interface{}) (*mongo.DeleteResult, error) {
 	ret := _m.Called(ctx, filter)
@@ -39,13 +113,34 @@ func (_m *CollectionHelper) DeleteOne(ctx context.Context, filter interface{}) (
 	return r0, r1
 }
 
-// Find provides a mock function with given fields: _a0, _a1
-func (_m *CollectionHelper) Find(_a0 context.Context, _a1 interface{}) (database.CursorHelper, error) {
-	ret := _m.Called(_a0, _a1)
+// DropIndex provides a mock function with given fields: ctx, name
+func (_m *CollectionHelper) DropIndex(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Find provides a mock function with given fields: ctx, filter, opts
+func (_m *CollectionHelper) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (database.CursorHelper, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, filter)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	var r0 database.CursorHelper
-	if rf, ok := ret.Get(0).(func(context.Context, interface{}) database.CursorHelper); ok {
-		r0 = rf(_a0, _a1)
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, ...*options.FindOptions) database.CursorHelper); ok {
+		r0 = rf(ctx, filter, opts...)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(database.CursorHelper)
@@ -53,8 +148,8 @@ func (_m *CollectionHelper) Find(_a0 context.Context, _a1 interface{}) (database
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, interface{}) error); ok {
-		r1 = rf(_a0, _a1)
+	if rf, ok := ret.Get(1).(func(context.Context, interface{}, ...*options.FindOptions) error); ok {
+		r1 = rf(ctx, filter, opts...)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -62,13 +157,20 @@ func (_m *CollectionHelper) Find(_a0 context.Context, _a1 interface{}) (database
 	return r0, r1
 }
 
-// FindOne provides a mock function with given fields: _a0, _a1
-func (_m *CollectionHelper) FindOne(_a0 context.Context, _a1 interface{}) database.SingleResultHelper {
-	ret := _m.Called(_a0, _a1)
+// FindOne provides a mock function with given fields: ctx, filter, opts
+func (_m *CollectionHelper) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) database.SingleResultHelper {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, filter)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	var r0 database.SingleResultHelper
-	if rf, ok := ret.Get(0).(func(context.Context, interface{}) database.SingleResultHelper); ok {
-		r0 = rf(_a0, _a1)
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, ...*options.FindOneOptions) database.SingleResultHelper); ok {
+		r0 = rf(ctx, filter, opts...)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(database.SingleResultHelper)
@@ -78,6 +180,36 @@ func (_m *CollectionHelper) FindOne(_a0 context.Context, _a1 interface{}) databa
 	return r0
 }
 
+// InsertMany provides a mock function with given fields: ctx, documents, opts
+func (_m *CollectionHelper) InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, documents)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *mongo.InsertManyResult
+	if rf, ok := ret.Get(0).(func(context.Context, []interface{}, ...*options.InsertManyOptions) *mongo.InsertManyResult); ok {
+		r0 = rf(ctx, documents, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*mongo.InsertManyResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []interface{}, ...*options.InsertManyOptions) error); ok {
+		r1 = rf(ctx, documents, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // InsertOne provides a mock function with given fields: _a0, _a1
 func (_m *CollectionHelper) InsertOne(_a0 context.Context, _a1 interface{}) (*mongo.InsertOneResult, error) {
 	ret := _m.Called(_a0, _a1)
@@ -100,3 +232,93 @@ func (_m *CollectionHelper) InsertOne(_a0 context.Context, _a1 interface{}) (*mo
 
 	return r0, r1
 }
+
+// ReplaceOne provides a mock function with given fields: ctx, filter, replacement, opts
+func (_m *CollectionHelper) ReplaceOne(ctx context.Context, filter interface{}, replacement interface{}, opts ...*options.ReplaceOptions) (*mongo.UpdateResult, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, filter, replacement)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *mongo.UpdateResult
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, interface{}, ...*options.ReplaceOptions) *mongo.UpdateResult); ok {
+		r0 = rf(ctx, filter, replacement, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*mongo.UpdateResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, interface{}, interface{}, ...*options.ReplaceOptions) error); ok {
+		r1 = rf(ctx, filter, replacement, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateMany provides a mock function with given fields: ctx, filter, update, opts
+func (_m *CollectionHelper) UpdateMany(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, filter, update)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *mongo.UpdateResult
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, interface{}, ...*options.UpdateOptions) *mongo.UpdateResult); ok {
+		r0 = rf(ctx, filter, update, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*mongo.UpdateResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, interface{}, interface{}, ...*options.UpdateOptions) error); ok {
+		r1 = rf(ctx, filter, update, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateOne provides a mock function with given fields: ctx, filter, update, opts
+func (_m *CollectionHelper) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, filter, update)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *mongo.UpdateResult
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, interface{}, ...*options.UpdateOptions) *mongo.UpdateResult); ok {
+		r0 = rf(ctx, filter, update, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*mongo.UpdateResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, interface{}, interface{}, ...*options.UpdateOptions) error); ok {
+		r1 = rf(ctx, filter, update, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
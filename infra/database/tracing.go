@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+
+	"b2w/swapi-challenge/infra/tracing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "b2w/swapi-challenge/infra/database"
+
+type tracedDatabase struct {
+	next DatabaseHelper
+}
+
+type tracedCollection struct {
+	next CollectionHelper
+	name string
+}
+
+// NewTracedDatabase cria um span para cada chamada às coleções do banco de dados
+func NewTracedDatabase(db DatabaseHelper) DatabaseHelper {
+	return &tracedDatabase{next: db}
+}
+
+func (td *tracedDatabase) Collection(name string) CollectionHelper {
+	return &tracedCollection{next: td.next.Collection(name), name: name}
+}
+
+func (td *tracedDatabase) Client() ClientHelper {
+	return td.next.Client()
+}
+
+func (tc *tracedCollection) start(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, tracerName, "mongo."+tc.name+"."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemMongoDB,
+			semconv.DBOperationKey.String(operation),
+			semconv.DBMongoDBCollectionKey.String(tc.name),
+		),
+	)
+}
+
+func (tc *tracedCollection) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (CursorHelper, error) {
+	ctx, span := tc.start(ctx, "find")
+	cursor, err := tc.next.Find(ctx, filter, opts...)
+	tracing.End(span, err)
+	return cursor, err
+}
+
+// O erro do FindOne só é conhecido no Decode, então o span termina nele
+func (tc *tracedCollection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) SingleResultHelper {
+	ctx, span := tc.start(ctx, "findOne")
+	return &tracedSingleResult{next: tc.next.FindOne(ctx, filter, opts...), span: span}
+}
+
+func (tc *tracedCollection) InsertOne(ctx context.Context, document interface{}) (*mongo.InsertOneResult, error) {
+	ctx, span := tc.start(ctx, "insertOne")
+	res, err := tc.next.InsertOne(ctx, document)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (tc *tracedCollection) InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+	ctx, span := tc.start(ctx, "insertMany")
+	span.SetAttributes(attribute.Int("db.mongodb.documents", len(documents)))
+	res, err := tc.next.InsertMany(ctx, documents, opts...)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (tc *tracedCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	ctx, span := tc.start(ctx, "updateOne")
+	res, err := tc.next.UpdateOne(ctx, filter, update, opts...)
+	if res != nil {
+		span.SetAttributes(attribute.Int64("db.mongodb.matched_count", res.MatchedCount))
+	}
+	tracing.End(span, err)
+	return res, err
+}
+
+func (tc *tracedCollection) UpdateMany(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	ctx, span := tc.start(ctx, "updateMany")
+	res, err := tc.next.UpdateMany(ctx, filter, update, opts...)
+	if res != nil {
+		span.SetAttributes(
+			attribute.Int64("db.mongodb.matched_count", res.MatchedCount),
+			attribute.Int64("db.mongodb.modified_count", res.ModifiedCount),
+		)
+	}
+	tracing.End(span, err)
+	return res, err
+}
+
+func (tc *tracedCollection) ReplaceOne(ctx context.Context, filter interface{}, replacement interface{}, opts ...*options.ReplaceOptions) (*mongo.UpdateResult, error) {
+	ctx, span := tc.start(ctx, "replaceOne")
+	res, err := tc.next.ReplaceOne(ctx, filter, replacement, opts...)
+	if res != nil {
+		span.SetAttributes(attribute.Int64("db.mongodb.matched_count", res.MatchedCount))
+	}
+	tracing.End(span, err)
+	return res, err
+}
+
+func (tc *tracedCollection) DeleteOne(ctx context.Context, filter interface{}) (*mongo.DeleteResult, error) {
+	ctx, span := tc.start(ctx, "deleteOne")
+	res, err := tc.next.DeleteOne(ctx, filter)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (tc *tracedCollection) DeleteMany(ctx context.Context, filter interface{}) (*mongo.DeleteResult, error) {
+	ctx, span := tc.start(ctx, "deleteMany")
+	res, err := tc.next.DeleteMany(ctx, filter)
+	if res != nil {
+		span.SetAttributes(attribute.Int64("db.mongodb.deleted_count", res.DeletedCount))
+	}
+	tracing.End(span, err)
+	return res, err
+}
+
+func (tc *tracedCollection) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	ctx, span := tc.start(ctx, "countDocuments")
+	count, err := tc.next.CountDocuments(ctx, filter, opts...)
+	tracing.End(span, err)
+	return count, err
+}
+
+func (tc *tracedCollection) CreateIndex(ctx context.Context, model mongo.IndexModel) (string, error) {
+	ctx, span := tc.start(ctx, "createIndex")
+	name, err := tc.next.CreateIndex(ctx, model)
+	tracing.End(span, err)
+	return name, err
+}
+
+func (tc *tracedCollection) DropIndex(ctx context.Context, name string) error {
+	ctx, span := tc.start(ctx, "dropIndex")
+	err := tc.next.DropIndex(ctx, name)
+	tracing.End(span, err)
+	return err
+}
+
+type tracedSingleResult struct {
+	next SingleResultHelper
+	span trace.Span
+}
+
+func (ts *tracedSingleResult) Decode(v interface{}) error {
+	err := ts.next.Decode(v)
+
+	// Documento não encontrado é um resultado esperado, não uma falha
+	spanErr := err
+	if err == mongo.ErrNoDocuments {
+		ts.span.SetAttributes(attribute.Bool("db.mongodb.found", false))
+		spanErr = nil
+	}
+	tracing.End(ts.span, spanErr)
+
+	return err
+}
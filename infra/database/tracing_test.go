@@ -0,0 +1,91 @@
+package database_test
+
+import (
+	"b2w/swapi-challenge/infra/database"
+	"b2w/swapi-challenge/infra/database/mocks"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracedDatabase(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	dbHelper := &mocks.DatabaseHelper{}
+	collectionHelper := &mocks.CollectionHelper{}
+	singleResultHelper := &mocks.SingleResultHelper{}
+
+	dbHelper.
+		On("Collection", "planets").
+		Return(collectionHelper)
+
+	collectionHelper.
+		On("InsertOne", mock.Anything, mock.Anything).
+		Return(nil, errors.New("insert error"))
+
+	collectionHelper.
+		On("FindOne", mock.Anything, bson.M{"name": "Kamino"}).
+		Return(singleResultHelper)
+
+	singleResultHelper.
+		On("Decode", mock.Anything).
+		Return(mongo.ErrNoDocuments)
+
+	collectionHelper.
+		On("InsertMany", mock.Anything, mock.Anything, mock.Anything).
+		Return(&mongo.InsertManyResult{InsertedIDs: []interface{}{1, 2}}, nil)
+
+	collectionHelper.
+		On("UpdateMany", mock.Anything, mock.Anything, mock.Anything).
+		Return(&mongo.UpdateResult{MatchedCount: 2, ModifiedCount: 2}, nil)
+
+	db := database.NewTracedDatabase(dbHelper)
+	collection := db.Collection("planets")
+
+	// Testing failed operations are recorded as errors
+	_, err := collection.InsertOne(context.Background(), bson.M{"name": "Tatooine"})
+	assert.NotNil(t, err)
+
+	// Testing documents not found are not recorded as errors
+	err = collection.FindOne(context.Background(), bson.M{"name": "Kamino"}).Decode(&bson.M{})
+	assert.Equal(t, mongo.ErrNoDocuments, err)
+
+	// Testing bulk operations
+	_, err = collection.InsertMany(context.Background(), []interface{}{bson.M{"name": "Tatooine"}, bson.M{"name": "Kamino"}})
+	assert.Nil(t, err)
+
+	_, err = collection.UpdateMany(context.Background(), bson.M{}, bson.M{"$set": bson.M{"climate": "arid"}})
+	assert.Nil(t, err)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 4)
+
+	assert.Equal(t, "mongo.planets.insertOne", spans[0].Name())
+	assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Contains(t, spans[0].Attributes(), attribute.String("db.system", "mongodb"))
+	assert.Contains(t, spans[0].Attributes(), attribute.String("db.mongodb.collection", "planets"))
+
+	assert.Equal(t, "mongo.planets.findOne", spans[1].Name())
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+	assert.Contains(t, spans[1].Attributes(), attribute.Bool("db.mongodb.found", false))
+
+	assert.Equal(t, "mongo.planets.insertMany", spans[2].Name())
+	assert.Contains(t, spans[2].Attributes(), attribute.Int("db.mongodb.documents", 2))
+
+	assert.Equal(t, "mongo.planets.updateMany", spans[3].Name())
+	assert.Contains(t, spans[3].Attributes(), attribute.Int64("db.mongodb.modified_count", 2))
+}
@@ -0,0 +1,121 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+	StatusUp       = "up"
+	StatusDown     = "down"
+)
+
+// Dependency é um serviço externo verificado antes da aplicação ser considerada
+// pronta. As dependências opcionais aparecem no relatório, mas não tornam a
+// aplicação não pronta quando estão fora do ar.
+type Dependency struct {
+	Name     string
+	Check    func(ctx context.Context) error
+	Optional bool
+}
+
+type DependencyReport struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+	Optional  bool    `json:"optional,omitempty"`
+}
+
+type Report struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyReport `json:"dependencies"`
+}
+
+func (r Report) Ready() bool {
+	return r.Status == StatusReady
+}
+
+// Readiness indica se a aplicação pode receber tráfego. Ela deixa de estar
+// pronta no início do desligamento, antes das conexões serem drenadas.
+type Readiness struct {
+	ready        int32
+	timeout      time.Duration
+	dependencies []Dependency
+}
+
+func NewReadiness(timeout time.Duration, deps ...Dependency) *Readiness {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+
+	return &Readiness{
+		timeout:      timeout,
+		dependencies: deps,
+	}
+}
+
+func (r *Readiness) SetReady(ready bool) {
+	var value int32
+	if ready {
+		value = 1
+	}
+	atomic.StoreInt32(&r.ready, value)
+}
+
+func (r *Readiness) Ready() bool {
+	return atomic.LoadInt32(&r.ready) == 1
+}
+
+// Check verifica todas as dependências em paralelo, cada uma limitada ao
+// timeout configurado. Durante a inicialização e o desligamento nenhuma
+// dependência é consultada.
+func (r *Readiness) Check(ctx context.Context) Report {
+	report := Report{Status: StatusNotReady, Dependencies: make(map[string]DependencyReport)}
+	if !r.Ready() {
+		return report
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, dep := range r.dependencies {
+		wg.Add(1)
+		go func(dep Dependency) {
+			defer wg.Done()
+
+			depReport := r.checkDependency(ctx, dep)
+
+			mu.Lock()
+			report.Dependencies[dep.Name] = depReport
+			mu.Unlock()
+		}(dep)
+	}
+	wg.Wait()
+
+	report.Status = StatusReady
+	for _, depReport := range report.Dependencies {
+		if depReport.Status != StatusUp && !depReport.Optional {
+			report.Status = StatusNotReady
+		}
+	}
+
+	return report
+}
+
+func (r *Readiness) checkDependency(ctx context.Context, dep Dependency) DependencyReport {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := dep.Check(ctx)
+	latency := float64(time.Since(start).Microseconds()) / 1000
+
+	if err != nil {
+		return DependencyReport{Status: StatusDown, LatencyMs: latency, Error: err.Error(), Optional: dep.Optional}
+	}
+
+	return DependencyReport{Status: StatusUp, LatencyMs: latency, Optional: dep.Optional}
+}
@@ -0,0 +1,58 @@
+package health_test
+
+import (
+	"b2w/swapi-challenge/infra/health"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadinessCheck(t *testing.T) {
+	up := health.Dependency{Name: "up", Check: func(ctx context.Context) error { return nil }}
+	down := health.Dependency{Name: "down", Check: func(ctx context.Context) error { return errors.New("ping error") }}
+	slow := health.Dependency{Name: "slow", Check: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	// Testing not ready before startup finishes, without checking dependencies
+	readiness := health.NewReadiness(time.Second, down)
+	report := readiness.Check(context.Background())
+	assert.False(t, report.Ready())
+	assert.Equal(t, 0, len(report.Dependencies))
+
+	// Testing all dependencies up
+	readiness = health.NewReadiness(time.Second, up)
+	readiness.SetReady(true)
+	report = readiness.Check(context.Background())
+	assert.True(t, report.Ready())
+	assert.Equal(t, health.StatusUp, report.Dependencies["up"].Status)
+
+	// Testing failing and slow dependencies
+	readiness = health.NewReadiness(10*time.Millisecond, up, down, slow)
+	readiness.SetReady(true)
+
+	start := time.Now()
+	report = readiness.Check(context.Background())
+	assert.True(t, time.Since(start) < time.Second)
+	assert.False(t, report.Ready())
+	assert.Equal(t, health.StatusUp, report.Dependencies["up"].Status)
+	assert.Equal(t, health.StatusDown, report.Dependencies["down"].Status)
+	assert.Equal(t, "ping error", report.Dependencies["down"].Error)
+	assert.Equal(t, health.StatusDown, report.Dependencies["slow"].Status)
+	assert.True(t, report.Dependencies["slow"].LatencyMs >= 10)
+
+	// Testing optional dependency down reported without affecting the status
+	optional := health.Dependency{Name: "optional", Check: down.Check, Optional: true}
+	readiness = health.NewReadiness(time.Second, up, optional)
+	readiness.SetReady(true)
+
+	report = readiness.Check(context.Background())
+	assert.True(t, report.Ready())
+	assert.Equal(t, health.StatusDown, report.Dependencies["optional"].Status)
+	assert.True(t, report.Dependencies["optional"].Optional)
+	assert.False(t, report.Dependencies["up"].Optional)
+}
@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type requestIDKey struct{}
+
+// New cria um logger que escreve uma linha JSON por evento, com data e nível.
+// Níveis desconhecidos resultam no nível info.
+func New(out io.Writer, level string) zerolog.Logger {
+	lvl, err := zerolog.ParseLevel(strings.ToLower(strings.TrimSpace(level)))
+	if err != nil || lvl == zerolog.NoLevel {
+		lvl = zerolog.InfoLevel
+	}
+
+	return zerolog.New(out).Level(lvl).With().Timestamp().Logger()
+}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Ctx retorna o logger acrescido do request ID e do trace presentes no contexto,
+// de forma que os eventos de uma mesma requisição possam ser correlacionados
+func Ctx(ctx context.Context, log zerolog.Logger) *zerolog.Logger {
+	if id := RequestID(ctx); id != "" {
+		log = log.With().Str("request_id", id).Logger()
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		log = log.With().Str("trace_id", sc.TraceID().String()).Logger()
+	}
+
+	return &log
+}
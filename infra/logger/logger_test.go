@@ -0,0 +1,59 @@
+package logger_test
+
+import (
+	"b2w/swapi-challenge/infra/logger"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestLogger(t *testing.T) {
+	var out bytes.Buffer
+	log := logger.New(&out, "warn")
+
+	// Testing events below the configured level are discarded
+	log.Info().Msg("ignored")
+	assert.Equal(t, 0, out.Len())
+
+	// Testing json output with the request id from the context
+	ctx := logger.WithRequestID(context.Background(), "abc123")
+	logger.Ctx(ctx, log).Warn().Str("planet_id", "1").Msg("something happened")
+
+	var line map[string]interface{}
+	err := json.Unmarshal(out.Bytes(), &line)
+	assert.Nil(t, err)
+	assert.Equal(t, "warn", line["level"])
+	assert.Equal(t, "abc123", line["request_id"])
+	assert.Equal(t, "1", line["planet_id"])
+	assert.Equal(t, "something happened", line["message"])
+	assert.NotNil(t, line["time"])
+
+	// Testing context without request id
+	out.Reset()
+	logger.Ctx(context.Background(), log).Error().Msg("no request")
+	err = json.Unmarshal(out.Bytes(), &line)
+	assert.Nil(t, err)
+	assert.Equal(t, "error", line["level"])
+
+	// Testing the trace id from the span in the context
+	out.Reset()
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+	logger.Ctx(ctx, log).Warn().Msg("traced")
+	line = nil
+	err = json.Unmarshal(out.Bytes(), &line)
+	assert.Nil(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", line["trace_id"])
+	assert.Equal(t, "abc123", line["request_id"])
+
+	// Testing unknown level falls back to info
+	out.Reset()
+	verbose := logger.New(&out, "verbose")
+	verbose.Debug().Msg("ignored")
+	assert.Equal(t, 0, out.Len())
+}
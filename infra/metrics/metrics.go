@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog"
+)
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total de requisições HTTP por rota, método e status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duração das requisições HTTP por rota, método e status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	MongoOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mongo_operation_duration_seconds",
+		Help:    "Duração das operações no MongoDB por operação do repositório.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	SWApiRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "swapi_request_duration_seconds",
+		Help:    "Duração das consultas à SWAPI por operação.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	SWApiRequestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "swapi_request_errors_total",
+		Help: "Total de consultas à SWAPI que falharam por operação.",
+	}, []string{"operation"})
+
+	SWApiCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "swapi_cache_lookups_total",
+		Help: "Total de consultas ao cache da SWAPI por resultado (hit, negative_hit ou miss).",
+	}, []string{"result"})
+)
+
+// Since registra no histograma o tempo decorrido desde start. Pensada para
+// ser usada com defer no início da operação medida.
+func Since(observer prometheus.Observer, start time.Time) {
+	observer.Observe(time.Since(start).Seconds())
+}
+
+// RegisterStoredPlanets registra o gauge de planetas salvos, calculado a cada coleta
+func RegisterStoredPlanets(count func(ctx context.Context) (int64, error), log zerolog.Logger) {
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "planets_stored",
+		Help: "Quantidade de planetas salvos, sem contar os que estão na lixeira.",
+	}, func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		total, err := count(ctx)
+		if err != nil {
+			log.Warn().Err(err).Str("metric", "planets_stored").Msg("metric collection failed")
+			return math.NaN()
+		}
+
+		return float64(total)
+	}))
+}
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
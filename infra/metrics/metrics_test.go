@@ -0,0 +1,25 @@
+package metrics_test
+
+import (
+	"b2w/swapi-challenge/infra/metrics"
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoredPlanets(t *testing.T) {
+	metrics.RegisterStoredPlanets(func(ctx context.Context) (int64, error) {
+		return 3, nil
+	}, zerolog.Nop())
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, err := ioutil.ReadAll(rec.Body)
+	assert.Nil(t, err)
+	assert.Contains(t, string(body), "planets_stored 3")
+}
@@ -0,0 +1,235 @@
+package swapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound    = errors.New("swapi: resource not found")
+	ErrCircuitOpen = errors.New("swapi: circuit breaker is open")
+)
+
+// StatusError representa uma resposta da SWAPI com status diferente de 200
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("swapi: unexpected status %d from %s", e.StatusCode, e.URL)
+}
+
+func (e *StatusError) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == http.StatusNotFound
+}
+
+// Options controla as novas tentativas e o circuit breaker do cliente.
+// Valores zerados desativam o recurso correspondente.
+type Options struct {
+	MaxRetries       int
+	BaseBackoff      time.Duration
+	MaxBackoff       time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+type Client struct {
+	baseUrl    string
+	httpClient *http.Client
+	options    Options
+	breaker    *circuitBreaker
+}
+
+func NewClient(baseUrl string, httpClient *http.Client, opts Options) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		baseUrl:    strings.TrimSuffix(baseUrl, "/"),
+		httpClient: httpClient,
+		options:    opts,
+		breaker:    newCircuitBreaker(opts.BreakerThreshold, opts.BreakerCooldown),
+	}
+}
+
+// SearchPlanets busca planetas pelo nome, percorrendo todas as páginas do resultado
+func (c *Client) SearchPlanets(ctx context.Context, search string) ([]Planet, error) {
+	pageUrl := fmt.Sprintf("%s/planets/?search=%s", c.baseUrl, url.QueryEscape(search))
+
+	var planets []Planet
+	for pageUrl != "" {
+		var page PlanetPage
+		if err := c.get(ctx, pageUrl, &page); err != nil {
+			return nil, err
+		}
+
+		planets = append(planets, page.Results...)
+
+		pageUrl = ""
+		if page.Next != nil {
+			pageUrl = *page.Next
+		}
+	}
+
+	return planets, nil
+}
+
+// FindPlanetByName retorna o planeta cujo nome é exatamente o informado,
+// sem diferenciar maiúsculas e minúsculas
+func (c *Client) FindPlanetByName(ctx context.Context, name string) (Planet, error) {
+	name = strings.TrimSpace(name)
+
+	planets, err := c.SearchPlanets(ctx, name)
+	if err != nil {
+		return Planet{}, err
+	}
+
+	for _, p := range planets {
+		if strings.EqualFold(strings.TrimSpace(p.Name), name) {
+			return p, nil
+		}
+	}
+
+	return Planet{}, ErrNotFound
+}
+
+// GetPlanetPage busca uma página da lista de planetas, começando pela página 1
+func (c *Client) GetPlanetPage(ctx context.Context, page int) (PlanetPage, error) {
+	var p PlanetPage
+	err := c.get(ctx, fmt.Sprintf("%s/planets/?page=%d", c.baseUrl, page), &p)
+	return p, err
+}
+
+func (c *Client) GetPlanet(ctx context.Context, id int) (Planet, error) {
+	var p Planet
+	err := c.get(ctx, fmt.Sprintf("%s/planets/%d/", c.baseUrl, id), &p)
+	return p, err
+}
+
+func (c *Client) GetFilm(ctx context.Context, id int) (Film, error) {
+	return c.GetFilmByUrl(ctx, fmt.Sprintf("%s/films/%d/", c.baseUrl, id))
+}
+
+// GetFilmByUrl busca um filme pela URL usada nas referências entre recursos da SWAPI
+func (c *Client) GetFilmByUrl(ctx context.Context, filmUrl string) (Film, error) {
+	var f Film
+	err := c.get(ctx, filmUrl, &f)
+	return f, err
+}
+
+func (c *Client) GetPerson(ctx context.Context, id int) (Person, error) {
+	var p Person
+	err := c.get(ctx, fmt.Sprintf("%s/people/%d/", c.baseUrl, id), &p)
+	return p, err
+}
+
+// Ping verifica se a SWAPI está acessível com uma única requisição à raiz da
+// API, sem novas tentativas e sem passar pelo circuit breaker
+func (c *Client) Ping(ctx context.Context) error {
+	var root map[string]string
+	return c.doGet(ctx, c.baseUrl+"/", &root)
+}
+
+func (c *Client) get(ctx context.Context, resourceUrl string, v interface{}) error {
+	if !c.breaker.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := c.getWithRetry(ctx, resourceUrl, v)
+	if err != nil && ctx.Err() != nil {
+		// O chamador desistiu antes da resposta, que não diz nada sobre a SWAPI
+		c.breaker.release()
+		return err
+	}
+	c.breaker.record(isRetryable(err))
+
+	return err
+}
+
+func (c *Client) getWithRetry(ctx context.Context, resourceUrl string, v interface{}) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = c.doGet(ctx, resourceUrl, v)
+		if !isRetryable(err) || attempt >= c.options.MaxRetries {
+			return err
+		}
+
+		timer := time.NewTimer(c.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// backoff calcula a espera exponencial da tentativa, com jitter para
+// evitar que vários clientes tentem novamente ao mesmo tempo
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.options.BaseBackoff << uint(attempt)
+	if delay <= 0 || (c.options.MaxBackoff > 0 && delay > c.options.MaxBackoff) {
+		delay = c.options.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+func (c *Client) doGet(ctx context.Context, resourceUrl string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, resourceUrl, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	response, err := c.httpClient.Do(req)
+	if err != nil {
+		// O cancelamento pelo chamador não indica indisponibilidade da SWAPI
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return &networkError{err: err}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return &StatusError{URL: resourceUrl, StatusCode: response.StatusCode}
+	}
+
+	return json.NewDecoder(response.Body).Decode(v)
+}
+
+type networkError struct {
+	err error
+}
+
+func (e *networkError) Error() string { return e.err.Error() }
+func (e *networkError) Unwrap() error { return e.err }
+
+// Apenas falhas de rede e erros 5xx indicam indisponibilidade da SWAPI
+func isRetryable(err error) bool {
+	var netErr *networkError
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	return false
+}
@@ -0,0 +1,345 @@
+package swapi_test
+
+import (
+	"b2w/swapi-challenge/infra/swapi"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSwapiServer() *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	mux.HandleFunc("/planets/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.URL.Path == "/planets/1/":
+			fmt.Fprint(w, `{"name":"Tatooine","climate":"arid","terrain":"desert","films":["f1","f2"]}`)
+		case r.URL.Path != "/planets/":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"detail":"Not found"}`)
+		case r.URL.Query().Get("search") == "error":
+			w.WriteHeader(http.StatusInternalServerError)
+		case r.URL.Query().Get("search") == "invalid":
+			fmt.Fprint(w, `{"results":`)
+		case r.URL.Query().Get("search") == "alderaan" && r.URL.Query().Get("page") == "":
+			// A primeira página contém apenas um nome parecido
+			fmt.Fprintf(w, `{"count":2,"next":"%s/planets/?search=alderaan&page=2","previous":null,"results":[{"name":"Alderaan Minor","films":["f1"]}]}`, server.URL)
+		case r.URL.Query().Get("search") == "alderaan":
+			fmt.Fprint(w, `{"count":2,"next":null,"previous":"x","results":[{"name":"Alderaan","films":["f1","f2","f3"]}]}`)
+		default:
+			fmt.Fprint(w, `{"count":0,"next":null,"previous":null,"results":[]}`)
+		}
+	})
+
+	mux.HandleFunc("/films/1/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"title":"A New Hope","episode_id":4,"release_date":"1977-05-25"}`)
+	})
+
+	mux.HandleFunc("/people/1/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name":"Luke Skywalker","homeworld":"h1","films":["f1"]}`)
+	})
+
+	return server
+}
+
+func TestSearchPlanets(t *testing.T) {
+	ts := newSwapiServer()
+	defer ts.Close()
+
+	client := swapi.NewClient(ts.URL+"/", ts.Client(), swapi.Options{})
+
+	// Testing search following all pages
+	result, err := client.SearchPlanets(context.Background(), "alderaan")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(result))
+
+	// Testing search without results
+	result, err = client.SearchPlanets(context.Background(), "nothing")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(result))
+
+	// Testing status error
+	_, err = client.SearchPlanets(context.Background(), "error")
+	assert.NotNil(t, err)
+
+	var statusErr *swapi.StatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusInternalServerError, statusErr.StatusCode)
+	assert.False(t, errors.Is(err, swapi.ErrNotFound))
+
+	// Testing invalid json
+	_, err = client.SearchPlanets(context.Background(), "invalid")
+	assert.NotNil(t, err)
+}
+
+func TestFindPlanetByName(t *testing.T) {
+	ts := newSwapiServer()
+	defer ts.Close()
+
+	client := swapi.NewClient(ts.URL, ts.Client(), swapi.Options{})
+
+	// Testing exact match outside the first page
+	p, err := client.FindPlanetByName(context.Background(), " alderaan ")
+	assert.Nil(t, err)
+	assert.Equal(t, "Alderaan", p.Name)
+	assert.Equal(t, 3, len(p.Films))
+
+	// Testing planet not found
+	_, err = client.FindPlanetByName(context.Background(), "nothing")
+	assert.Equal(t, swapi.ErrNotFound, err)
+
+	// Testing status error
+	_, err = client.FindPlanetByName(context.Background(), "error")
+	assert.NotNil(t, err)
+	assert.NotEqual(t, swapi.ErrNotFound, err)
+}
+
+func TestGetResources(t *testing.T) {
+	ts := newSwapiServer()
+	defer ts.Close()
+
+	client := swapi.NewClient(ts.URL, ts.Client(), swapi.Options{})
+
+	// Testing get planet
+	p, err := client.GetPlanet(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "Tatooine", p.Name)
+	assert.Equal(t, "arid", p.Climate)
+
+	// Testing planet not found
+	_, err = client.GetPlanet(context.Background(), 999)
+	assert.True(t, errors.Is(err, swapi.ErrNotFound))
+
+	// Testing get film
+	f, err := client.GetFilm(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "A New Hope", f.Title)
+	assert.Equal(t, 4, f.EpisodeID)
+	assert.Equal(t, "1977-05-25", f.ReleaseDate)
+
+	// Testing get person
+	person, err := client.GetPerson(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "Luke Skywalker", person.Name)
+}
+
+func TestClientRetries(t *testing.T) {
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempt := atomic.AddInt32(&attempts, 1)
+		if r.URL.Path == "/planets/2/" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if attempt < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"name":"Tatooine"}`)
+	}))
+	defer ts.Close()
+
+	client := swapi.NewClient(ts.URL, ts.Client(), swapi.Options{
+		MaxRetries:  2,
+		BaseBackoff: time.Millisecond,
+		MaxBackoff:  5 * time.Millisecond,
+	})
+
+	// Testing success after retrying 5xx errors
+	p, err := client.GetPlanet(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "Tatooine", p.Name)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+
+	// Testing 4xx errors are not retried
+	atomic.StoreInt32(&attempts, 0)
+	_, err = client.GetPlanet(context.Background(), 2)
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
+
+func TestClientTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		fmt.Fprint(w, `{"name":"Tatooine"}`)
+	}))
+	defer ts.Close()
+
+	httpClient := ts.Client()
+	httpClient.Timeout = 5 * time.Millisecond
+	client := swapi.NewClient(ts.URL, httpClient, swapi.Options{MaxRetries: 1, BaseBackoff: time.Millisecond})
+
+	// Testing the request is aborted after the timeout
+	start := time.Now()
+	_, err := client.GetPlanet(context.Background(), 1)
+	assert.NotNil(t, err)
+	assert.True(t, time.Since(start) < 50*time.Millisecond)
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	failing := int32(1)
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `{"name":"Tatooine"}`)
+	}))
+	defer ts.Close()
+
+	client := swapi.NewClient(ts.URL, ts.Client(), swapi.Options{
+		BreakerThreshold: 2,
+		BreakerCooldown:  20 * time.Millisecond,
+	})
+
+	// Testing failures opening the circuit
+	_, err := client.GetPlanet(context.Background(), 1)
+	assert.NotEqual(t, swapi.ErrCircuitOpen, err)
+	_, err = client.GetPlanet(context.Background(), 1)
+	assert.NotEqual(t, swapi.ErrCircuitOpen, err)
+
+	// Testing fail fast while the circuit is open
+	_, err = client.GetPlanet(context.Background(), 1)
+	assert.Equal(t, swapi.ErrCircuitOpen, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+
+	// Testing trial call after the cooldown closing the circuit
+	atomic.StoreInt32(&failing, 0)
+	time.Sleep(30 * time.Millisecond)
+
+	p, err := client.GetPlanet(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "Tatooine", p.Name)
+
+	_, err = client.GetPlanet(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&attempts))
+}
+
+func TestClientCircuitBreakerCanceledTrial(t *testing.T) {
+	var blocking int32
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		if atomic.LoadInt32(&blocking) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	client := swapi.NewClient(ts.URL, ts.Client(), swapi.Options{
+		BreakerThreshold: 2,
+		BreakerCooldown:  20 * time.Millisecond,
+	})
+
+	_, err := client.GetPlanet(context.Background(), 1)
+	assert.NotEqual(t, swapi.ErrCircuitOpen, err)
+	_, err = client.GetPlanet(context.Background(), 1)
+	assert.NotEqual(t, swapi.ErrCircuitOpen, err)
+
+	// Testing trial call canceled by the caller does not close the circuit
+	time.Sleep(30 * time.Millisecond)
+	atomic.StoreInt32(&blocking, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = client.GetPlanet(ctx, 1)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	// Testing the next call is a new trial call, reopening the circuit on failure
+	atomic.StoreInt32(&blocking, 0)
+
+	_, err = client.GetPlanet(context.Background(), 1)
+	assert.NotNil(t, err)
+	assert.NotEqual(t, swapi.ErrCircuitOpen, err)
+
+	_, err = client.GetPlanet(context.Background(), 1)
+	assert.Equal(t, swapi.ErrCircuitOpen, err)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&attempts))
+}
+
+func TestClientCancellation(t *testing.T) {
+	var attempts int32
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	client := swapi.NewClient(ts.URL, ts.Client(), swapi.Options{
+		MaxRetries:       2,
+		BaseBackoff:      time.Millisecond,
+		BreakerThreshold: 1,
+		BreakerCooldown:  time.Minute,
+	})
+
+	// Testing the request is aborted when the caller gives up
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.GetPlanet(ctx, 1)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, time.Since(start) < time.Second)
+
+	// Testing cancellation is neither retried nor counted by the circuit breaker
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+
+	canceled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+
+	_, err = client.GetPlanet(canceled, 1)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.NotEqual(t, swapi.ErrCircuitOpen, err)
+}
+
+func TestClientPing(t *testing.T) {
+	var failing int32
+	var attempts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprintf(w, `{"planets":"%s/planets/"}`, "http://"+r.Host)
+	}))
+	defer ts.Close()
+
+	client := swapi.NewClient(ts.URL, ts.Client(), swapi.Options{MaxRetries: 2, BreakerThreshold: 1, BreakerCooldown: time.Minute})
+
+	// Testing reachable api
+	err := client.Ping(context.Background())
+	assert.Nil(t, err)
+
+	// Testing failures are neither retried nor counted by the circuit breaker
+	atomic.StoreInt32(&failing, 1)
+	atomic.StoreInt32(&attempts, 0)
+	err = client.Ping(context.Background())
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+
+	err = client.Ping(context.Background())
+	assert.NotNil(t, err)
+	assert.NotEqual(t, swapi.ErrCircuitOpen, err)
+}
@@ -0,0 +1,75 @@
+package swapi
+
+type PageInfo struct {
+	Count    int     `json:"count"`
+	Next     *string `json:"next"`
+	Previous *string `json:"previous"`
+}
+
+type Planet struct {
+	Name           string   `json:"name"`
+	RotationPeriod string   `json:"rotation_period"`
+	OrbitalPeriod  string   `json:"orbital_period"`
+	Diameter       string   `json:"diameter"`
+	Climate        string   `json:"climate"`
+	Gravity        string   `json:"gravity"`
+	Terrain        string   `json:"terrain"`
+	SurfaceWater   string   `json:"surface_water"`
+	Population     string   `json:"population"`
+	Residents      []string `json:"residents"`
+	Films          []string `json:"films"`
+	Created        string   `json:"created"`
+	Edited         string   `json:"edited"`
+	URL            string   `json:"url"`
+}
+
+type Film struct {
+	Title        string   `json:"title"`
+	EpisodeID    int      `json:"episode_id"`
+	OpeningCrawl string   `json:"opening_crawl"`
+	Director     string   `json:"director"`
+	Producer     string   `json:"producer"`
+	ReleaseDate  string   `json:"release_date"`
+	Characters   []string `json:"characters"`
+	Planets      []string `json:"planets"`
+	Starships    []string `json:"starships"`
+	Vehicles     []string `json:"vehicles"`
+	Species      []string `json:"species"`
+	Created      string   `json:"created"`
+	Edited       string   `json:"edited"`
+	URL          string   `json:"url"`
+}
+
+type Person struct {
+	Name      string   `json:"name"`
+	Height    string   `json:"height"`
+	Mass      string   `json:"mass"`
+	HairColor string   `json:"hair_color"`
+	SkinColor string   `json:"skin_color"`
+	EyeColor  string   `json:"eye_color"`
+	BirthYear string   `json:"birth_year"`
+	Gender    string   `json:"gender"`
+	Homeworld string   `json:"homeworld"`
+	Films     []string `json:"films"`
+	Species   []string `json:"species"`
+	Vehicles  []string `json:"vehicles"`
+	Starships []string `json:"starships"`
+	Created   string   `json:"created"`
+	Edited    string   `json:"edited"`
+	URL       string   `json:"url"`
+}
+
+type PlanetPage struct {
+	PageInfo
+	Results []Planet `json:"results"`
+}
+
+type FilmPage struct {
+	PageInfo
+	Results []Film `json:"results"`
+}
+
+type PersonPage struct {
+	PageInfo
+	Results []Person `json:"results"`
+}
@@ -0,0 +1,61 @@
+package tracing_test
+
+import (
+	"b2w/swapi-challenge/config"
+	"b2w/swapi-challenge/infra/tracing"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetup(t *testing.T) {
+	// Testing tracing disabled still propagates the w3c trace context
+	shutdown, err := tracing.Setup(context.Background(), config.Tracing{})
+	assert.Nil(t, err)
+	assert.Nil(t, shutdown(context.Background()))
+
+	headers := http.Header{}
+	headers.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(headers))
+
+	outgoing := http.Header{}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(outgoing))
+	assert.Equal(t, headers.Get("traceparent"), outgoing.Get("traceparent"))
+
+	// Testing unknown exporter
+	_, err = tracing.Setup(context.Background(), config.Tracing{Enabled: true, Exporter: "zipkin"})
+	assert.NotNil(t, err)
+
+	// Testing stdout exporter
+	shutdown, err = tracing.Setup(context.Background(), config.Tracing{Enabled: true, Exporter: tracing.ExporterStdout})
+	assert.Nil(t, err)
+	assert.Nil(t, shutdown(context.Background()))
+}
+
+func TestEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	// Testing span finished with error
+	_, span := provider.Tracer("test").Start(context.Background(), "failed")
+	tracing.End(span, errors.New("operation error"))
+
+	// Testing span finished without error
+	_, span = provider.Tracer("test").Start(context.Background(), "succeeded")
+	tracing.End(span, nil)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "operation error", spans[0].Status().Description)
+	assert.Len(t, spans[0].Events(), 1)
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}
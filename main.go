@@ -2,60 +2,409 @@ package main
 
 import (
 	"b2w/swapi-challenge/api"
+	"b2w/swapi-challenge/api/middleware"
 	"b2w/swapi-challenge/config"
 	"b2w/swapi-challenge/domain/entity/planet"
+	"b2w/swapi-challenge/infra/auth"
 	"b2w/swapi-challenge/infra/database"
+	"b2w/swapi-challenge/infra/health"
+	"b2w/swapi-challenge/infra/logger"
+	"b2w/swapi-challenge/infra/metrics"
+	"b2w/swapi-challenge/infra/swapi"
+	"b2w/swapi-challenge/infra/tracing"
 	"context"
-	"log"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/rs/zerolog"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+const (
+	serveCommand         = "serve"
+	importPlanetsCommand = "import-planets"
+	migrateNamesCommand  = "migrate-names"
 )
 
 func main() {
-	// Lendo as configurações
-	config.ReadConfig()
+	command := serveCommand
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+	if command != serveCommand && command != importPlanetsCommand && command != migrateNamesCommand {
+		fmt.Fprintf(os.Stderr, "unknown command %q\nusage: %s [%s|%s|%s]\n", command, os.Args[0], serveCommand, importPlanetsCommand, migrateNamesCommand)
+		os.Exit(2)
+	}
+
+	// O código de saída é definido depois que os deferidos terminam
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
+	// Lendo as configurações. O nível de log ainda não é conhecido, então o
+	// erro é registrado no nível padrão, na saída de erros.
+	if err := config.ReadConfig(); err != nil {
+		bootLog := logger.New(os.Stderr, "")
+		bootLog.Error().Err(err).Msg("could not read configuration")
+		exitCode = 1
+		return
+	}
+	log := logger.New(os.Stdout, config.Data.Log.Level)
+
+	// Configurando o envio dos spans
+	shutdownTracing, err := tracing.Setup(context.Background(), config.Data.Tracing)
+	if err != nil {
+		log.Error().Err(err).Msg("invalid tracing configuration")
+		exitCode = 1
+		return
+	}
+	defer flushTracing(shutdownTracing, log)
 
-	// Conectando com o banco de dados
+	// Conectando com o banco de dados. A partir daqui os erros encerram a
+	// aplicação pelo retorno, para que os deferidos desconectem o banco.
 	dbConfig := config.Data.Database
 	dbClient, err := database.NewClient(dbConfig)
 	if err != nil {
-		log.Fatalln(err)
+		log.Error().Err(err).Msg("invalid database configuration")
+		exitCode = 1
+		return
 	}
 
-	connectDatabaseClient(dbClient, dbConfig)
-	defer disconnectDatabaseClient(dbClient, dbConfig)
-	db := dbClient.Database(dbConfig.DBName)
+	if err := connectDatabaseClient(dbClient, dbConfig); err != nil {
+		log.Error().Err(err).Msg("could not connect to database")
+		exitCode = 1
+		return
+	}
+	defer func() {
+		if err := disconnectDatabaseClient(dbClient, dbConfig); err != nil {
+			log.Error().Err(err).Msg("could not disconnect from database")
+			exitCode = 1
+		}
+	}()
+	db := database.NewTracedDatabase(dbClient.Database(dbConfig.DBName))
 
 	// Criando os repositórios e gerenciadores
-	planetDbRepo := planet.NewMongoRepository(db)
-	planetSWApiRepo := planet.NewSWApiRepository()
-	planetManager := planet.NewManager(planetDbRepo, planetSWApiRepo)
+	planetDbRepo := planet.NewMongoRepository(db, log)
+	if command == migrateNamesCommand {
+		if err := runMigrateNames(planetDbRepo, log); err != nil {
+			log.Error().Err(err).Msg("planet names migration failed")
+			exitCode = 1
+		}
+		return
+	}
+
+	// Sem o índice único, criações concorrentes poderiam repetir um nome
+	if err := planetDbRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Error().Err(err).Msgf("could not create planet indexes, run the %s command to normalize the saved names", migrateNamesCommand)
+		exitCode = 1
+		return
+	}
+	metrics.RegisterStoredPlanets(planetDbRepo.Count, log)
+
+	swapiConfig := config.Data.SWApi
+	swapiHttpClient := &http.Client{
+		Timeout:   swapiConfig.Timeout,
+		Transport: otelhttp.NewTransport(http.DefaultTransport),
+	}
+	swapiClient := swapi.NewClient(swapiConfig.BaseUrl, swapiHttpClient, swapi.Options{
+		MaxRetries:       swapiConfig.MaxRetries,
+		BaseBackoff:      swapiConfig.BaseBackoff,
+		MaxBackoff:       swapiConfig.MaxBackoff,
+		BreakerThreshold: swapiConfig.BreakerThreshold,
+		BreakerCooldown:  swapiConfig.BreakerCooldown,
+	})
+	planetSWApiRepo := planet.NewSWApiRepository(swapiClient, log)
+	insertPolicy, err := planet.ParseInsertPolicy(config.Data.Planets.InsertPolicy)
+	if err != nil {
+		log.Error().Err(err).Msg("invalid planets configuration")
+		exitCode = 1
+		return
+	}
+	cachedSWApiRepo, err := withSWApiCache(planetSWApiRepo, db, log)
+	if err != nil {
+		log.Error().Err(err).Msg("could not create swapi cache indexes")
+		exitCode = 1
+		return
+	}
+	planetManager := planet.NewTracedManager(planet.NewManager(planetDbRepo, cachedSWApiRepo, planet.ManagerOptions{
+		InsertPolicy:     insertPolicy,
+		BatchConcurrency: config.Data.Planets.BatchConcurrency,
+	}, log))
+
+	// Importando o catálogo da SWAPI sem passar pelo cache, já que os dados atuais são esperados
+	catalogImporter := planet.NewCatalogImporter(planetManager, planetSWApiRepo, planet.CatalogOptions{
+		Concurrency: config.Data.Planets.ImportConcurrency,
+	}, log)
+	if command == importPlanetsCommand {
+		if err := runImportPlanets(catalogImporter, os.Stdout); err != nil {
+			log.Error().Err(err).Msg("planets import failed")
+			exitCode = 1
+		}
+		return
+	}
+	defer catalogImporter.Stop()
+
+	// Atualizando as aparições periodicamente, sem passar pelo cache
+	refresherConfig := config.Data.Refresher
+	planetRefresher := planet.NewRefresher(planetDbRepo, planetSWApiRepo, planet.RefresherOptions{
+		Interval:    refresherConfig.Interval,
+		Concurrency: refresherConfig.Concurrency,
+	}, log)
+	planetRefresher.Start()
+	defer planetRefresher.Stop()
+
+	// Removendo definitivamente os planetas que passaram do período de retenção na lixeira
+	trashConfig := config.Data.Trash
+	planetPurger := planet.NewPurger(planetDbRepo, planet.PurgerOptions{
+		Interval:  trashConfig.PurgeInterval,
+		Retention: trashConfig.Retention,
+	}, log)
+	planetPurger.Start()
+	defer planetPurger.Stop()
 
 	// Criando as rotas da API
-	router := api.SetupRouter(planetManager)
-	router.Run(config.Data.Server.Address)
+	readiness := newReadiness(dbClient, swapiClient)
+	corsOpts := middleware.CorsOptions(config.Data.Cors)
+	routerOpts := api.RouterOptions{
+		Refresher:       planetRefresher,
+		CatalogImporter: catalogImporter,
+		Readiness:       readiness,
+		Logger:          &log,
+		Cors:            &corsOpts,
+	}
+
+	authConfig := config.Data.Auth
+	if authConfig.Enabled {
+		routerOpts.Authenticator, err = auth.NewAuthenticator(authConfig)
+		if err != nil {
+			log.Error().Err(err).Msg("invalid auth configuration")
+			exitCode = 1
+			return
+		}
+		routerOpts.Auth = middleware.AuthOptions{PublicReads: authConfig.PublicReads}
+		if len(authConfig.APIKeys) == 0 && authConfig.JWT.HMACSecret == "" && authConfig.JWT.RSAPublicKeyFile == "" {
+			log.Warn().Msg("auth enabled without api keys or jwt keys, only public reads are accepted")
+		}
+	} else {
+		log.Warn().Msg("auth disabled, every client can create, change and remove planets")
+	}
+	if rateLimitConfig := config.Data.RateLimit; rateLimitConfig.Enabled {
+		trustedProxies, err := middleware.ParseTrustedProxies(rateLimitConfig.TrustedProxies)
+		if err != nil {
+			log.Error().Err(err).Msg("invalid rate limit configuration")
+			exitCode = 1
+			return
+		}
+		routerOpts.RateLimit = &middleware.RateLimitOptions{
+			Read:           middleware.RateLimit(rateLimitConfig.Read),
+			Write:          middleware.RateLimit(rateLimitConfig.Write),
+			Batch:          middleware.RateLimit(rateLimitConfig.Batch),
+			MaxClients:     rateLimitConfig.MaxClients,
+			TrustedProxies: trustedProxies,
+		}
+	}
+	router := api.SetupRouter(planetManager, routerOpts)
+
+	serverConfig := config.Data.Server
+	server := &http.Server{Addr: serverConfig.Address, Handler: router}
+
+	// Abrindo a porta antes de marcar a aplicação como pronta, para que uma
+	// porta ocupada encerre a aplicação com erro
+	listener, err := net.Listen("tcp", serverConfig.Address)
+	if err != nil {
+		log.Error().Err(err).Str("address", serverConfig.Address).Msg("server could not listen")
+		exitCode = 1
+		return
+	}
+
+	serverErr := make(chan error, 1)
+	go func() { serverErr <- server.Serve(listener) }()
+	readiness.SetReady(true)
+	log.Info().Str("address", serverConfig.Address).Msg("server started")
+
+	// Aguardando o sinal de desligamento. Ao retornar, os deferidos param os
+	// processos em segundo plano e, por último, desconectam o banco de dados.
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case sig := <-quit:
+		log.Info().Str("signal", sig.String()).Msg("shutdown signal received")
+	case err := <-serverErr:
+		// O servidor já parou, então não há requisições para drenar
+		readiness.SetReady(false)
+		log.Error().Err(err).Msg("server error")
+		exitCode = 1
+		return
+	}
+
+	if err := shutdownServer(server, readiness, serverConfig); err != nil {
+		// As requisições que não terminaram no drainTimeout foram interrompidas
+		log.Error().Err(err).Msg("server shutdown failed")
+		exitCode = 1
+		return
+	}
+	log.Info().Msg("server stopped")
+}
+
+// runImportPlanets importa o catálogo da SWAPI e escreve o resumo em out. A
+// importação é interrompida pelos sinais de desligamento, e as falhas de
+// planetas específicos também são reportadas como erro.
+func runImportPlanets(importer planet.CatalogImporter, out io.Writer) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+	go func() {
+		select {
+		case <-quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	result, err := importer.ImportCatalog(ctx)
+	fmt.Fprintf(out, "planets import summary: %d created, %d updated, %d skipped, %d failed\n",
+		result.Created, result.Updated, result.Skipped, result.Failed)
+	if err != nil {
+		return err
+	}
+	if result.Failed > 0 {
+		return fmt.Errorf("%d planets could not be imported", result.Failed)
+	}
+
+	return nil
+}
+
+// newReadiness define as dependências verificadas pelo /readyz
+func newReadiness(dbClient database.ClientHelper, swapiClient *swapi.Client) *health.Readiness {
+	healthConfig := config.Data.Health
+
+	deps := []health.Dependency{{
+		Name: "mongodb",
+		Check: func(ctx context.Context) error {
+			return dbClient.Ping(ctx, readpref.Primary())
+		},
+	}}
+	if healthConfig.CheckSWApi {
+		// A SWAPI só é necessária para criar planetas, então a indisponibilidade
+		// dela não deve tirar de todas as réplicas o tráfego das leituras
+		deps = append(deps, health.Dependency{Name: "swapi", Check: swapiClient.Ping, Optional: true})
+	}
+
+	return health.NewReadiness(healthConfig.Timeout, deps...)
+}
+
+// shutdownServer marca a aplicação como não pronta, aguarda os balanceadores
+// de carga perceberem a mudança e drena as requisições em andamento. Retorna
+// erro quando as requisições não terminam dentro do drainTimeout.
+func shutdownServer(server *http.Server, readiness *health.Readiness, serverConfig config.Server) error {
+	readiness.SetReady(false)
+	time.Sleep(serverConfig.ShutdownDelay)
+
+	drainTimeout := serverConfig.DrainTimeout
+	if drainTimeout == 0 {
+		drainTimeout = 15 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	return server.Shutdown(ctx)
+}
+
+// flushTracing envia os spans pendentes antes de a aplicação terminar
+func flushTracing(shutdown func(context.Context) error, log zerolog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("could not flush tracing spans")
+	}
+}
+
+// runMigrateNames normaliza os nomes salvos e cria o índice único de nomes.
+// Os planetas com nomes que colidem são registrados no log e precisam ser
+// renomeados ou removidos antes de uma nova execução.
+func runMigrateNames(repo interface {
+	MigrateNames(ctx context.Context) ([]planet.NameCollision, error)
+	EnsureIndexes(ctx context.Context) error
+}, log zerolog.Logger) error {
+	collisions, err := repo.MigrateNames(context.Background())
+	if err != nil {
+		return err
+	}
+
+	for _, collision := range collisions {
+		ids := make([]string, 0, len(collision.PlanetIDs))
+		for _, id := range collision.PlanetIDs {
+			ids = append(ids, id.Hex())
+		}
+		log.Error().
+			Str("name", collision.Name).
+			Strs("planet_ids", ids).
+			Strs("names", collision.Names).
+			Msg("planets with colliding names")
+	}
+	if len(collisions) > 0 {
+		return fmt.Errorf("%d planet names collide, rename or purge the colliding planets and run again", len(collisions))
+	}
+
+	return repo.EnsureIndexes(context.Background())
 }
 
-func connectDatabaseClient(dbClient database.ClientHelper, dbConfig config.Database) {
+func withSWApiCache(swapiRepo planet.SwapiRepository, db database.DatabaseHelper, log zerolog.Logger) (planet.SwapiRepository, error) {
+	cacheConfig := config.Data.Cache
+	if !cacheConfig.Enabled {
+		return swapiRepo, nil
+	}
+
+	var store planet.SwapiCacheStore
+	if cacheConfig.Persistent {
+		mongoStore := planet.NewMongoCacheStore(db)
+		if err := mongoStore.EnsureIndexes(context.Background()); err != nil {
+			return nil, err
+		}
+		store = mongoStore
+	}
+
+	return planet.NewCachedSWApiRepository(swapiRepo, store, planet.CacheOptions{
+		Size:        cacheConfig.Size,
+		TTL:         cacheConfig.TTL,
+		NegativeTTL: cacheConfig.NegativeTTL,
+	}, log), nil
+}
+
+func connectDatabaseClient(dbClient database.ClientHelper, dbConfig config.Database) error {
 	ctx, cancel := context.WithTimeout(context.Background(), dbConfig.ConnectionTimeout)
 	defer cancel()
 	err := dbClient.Connect(ctx)
 	if err != nil {
-		log.Fatalln(err)
+		return err
 	}
 
 	// Checar se realmente está conectado no banco
 	err = dbClient.Ping(ctx, readpref.Primary())
 	if err != nil {
-		log.Fatalln(err)
+		return fmt.Errorf("database is not reachable: %w", err)
 	}
+
+	return nil
 }
 
-func disconnectDatabaseClient(dbClient database.ClientHelper, dbConfig config.Database) {
+func disconnectDatabaseClient(dbClient database.ClientHelper, dbConfig config.Database) error {
 	ctx, cancel := context.WithTimeout(context.Background(), dbConfig.ConnectionTimeout)
 	defer cancel()
-	err := dbClient.Disconnect(ctx)
-	if err != nil {
-		log.Fatalln(err)
-	}
+
+	return dbClient.Disconnect(ctx)
 }